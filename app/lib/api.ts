@@ -46,7 +46,7 @@ export async function fetchListings(limit = 50): Promise<Listing[]> {
       next: { revalidate: 300 },
     });
     if (!res.ok) return [];
-    return (await res.json()) as Listing[];
+    return ((await res.json()) as { items: Listing[] }).items;
   } catch {
     return [];
   }
@@ -70,7 +70,7 @@ export async function fetchAuctions(limit = 20): Promise<Auction[]> {
       next: { revalidate: 30 },
     });
     if (!res.ok) return [];
-    return (await res.json()) as Auction[];
+    return ((await res.json()) as { items: Auction[] }).items;
   } catch {
     return [];
   }
@@ -25,8 +25,8 @@ async function loadRelated(excludeId: string): Promise<Auction[]> {
       next: { revalidate: 30 },
     });
     if (!res.ok) return [];
-    const data = (await res.json()) as Auction[];
-    return data.filter((a) => String(a.id) !== excludeId).slice(0, 3);
+    const data = (await res.json()) as { items: Auction[] };
+    return data.items.filter((a) => String(a.id) !== excludeId).slice(0, 3);
   } catch {
     return [];
   }
@@ -11,10 +11,12 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"maqzone/backend/internal/auth"
 	"maqzone/backend/internal/config"
 	"maqzone/backend/internal/db"
 	sqlc "maqzone/backend/internal/db/sqlc"
 	"maqzone/backend/internal/httpapi"
+	"maqzone/backend/internal/mailer"
 	"maqzone/backend/internal/scheduler"
 )
 
@@ -28,6 +30,15 @@ func main() {
 	zerolog.SetGlobalLevel(level)
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid configuration")
+	}
+
+	keys, err := auth.LoadKeySet(cfg.JWTSigningKeyFile, cfg.JWTVerificationKeyFiles, cfg.LegacyJWTSecret())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load JWT keys")
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -50,10 +61,16 @@ func main() {
 
 	server := httpapi.New(cfg, queries, log.Logger)
 	server.SetHub(hub)
-
-	// Start auction scheduler with hub for WS broadcasts
+	server.SetKeys(keys)
+	// Start auction scheduler with hub for WS broadcasts and like alerts
 	sched := scheduler.New(queries, log.Logger)
 	sched.SetBroadcaster(hub)
+	sched.SetNotifier(hub)
+	if cfg.SMTPHost != "" {
+		smtp := mailer.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+		server.SetMailer(smtp)
+		sched.SetMailer(smtp)
+	}
 	go sched.Start(ctx)
 
 	httpServer := &http.Server{
@@ -0,0 +1,25 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, prefix, secret_hash, scopes, created_by, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+WHERE prefix = ?;
+
+-- name: ListAPIKeys :many
+SELECT id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+ORDER BY created_at DESC, id DESC;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = datetime('now')
+WHERE id = ? AND revoked_at = ''
+RETURNING id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = datetime('now')
+WHERE id = ?;
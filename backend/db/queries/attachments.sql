@@ -0,0 +1,21 @@
+-- name: CreateItemAttachment :one
+INSERT INTO item_attachments (item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key)
+VALUES (?1, ?2, ?3, ?4,
+        (SELECT COALESCE(MAX(position) + 1, 0) FROM item_attachments WHERE item_type = ?1 AND item_id = ?2),
+        ?5, ?6, ?7, ?8)
+RETURNING id, item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key, created_at;
+
+-- name: GetItemAttachment :one
+SELECT id, item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key, created_at FROM item_attachments WHERE id = ?;
+
+-- name: ListItemAttachments :many
+SELECT id, item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key, created_at FROM item_attachments
+WHERE item_type = ? AND item_id = ?
+ORDER BY position, id;
+
+-- name: UpdateItemAttachment :one
+UPDATE item_attachments SET title = ?, visibility = ?, position = ? WHERE id = ?
+RETURNING id, item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key, created_at;
+
+-- name: DeleteItemAttachment :exec
+DELETE FROM item_attachments WHERE id = ?;
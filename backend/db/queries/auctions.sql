@@ -1,27 +1,30 @@
 -- name: ListActiveAuctions :many
 SELECT id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 WHERE status = 'active'
+  AND (visibility = 'public'
+       OR (visibility = 'invite_only'
+           AND EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = auctions.id AND ai.user_id = ?)))
 ORDER BY datetime(end_time) ASC
 LIMIT ?;
 
 -- name: GetAuction :one
 SELECT id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 WHERE id = ?;
 
 -- name: CreateAuction :one
 INSERT INTO auctions (title, description, location, current_bid, reserve_price, status, end_time, image_url,
                       start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct,
-                      auto_extend_minutes, auto_extend_window_minutes, price_visible)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+                      auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
           start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-          auto_extend_minutes, auto_extend_window_minutes, price_visible;
+          auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility;
 
 -- name: UpdateAuction :one
 UPDATE auctions
@@ -40,11 +43,12 @@ SET title = ?,
     buyer_premium_pct = ?,
     auto_extend_minutes = ?,
     auto_extend_window_minutes = ?,
-    price_visible = ?
+    price_visible = ?,
+    visibility = ?
 WHERE id = ?
 RETURNING id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
           start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-          auto_extend_minutes, auto_extend_window_minutes, price_visible;
+          auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility;
 
 -- name: DeleteAuction :exec
 DELETE FROM auctions WHERE id = ?;
@@ -52,7 +56,7 @@ DELETE FROM auctions WHERE id = ?;
 -- name: ListAllAuctions :many
 SELECT id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 ORDER BY created_at DESC
 LIMIT ?;
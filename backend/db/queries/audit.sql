@@ -0,0 +1,9 @@
+-- name: CreateAuditEntry :one
+INSERT INTO admin_audit_log (actor_user_id, actor_api_key_id, action, target_type, target_id,
+                             before_json, after_json, changes_json, request_id, ip)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, actor_user_id, actor_api_key_id, action, target_type, target_id,
+          before_json, after_json, changes_json, request_id, ip, created_at;
+
+-- ListAuditEntries and CountAuditEntries build their WHERE clause from
+-- optional filters and are hand-written in internal/db/sqlc/audit.sql.go.
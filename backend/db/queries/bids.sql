@@ -40,10 +40,21 @@ WHERE status = 'active'
 SELECT id, title, description, location, current_bid, reserve_price, status,
        end_time, image_url, created_at, start_time, sale_mode, fixed_price,
        min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 ORDER BY created_at DESC
 LIMIT ?;
 
 -- name: ExtendAuctionEndTime :exec
 UPDATE auctions SET end_time = ? WHERE id = ?;
+
+-- name: ListBidsForUser :many
+SELECT id, auction_id, user_id, amount, created_at
+FROM bids
+WHERE user_id = ?
+ORDER BY id;
+
+-- name: CountLeadingAuctions :one
+SELECT COUNT(*)
+FROM auctions
+WHERE highest_bidder_id = ? AND status IN ('active', 'scheduled');
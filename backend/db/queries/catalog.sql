@@ -0,0 +1,3 @@
+-- ListCatalogListings, ListCatalogAuctions, ListingFacets and AuctionFacets are
+-- built dynamically from CatalogFilter with keyset pagination on the sort key
+-- and id (see catalog.sql.go).
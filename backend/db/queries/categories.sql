@@ -0,0 +1,86 @@
+-- name: ListCategoriesWithCounts :many
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT c.id, c.name, c.slug, c.parent_id, c.position,
+       (SELECT COUNT(DISTINCT lc.listing_id)
+        FROM subtree s
+        JOIN listing_categories lc ON lc.category_id = s.id
+        JOIN listings l ON l.id = lc.listing_id
+        WHERE s.root_id = c.id AND l.status = 'active'),
+       (SELECT COUNT(DISTINCT ac.auction_id)
+        FROM subtree s
+        JOIN auction_categories ac ON ac.category_id = s.id
+        JOIN auctions a ON a.id = ac.auction_id
+        WHERE s.root_id = c.id AND a.status = 'active' AND a.visibility = 'public')
+FROM categories c
+ORDER BY c.parent_id, c.position, c.name;
+
+-- name: GetCategory :one
+SELECT id, name, slug, parent_id, position FROM categories WHERE id = ?;
+
+-- name: GetCategoryBySlug :one
+SELECT id, name, slug, parent_id, position FROM categories WHERE slug = ?;
+
+-- name: ListChildCategories :many
+SELECT id, name, slug, parent_id, position FROM categories WHERE parent_id = ? ORDER BY position, name;
+
+-- name: ListCategoryAncestors :many
+WITH RECURSIVE ancestors(id, depth) AS (
+  SELECT parent_id, 1 FROM categories WHERE id = ? AND parent_id != 0
+  UNION ALL
+  SELECT c.parent_id, a.depth + 1 FROM categories c JOIN ancestors a ON c.id = a.id WHERE c.parent_id != 0
+)
+SELECT c.id, c.name, c.slug, c.parent_id, c.position
+FROM ancestors a
+JOIN categories c ON c.id = a.id
+ORDER BY a.depth DESC;
+
+-- name: IsCategoryInSubtree :one
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE root_id = ? AND id = ?);
+
+-- name: CreateCategory :one
+INSERT INTO categories (name, slug, parent_id, position)
+VALUES (?, ?, ?, ?)
+RETURNING id, name, slug, parent_id, position;
+
+-- name: UpdateCategory :one
+UPDATE categories SET name = ?, slug = ?, parent_id = ?, position = ?
+WHERE id = ?
+RETURNING id, name, slug, parent_id, position;
+
+-- name: DeleteCategory :exec
+DELETE FROM categories WHERE id = ?;
+
+-- name: ListCategoriesForListing :many
+SELECT c.id, c.name, c.slug, c.parent_id, c.position
+FROM listing_categories lc
+JOIN categories c ON c.id = lc.category_id
+WHERE lc.listing_id = ?
+ORDER BY c.name;
+
+-- name: ListCategoriesForAuction :many
+SELECT c.id, c.name, c.slug, c.parent_id, c.position
+FROM auction_categories ac
+JOIN categories c ON c.id = ac.category_id
+WHERE ac.auction_id = ?
+ORDER BY c.name;
+
+-- name: ClearListingCategories :exec
+DELETE FROM listing_categories WHERE listing_id = ?;
+
+-- name: AddListingCategory :exec
+INSERT OR IGNORE INTO listing_categories (listing_id, category_id) VALUES (?, ?);
+
+-- name: ClearAuctionCategories :exec
+DELETE FROM auction_categories WHERE auction_id = ?;
+
+-- name: AddAuctionCategory :exec
+INSERT OR IGNORE INTO auction_categories (auction_id, category_id) VALUES (?, ?);
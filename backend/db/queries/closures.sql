@@ -0,0 +1,32 @@
+-- name: CreateClosureRequest :one
+INSERT INTO account_closure_requests (user_id, reason)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: GetClosureRequest :one
+SELECT * FROM account_closure_requests
+WHERE id = ?;
+
+-- name: GetLatestClosureRequest :one
+SELECT * FROM account_closure_requests
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListClosureRequestsByStatus :many
+SELECT * FROM account_closure_requests
+WHERE status = ?
+ORDER BY id
+LIMIT ?;
+
+-- name: ReviewClosureRequest :one
+UPDATE account_closure_requests
+SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = datetime('now')
+WHERE id = ? AND status = 'pending'
+RETURNING *;
+
+-- name: CancelClosureRequest :one
+UPDATE account_closure_requests
+SET status = 'cancelled'
+WHERE user_id = ? AND status = 'pending'
+RETURNING *;
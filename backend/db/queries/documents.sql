@@ -0,0 +1,37 @@
+-- name: UpsertUserDocument :one
+INSERT INTO user_documents (user_id, doc_type, filename, content_type, size_bytes, storage_key)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id, doc_type) DO UPDATE SET
+  filename = excluded.filename,
+  content_type = excluded.content_type,
+  size_bytes = excluded.size_bytes,
+  storage_key = excluded.storage_key,
+  status = 'pending',
+  review_note = '',
+  reviewed_by = 0,
+  reviewed_at = '',
+  uploaded_at = datetime('now')
+RETURNING *;
+
+-- name: GetUserDocument :one
+SELECT * FROM user_documents
+WHERE id = ?;
+
+-- name: GetUserDocumentByType :one
+SELECT * FROM user_documents
+WHERE user_id = ? AND doc_type = ?;
+
+-- name: ListUserDocuments :many
+SELECT * FROM user_documents
+WHERE user_id = ?
+ORDER BY doc_type;
+
+-- name: ReviewUserDocument :one
+UPDATE user_documents
+SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = datetime('now')
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteUserDocuments :exec
+DELETE FROM user_documents
+WHERE user_id = ?;
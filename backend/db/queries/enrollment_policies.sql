@@ -0,0 +1,25 @@
+-- name: GetEnrollmentPolicy :one
+SELECT * FROM auction_enrollment_policies
+WHERE auction_id = ?;
+
+-- name: UpsertEnrollmentPolicy :one
+INSERT INTO auction_enrollment_policies (auction_id, auto_approve_tiers, manual_review_above, max_enrollees,
+                                         deadline_minutes_before_start, waitlist)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(auction_id) DO UPDATE SET
+  auto_approve_tiers = excluded.auto_approve_tiers,
+  manual_review_above = excluded.manual_review_above,
+  max_enrollees = excluded.max_enrollees,
+  deadline_minutes_before_start = excluded.deadline_minutes_before_start,
+  waitlist = excluded.waitlist,
+  updated_at = datetime('now')
+RETURNING *;
+
+-- name: DeleteEnrollmentPolicy :exec
+DELETE FROM auction_enrollment_policies
+WHERE auction_id = ?;
+
+-- name: CountActiveEnrollments :one
+SELECT COUNT(*)
+FROM auction_enrollments
+WHERE auction_id = ? AND status IN ('pending', 'approved');
@@ -1,6 +1,6 @@
 -- name: RequestEnrollment :one
-INSERT INTO auction_enrollments (auction_id, user_id)
-VALUES (?, ?)
+INSERT INTO auction_enrollments (auction_id, user_id, status)
+VALUES (?, ?, ?)
 RETURNING id, auction_id, user_id, status, created_at;
 
 -- name: GetEnrollment :one
@@ -25,3 +25,38 @@ RETURNING id, auction_id, user_id, status, created_at;
 UPDATE auction_enrollments SET status = 'rejected'
 WHERE auction_id = ? AND user_id = ?
 RETURNING id, auction_id, user_id, status, created_at;
+
+-- name: ListEnrollmentsForUser :many
+SELECT id, auction_id, user_id, status, created_at
+FROM auction_enrollments
+WHERE user_id = ?
+ORDER BY id;
+
+-- ListEnrollmentsByFilter is built dynamically from EnrollmentFilter (see
+-- enrollments.sql.go).
+
+-- name: ListMyEnrollments :many
+SELECT ae.id, ae.auction_id, ae.user_id, ae.status, ae.created_at,
+       a.title, a.status, a.start_time, a.end_time
+FROM auction_enrollments ae
+JOIN auctions a ON a.id = ae.auction_id
+WHERE ae.user_id = ?
+ORDER BY ae.id DESC;
+
+-- name: UpsertApprovedEnrollment :one
+INSERT INTO auction_enrollments (auction_id, user_id, status)
+VALUES (?, ?, 'approved')
+ON CONFLICT(auction_id, user_id) DO UPDATE SET status = 'approved'
+RETURNING id, auction_id, user_id, status, created_at;
+
+-- name: NextWaitlistedEnrollment :one
+SELECT id, auction_id, user_id, status, created_at
+FROM auction_enrollments
+WHERE auction_id = ? AND status = 'waitlisted'
+ORDER BY id
+LIMIT 1;
+
+-- name: SetEnrollmentStatus :one
+UPDATE auction_enrollments SET status = ?
+WHERE id = ?
+RETURNING id, auction_id, user_id, status, created_at;
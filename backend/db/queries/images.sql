@@ -0,0 +1,56 @@
+-- name: CreateItemImage :one
+INSERT INTO item_images (item_type, item_id, position, caption, is_primary, filename)
+VALUES (?1, ?2,
+        (SELECT COALESCE(MAX(position) + 1, 0) FROM item_images WHERE item_type = ?1 AND item_id = ?2),
+        ?3,
+        NOT EXISTS (SELECT 1 FROM item_images WHERE item_type = ?1 AND item_id = ?2),
+        ?4)
+RETURNING id, item_type, item_id, position, caption, is_primary, filename, created_at;
+
+-- name: AddImageVariant :exec
+INSERT INTO item_image_variants (image_id, name, storage_key, content_type, width, height, size_bytes)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetItemImage :one
+SELECT id, item_type, item_id, position, caption, is_primary, filename, created_at FROM item_images WHERE id = ?;
+
+-- name: ListItemImages :many
+SELECT id, item_type, item_id, position, caption, is_primary, filename, created_at FROM item_images
+WHERE item_type = ? AND item_id = ?
+ORDER BY position, id;
+
+-- name: ListItemImageVariants :many
+SELECT v.image_id, v.name, v.storage_key, v.content_type, v.width, v.height, v.size_bytes
+FROM item_image_variants v
+JOIN item_images i ON i.id = v.image_id
+WHERE i.item_type = ? AND i.item_id = ?;
+
+-- name: ListImageVariants :many
+SELECT image_id, name, storage_key, content_type, width, height, size_bytes FROM item_image_variants WHERE image_id = ?;
+
+-- name: GetImageVariant :one
+SELECT image_id, name, storage_key, content_type, width, height, size_bytes FROM item_image_variants WHERE image_id = ? AND name = ?;
+
+-- name: UpdateItemImageCaption :one
+UPDATE item_images SET caption = ? WHERE id = ?
+RETURNING id, item_type, item_id, position, caption, is_primary, filename, created_at;
+
+-- name: SetPrimaryItemImage :exec
+UPDATE item_images SET is_primary = (id = ?3) WHERE item_type = ?1 AND item_id = ?2;
+
+-- name: EnsurePrimaryItemImage :exec
+UPDATE item_images SET is_primary = 1
+WHERE id = (SELECT id FROM item_images WHERE item_type = ?1 AND item_id = ?2 ORDER BY position, id LIMIT 1)
+  AND NOT EXISTS (SELECT 1 FROM item_images WHERE item_type = ?1 AND item_id = ?2 AND is_primary = 1);
+
+-- name: SetItemImagePosition :exec
+UPDATE item_images SET position = ? WHERE id = ?;
+
+-- name: DeleteItemImage :exec
+DELETE FROM item_images WHERE id = ?;
+
+-- name: SetListingImageURL :exec
+UPDATE listings SET image_url = ? WHERE id = ?;
+
+-- name: SetAuctionImageURL :exec
+UPDATE auctions SET image_url = ? WHERE id = ?;
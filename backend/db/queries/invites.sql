@@ -0,0 +1,19 @@
+-- name: CreateAuctionInvite :one
+INSERT INTO auction_invites (auction_id, user_id, invited_by)
+VALUES (?, ?, ?)
+RETURNING id, auction_id, user_id, invited_by, created_at;
+
+-- name: ListAuctionInvites :many
+SELECT ai.id, ai.auction_id, ai.user_id, ai.invited_by, ai.created_at, u.email, u.business_name
+FROM auction_invites ai
+JOIN users u ON u.id = ai.user_id
+WHERE ai.auction_id = ?
+ORDER BY ai.id;
+
+-- name: DeleteAuctionInvite :one
+DELETE FROM auction_invites
+WHERE auction_id = ? AND user_id = ?
+RETURNING id;
+
+-- name: IsInvited :one
+SELECT EXISTS (SELECT 1 FROM auction_invites WHERE auction_id = ? AND user_id = ?);
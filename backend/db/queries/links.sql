@@ -0,0 +1,50 @@
+-- name: CreateListingAuctionLink :one
+INSERT INTO listing_auction_links (auction_id, listing_id, return_if_unsold, outcome, resolved_at)
+VALUES (?1, ?2, ?3, ?4, CASE WHEN ?4 = '' THEN '' ELSE datetime('now') END)
+RETURNING auction_id, listing_id, return_if_unsold, outcome, created_at, resolved_at;
+
+-- name: GetLinkForAuction :one
+SELECT auction_id, listing_id, return_if_unsold, outcome, created_at, resolved_at FROM listing_auction_links WHERE auction_id = ?;
+
+-- name: GetLatestLinkForListing :one
+SELECT auction_id, listing_id, return_if_unsold, outcome, created_at, resolved_at FROM listing_auction_links
+WHERE listing_id = ?
+ORDER BY created_at DESC, auction_id DESC
+LIMIT 1;
+
+-- name: ListEndedListingAuctionLinks :many
+SELECT l.auction_id, l.listing_id, l.return_if_unsold, l.outcome, l.created_at, l.resolved_at,
+       a.status = 'sold' OR (a.highest_bidder_id != 0 AND (a.reserve_price = 0 OR a.current_bid >= a.reserve_price))
+FROM listing_auction_links l
+JOIN auctions a ON a.id = l.auction_id
+WHERE l.outcome = '' AND a.status IN ('closed', 'sold');
+
+-- name: ResolveListingAuctionLink :exec
+UPDATE listing_auction_links SET outcome = ?, resolved_at = datetime('now') WHERE auction_id = ?;
+
+-- name: SetListingStatus :exec
+UPDATE listings SET status = ? WHERE id = ?;
+
+-- name: SendListingToAuction :execrows
+UPDATE listings SET status = 'auction' WHERE id = ? AND status NOT IN ('auction', 'sold');
+
+-- name: RelistListing :one
+UPDATE listings SET status = 'active', price = CASE WHEN ?2 > 0 THEN ?2 ELSE price END
+WHERE id = ?1
+RETURNING id, title, description, location, price, sale_type, year, status, image_url, created_at;
+
+-- name: CopyListingCategoriesToAuction :exec
+INSERT OR IGNORE INTO auction_categories (auction_id, category_id)
+SELECT ?2, category_id FROM listing_categories WHERE listing_id = ?1;
+
+-- name: CopyAuctionCategoriesToListing :exec
+INSERT OR IGNORE INTO listing_categories (listing_id, category_id)
+SELECT ?2, category_id FROM auction_categories WHERE auction_id = ?1;
+
+-- name: CopyListingSpecsToAuction :exec
+INSERT OR IGNORE INTO auction_specs (auction_id, attribute_id, value)
+SELECT ?2, attribute_id, value FROM listing_specs WHERE listing_id = ?1;
+
+-- name: CopyAuctionSpecsToListing :exec
+INSERT OR IGNORE INTO listing_specs (listing_id, attribute_id, value)
+SELECT ?2, attribute_id, value FROM auction_specs WHERE auction_id = ?1;
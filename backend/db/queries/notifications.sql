@@ -0,0 +1,98 @@
+-- name: GetNotificationPreferences :one
+SELECT u.id, COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM users u
+LEFT JOIN notification_preferences p ON p.user_id = u.id
+WHERE u.id = ?;
+
+-- name: UpsertNotificationPreferences :one
+INSERT INTO notification_preferences (user_id, price_drops, status_changes, email_mode, websocket,
+  auction_starting, auction_ending, auction_bids, auction_results)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+  price_drops = excluded.price_drops,
+  status_changes = excluded.status_changes,
+  email_mode = excluded.email_mode,
+  websocket = excluded.websocket,
+  auction_starting = excluded.auction_starting,
+  auction_ending = excluded.auction_ending,
+  auction_bids = excluded.auction_bids,
+  auction_results = excluded.auction_results,
+  updated_at = CURRENT_TIMESTAMP
+RETURNING user_id, price_drops, status_changes, email_mode, websocket,
+  auction_starting, auction_ending, auction_bids, auction_results, last_digest_at;
+
+-- name: ListLikeChanges :many
+SELECT ul.id, ul.user_id, u.closed_at, l.id, l.title, ul.alerted_price, ul.alerted_status, l.price, l.status,
+       COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM user_likes ul
+JOIN listings l ON l.id = ul.listing_id
+JOIN users u ON u.id = ul.user_id
+LEFT JOIN notification_preferences p ON p.user_id = ul.user_id
+WHERE l.price != ul.alerted_price OR l.status != ul.alerted_status
+ORDER BY ul.id;
+
+-- name: UpdateLikeAlerted :exec
+UPDATE user_likes SET alerted_price = ?, alerted_status = ? WHERE id = ?;
+
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at;
+
+-- name: ListNotifications :many
+-- The unread filter and the before-id cursor are appended in Go.
+SELECT id, user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at
+FROM notifications
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT ?;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at = '';
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = datetime('now')
+WHERE id = ? AND user_id = ? AND read_at = '';
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = datetime('now') WHERE user_id = ? AND read_at = '';
+
+-- name: ListPendingEmails :many
+SELECT u.id, u.email, COALESCE(p.email_mode, 'instant'), COALESCE(p.last_digest_at, '')
+FROM users u
+LEFT JOIN notification_preferences p ON p.user_id = u.id
+WHERE u.closed_at = ''
+  AND EXISTS (SELECT 1 FROM notifications n WHERE n.user_id = u.id AND n.email_state = 'pending')
+ORDER BY u.id;
+
+-- name: ListPendingEmailNotifications :many
+SELECT id, user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at
+FROM notifications
+WHERE user_id = ? AND email_state = 'pending'
+ORDER BY id;
+
+-- name: MarkNotificationsEmailed :exec
+UPDATE notifications SET email_state = 'sent'
+WHERE user_id = ? AND email_state = 'pending' AND id <= ?;
+
+-- name: SkipPendingNotificationEmails :exec
+UPDATE notifications SET email_state = 'none' WHERE user_id = ? AND email_state = 'pending';
+
+-- name: SetLastDigestAt :exec
+INSERT INTO notification_preferences (user_id, email_mode, last_digest_at)
+VALUES (?, 'digest', datetime('now'))
+ON CONFLICT (user_id) DO UPDATE SET last_digest_at = excluded.last_digest_at;
+
+-- name: ListUserNotifications :many
+SELECT id, user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at
+FROM notifications WHERE user_id = ? ORDER BY id DESC;
+
+-- name: DeleteUserNotifications :exec
+DELETE FROM notifications WHERE user_id = ?;
+
+-- name: DeleteNotificationPreferences :exec
+DELETE FROM notification_preferences WHERE user_id = ?;
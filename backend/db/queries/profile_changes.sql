@@ -0,0 +1,39 @@
+-- name: SupersedeProfileChanges :exec
+UPDATE profile_change_requests
+SET status = 'superseded'
+WHERE user_id = ? AND status = 'pending';
+
+-- name: CreateProfileChange :one
+INSERT INTO profile_change_requests (user_id, business_name, legal_representative, rfc,
+                                     previous_business_name, previous_legal_representative, previous_rfc)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetProfileChange :one
+SELECT * FROM profile_change_requests
+WHERE id = ?;
+
+-- name: GetPendingProfileChange :one
+SELECT * FROM profile_change_requests
+WHERE user_id = ? AND status = 'pending';
+
+-- name: ListProfileChangesForUser :many
+SELECT * FROM profile_change_requests
+WHERE user_id = ?
+ORDER BY id DESC;
+
+-- name: ListProfileChangesByStatus :many
+SELECT * FROM profile_change_requests
+WHERE status = ?
+ORDER BY id
+LIMIT ?;
+
+-- name: ReviewProfileChange :one
+UPDATE profile_change_requests
+SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = datetime('now')
+WHERE id = ? AND status = 'pending'
+RETURNING *;
+
+-- name: DeleteProfileChangesForUser :exec
+DELETE FROM profile_change_requests
+WHERE user_id = ?;
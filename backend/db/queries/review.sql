@@ -0,0 +1,57 @@
+-- ListReviewQueue is built dynamically from ReviewQueueFilter (see review.sql.go).
+
+-- name: ClaimUserReview :one
+UPDATE users
+SET assigned_reviewer_id = ?1, assigned_at = datetime('now')
+WHERE id = ?2 AND status IN ('pending', 'info_requested') AND assigned_reviewer_id IN (0, ?1)
+RETURNING *;
+
+-- name: AssignUserReviewer :one
+UPDATE users
+SET assigned_reviewer_id = ?1, assigned_at = CASE WHEN ?1 = 0 THEN '' ELSE datetime('now') END
+WHERE id = ?2 AND status IN ('pending', 'info_requested')
+RETURNING *;
+
+-- name: RequestUserInfo :one
+UPDATE users
+SET status = 'info_requested', info_request = ?, reviewed_at = datetime('now')
+WHERE id = ? AND status = 'pending'
+RETURNING *;
+
+-- name: ResubmitUser :one
+UPDATE users
+SET status = 'pending', info_request = '', submitted_at = datetime('now')
+WHERE id = ? AND status = 'info_requested'
+RETURNING *;
+
+-- name: CreateReviewNote :one
+INSERT INTO user_review_notes (user_id, author_user_id, body)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: ListReviewNotes :many
+SELECT * FROM user_review_notes
+WHERE user_id = ?
+ORDER BY id;
+
+-- name: GetReviewQueueStats :one
+SELECT
+  COALESCE(SUM(status = 'pending'), 0) AS pending,
+  COALESCE(SUM(status = 'info_requested'), 0) AS info_requested,
+  COALESCE(SUM(status = 'pending' AND assigned_reviewer_id = 0), 0) AS unassigned,
+  COALESCE(SUM(status = 'pending' AND (julianday('now') - julianday(submitted_at)) * 24 > ?), 0) AS over_sla,
+  COALESCE(MIN(CASE WHEN status = 'pending' THEN submitted_at END), '') AS oldest_submitted_at,
+  COALESCE(AVG(CASE WHEN status = 'pending' THEN (julianday('now') - julianday(submitted_at)) * 24 END), 0) AS avg_hours_in_queue,
+  COALESCE(SUM(status IN ('approved', 'rejected') AND reviewed_at >= datetime('now', '-30 days')), 0) AS decided_30d,
+  COALESCE(AVG(CASE WHEN status IN ('approved', 'rejected') AND reviewed_at >= datetime('now', '-30 days')
+                    THEN (julianday(reviewed_at) - julianday(submitted_at)) * 24 END), 0) AS avg_hours_to_decision_30d
+FROM users
+WHERE closed_at = '';
+
+-- name: ListReviewerWorkload :many
+SELECT u.assigned_reviewer_id AS reviewer_id, COALESCE(r.email, '') AS email, COUNT(*) AS assigned
+FROM users u
+LEFT JOIN users r ON r.id = u.assigned_reviewer_id
+WHERE u.assigned_reviewer_id != 0 AND u.status IN ('pending', 'info_requested') AND u.closed_at = ''
+GROUP BY u.assigned_reviewer_id
+ORDER BY COUNT(*) DESC;
@@ -0,0 +1,13 @@
+-- name: ListUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = ?
+ORDER BY role;
+
+-- name: AddUserRole :exec
+INSERT OR IGNORE INTO user_roles (user_id, role, granted_by)
+VALUES (?, ?, ?);
+
+-- name: DeleteUserRoles :exec
+DELETE FROM user_roles
+WHERE user_id = ?;
@@ -0,0 +1,66 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (user_id, name, item_types, query, filter_json, match_query, notify)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, name, item_types, query, filter_json, match_query, notify, seen_match_id, created_at, updated_at;
+
+-- name: GetSavedSearch :one
+SELECT id, user_id, name, item_types, query, filter_json, match_query, notify, seen_match_id, created_at, updated_at FROM saved_searches WHERE id = ?;
+
+-- name: CountSavedSearches :one
+SELECT COUNT(*) FROM saved_searches WHERE user_id = ?;
+
+-- name: ListSavedSearches :many
+SELECT s.id, s.user_id, s.name, s.item_types, s.query, s.filter_json, s.match_query, s.notify, s.seen_match_id, s.created_at, s.updated_at,
+       (SELECT COUNT(*) FROM saved_search_matches m WHERE m.search_id = s.id AND m.baseline = 0),
+       (SELECT COUNT(*) FROM saved_search_matches m WHERE m.search_id = s.id AND m.baseline = 0 AND m.id > s.seen_match_id)
+FROM saved_searches s
+WHERE s.user_id = ?
+ORDER BY s.id;
+
+-- name: UpdateSavedSearch :one
+UPDATE saved_searches
+SET name = ?, item_types = ?, query = ?, filter_json = ?, match_query = ?, notify = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING id, user_id, name, item_types, query, filter_json, match_query, notify, seen_match_id, created_at, updated_at;
+
+-- name: DeleteSavedSearch :exec
+DELETE FROM saved_searches WHERE id = ?;
+
+-- name: DeleteUserSavedSearches :exec
+DELETE FROM saved_searches WHERE user_id = ?;
+
+-- name: ResetSavedSearchMatches :exec
+DELETE FROM saved_search_matches WHERE search_id = ?;
+
+-- name: AddSavedSearchMatch :execrows
+INSERT OR IGNORE INTO saved_search_matches (search_id, item_type, item_id, baseline) VALUES (?, ?, ?, ?);
+
+-- name: ListSavedSearchMatches :many
+SELECT m.id, m.search_id, m.item_type, m.item_id, m.created_at, m.id > s.seen_match_id
+FROM saved_search_matches m
+JOIN saved_searches s ON s.id = m.search_id
+WHERE m.search_id = ?1 AND m.baseline = 0 AND (?2 = 0 OR m.id > s.seen_match_id)
+ORDER BY m.id DESC
+LIMIT ?3;
+
+-- name: MarkSavedSearchSeen :exec
+UPDATE saved_searches
+SET seen_match_id = (SELECT COALESCE(MAX(id), 0) FROM saved_search_matches WHERE search_id = saved_searches.id)
+WHERE id = ?;
+
+-- name: ListSavedSearchesForMatching :many
+SELECT s.id, s.user_id, s.name, s.item_types, s.query, s.filter_json, s.match_query, s.notify, s.seen_match_id, s.created_at, s.updated_at,
+       u.closed_at,
+       COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM saved_searches s
+JOIN users u ON u.id = s.user_id
+LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+ORDER BY s.id;
+
+-- name: ListCatalogChanges :many
+SELECT id, item_type, item_id FROM catalog_changes ORDER BY id LIMIT ?;
+
+-- name: DeleteCatalogChanges :exec
+DELETE FROM catalog_changes WHERE id <= ?;
@@ -0,0 +1,30 @@
+-- name: SearchIndex :many
+SELECT rowid,
+       -bm25(search_index, 4.0, 1.0, 1.5, 2.0) AS score,
+       snippet(search_index, 0, char(2), char(3), '…', 64),
+       snippet(search_index, 1, char(2), char(3), '…', 24)
+FROM search_index
+WHERE search_index MATCH ?1
+  AND ((rowid % 2 = 0 AND ?2 IN ('', 'listing')
+        AND EXISTS (SELECT 1 FROM listings l WHERE l.id = search_index.rowid / 2 AND l.status = 'active'))
+    OR (rowid % 2 = 1 AND ?2 IN ('', 'auction')
+        AND EXISTS (SELECT 1 FROM auctions a
+                    WHERE a.id = search_index.rowid / 2 AND a.status = 'active'
+                      AND (a.visibility = 'public'
+                           OR (a.visibility = 'invite_only'
+                               AND EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = ?3))))))
+ORDER BY score DESC, rowid
+LIMIT ?4;
+
+-- name: CountSearchIndex :one
+SELECT COUNT(*)
+FROM search_index
+WHERE search_index MATCH ?1
+  AND ((rowid % 2 = 0 AND ?2 IN ('', 'listing')
+        AND EXISTS (SELECT 1 FROM listings l WHERE l.id = search_index.rowid / 2 AND l.status = 'active'))
+    OR (rowid % 2 = 1 AND ?2 IN ('', 'auction')
+        AND EXISTS (SELECT 1 FROM auctions a
+                    WHERE a.id = search_index.rowid / 2 AND a.status = 'active'
+                      AND (a.visibility = 'public'
+                           OR (a.visibility = 'invite_only'
+                               AND EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = ?3))))));
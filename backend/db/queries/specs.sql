@@ -0,0 +1,96 @@
+-- name: ListSpecAttributes :many
+SELECT id, category_id, key, label, type, unit, options, min_value, max_value, required, position FROM spec_attributes ORDER BY category_id, position, label;
+
+-- name: GetSpecAttribute :one
+SELECT id, category_id, key, label, type, unit, options, min_value, max_value, required, position FROM spec_attributes WHERE id = ?;
+
+-- name: ListSpecAttributesForCategory :many
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT id, category_id, key, label, type, unit, options, min_value, max_value, required, position
+FROM spec_attributes
+WHERE category_id = 0 OR category_id IN (SELECT root_id FROM subtree WHERE id = ?)
+ORDER BY category_id != 0, position, label;
+
+-- name: ListSpecAttributesForListing :many
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT id, category_id, key, label, type, unit, options, min_value, max_value, required, position
+FROM spec_attributes
+WHERE category_id = 0
+   OR category_id IN (SELECT s.root_id FROM subtree s JOIN listing_categories lc ON lc.category_id = s.id WHERE lc.listing_id = ?)
+ORDER BY category_id != 0, position, label;
+
+-- name: ListSpecAttributesForAuction :many
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT id, category_id, key, label, type, unit, options, min_value, max_value, required, position
+FROM spec_attributes
+WHERE category_id = 0
+   OR category_id IN (SELECT s.root_id FROM subtree s JOIN auction_categories ac ON ac.category_id = s.id WHERE ac.auction_id = ?)
+ORDER BY category_id != 0, position, label;
+
+-- name: SpecKeyInUse :one
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (
+  SELECT 1 FROM spec_attributes
+  WHERE key = ?1
+    AND (?2 = 0 OR category_id = 0
+         OR category_id IN (SELECT root_id FROM subtree WHERE id = ?2)
+         OR category_id IN (SELECT id FROM subtree WHERE root_id = ?2))
+);
+
+-- name: CreateSpecAttribute :one
+INSERT INTO spec_attributes (category_id, key, label, type, unit, options, min_value, max_value, required, position)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, category_id, key, label, type, unit, options, min_value, max_value, required, position;
+
+-- name: UpdateSpecAttribute :one
+UPDATE spec_attributes
+SET label = ?, unit = ?, options = ?, min_value = ?, max_value = ?, required = ?, position = ?
+WHERE id = ?
+RETURNING id, category_id, key, label, type, unit, options, min_value, max_value, required, position;
+
+-- name: DeleteSpecAttribute :exec
+DELETE FROM spec_attributes WHERE id = ?;
+
+-- name: ListListingSpecs :many
+SELECT a.id, a.key, a.label, a.type, a.unit, v.value
+FROM listing_specs v
+JOIN spec_attributes a ON a.id = v.attribute_id
+WHERE v.listing_id = ?
+ORDER BY a.category_id != 0, a.position, a.label;
+
+-- name: ListAuctionSpecs :many
+SELECT a.id, a.key, a.label, a.type, a.unit, v.value
+FROM auction_specs v
+JOIN spec_attributes a ON a.id = v.attribute_id
+WHERE v.auction_id = ?
+ORDER BY a.category_id != 0, a.position, a.label;
+
+-- name: ClearListingSpecs :exec
+DELETE FROM listing_specs WHERE listing_id = ?;
+
+-- name: SetListingSpec :exec
+INSERT INTO listing_specs (listing_id, attribute_id, value) VALUES (?, ?, ?)
+ON CONFLICT (listing_id, attribute_id) DO UPDATE SET value = excluded.value;
+
+-- name: ClearAuctionSpecs :exec
+DELETE FROM auction_specs WHERE auction_id = ?;
+
+-- name: SetAuctionSpec :exec
+INSERT INTO auction_specs (auction_id, attribute_id, value) VALUES (?, ?, ?)
+ON CONFLICT (auction_id, attribute_id) DO UPDATE SET value = excluded.value;
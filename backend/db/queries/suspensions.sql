@@ -0,0 +1,27 @@
+-- name: SuspendUser :one
+UPDATE users
+SET status = 'suspended', suspension_reason = ?, suspended_until = ?,
+    suspended_at = CASE WHEN status = 'suspended' THEN suspended_at ELSE datetime('now') END
+WHERE id = ? AND status IN ('approved', 'suspended')
+RETURNING *;
+
+-- name: ReinstateUser :one
+UPDATE users
+SET status = 'approved', suspension_reason = '', suspended_at = '', suspended_until = ''
+WHERE id = ? AND status = 'suspended'
+RETURNING *;
+
+-- name: ReinstateExpiredSuspensions :many
+UPDATE users
+SET status = 'approved', suspension_reason = '', suspended_at = '', suspended_until = ''
+WHERE status = 'suspended' AND suspended_until != '' AND datetime(suspended_until) <= datetime('now')
+RETURNING *;
+
+-- name: CreateStatusChange :exec
+INSERT INTO user_status_history (user_id, from_status, to_status, reason, actor_user_id, actor_api_key_id)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListStatusHistory :many
+SELECT * FROM user_status_history
+WHERE user_id = ?
+ORDER BY id;
@@ -28,13 +28,13 @@ LIMIT ?;
 
 -- name: ApproveUser :one
 UPDATE users
-SET status = 'approved', guarantee_tier = ?, remaining_opportunities = 5
+SET status = 'approved', guarantee_tier = ?, remaining_opportunities = 5, info_request = '', reviewed_at = datetime('now')
 WHERE id = ?
 RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
 
 -- name: RejectUser :one
 UPDATE users
-SET status = 'rejected', rejection_reason = ?
+SET status = 'rejected', rejection_reason = ?, info_request = '', reviewed_at = datetime('now')
 WHERE id = ?
 RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
 
@@ -57,3 +57,23 @@ WHERE id = ? AND remaining_opportunities > 0;
 
 -- name: SetUserOpportunities :exec
 UPDATE users SET remaining_opportunities = ? WHERE id = ?;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET business_name = ?, legal_representative = ?, rfc = ?, street_address = ?, colony = ?, municipality = ?, postal_code = ?, city = ?, state = ?, phone = ?, mobile = ?
+WHERE id = ?
+RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+
+-- name: UpdateUserFiscalIdentity :one
+UPDATE users
+SET business_name = ?, legal_representative = ?, rfc = ?
+WHERE id = ?
+RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+
+-- name: AnonymizeUser :one
+UPDATE users
+SET email = ?, password_hash = '!', legal_representative = '', street_address = '', colony = '',
+    municipality = '', postal_code = '', city = '', state = '', phone = '', mobile = '',
+    is_admin = 0, must_change_password = 0, closed_at = datetime('now')
+WHERE id = ?
+RETURNING *;
@@ -0,0 +1,59 @@
+-- name: WatchAuction :one
+INSERT INTO auction_watches (user_id, auction_id, lead_minutes, alerted_bid)
+SELECT ?1, a.id, ?3, a.current_bid FROM auctions a WHERE a.id = ?2
+ON CONFLICT (user_id, auction_id) DO UPDATE SET lead_minutes = excluded.lead_minutes
+RETURNING id, user_id, auction_id, lead_minutes, alerted_bid, starting_sent, ending_sent, result_sent, created_at;
+
+-- name: GetAuctionWatch :one
+SELECT id, user_id, auction_id, lead_minutes, alerted_bid, starting_sent, ending_sent, result_sent, created_at FROM auction_watches WHERE user_id = ? AND auction_id = ?;
+
+-- name: UnwatchAuction :exec
+DELETE FROM auction_watches WHERE user_id = ? AND auction_id = ?;
+
+-- name: ListUserWatches :many
+SELECT w.id, w.user_id, w.auction_id, w.lead_minutes, w.alerted_bid, w.starting_sent, w.ending_sent, w.result_sent, w.created_at,
+       a.title, a.image_url, a.status, a.current_bid,
+       (SELECT COUNT(*) FROM bids b WHERE b.auction_id = a.id),
+       a.highest_bidder_id = w.user_id,
+       a.start_time, a.end_time,
+       COALESCE(MAX(0, CAST(ROUND((julianday(a.start_time) - julianday('now')) * 86400) AS INTEGER)), 0),
+       COALESCE(MAX(0, CAST(ROUND((julianday(a.end_time) - julianday('now')) * 86400) AS INTEGER)), 0)
+FROM auction_watches w
+JOIN auctions a ON a.id = w.auction_id
+WHERE w.user_id = ?
+  AND (a.visibility != 'invite_only'
+       OR EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = w.user_id))
+ORDER BY CASE a.status WHEN 'active' THEN 0 WHEN 'scheduled' THEN 1 ELSE 2 END, a.end_time, w.id;
+
+-- name: ListWatchChanges :many
+SELECT w.id, w.user_id, w.auction_id, w.lead_minutes, w.alerted_bid, w.starting_sent, w.ending_sent, w.result_sent, w.created_at,
+       u.closed_at, a.title, a.status, a.current_bid, a.highest_bidder_id,
+       a.status = 'sold' OR (a.highest_bidder_id != 0 AND (a.reserve_price = 0 OR a.current_bid >= a.reserve_price)),
+       COALESCE(a.status = 'scheduled'
+         AND datetime(a.start_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'), 0),
+       COALESCE(a.status = 'active'
+         AND datetime(a.end_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'), 0),
+       COALESCE(MAX(0, CAST(ROUND((julianday(CASE a.status WHEN 'scheduled' THEN a.start_time ELSE a.end_time END)
+         - julianday('now')) * 1440) AS INTEGER)), 0),
+       COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM auction_watches w
+JOIN auctions a ON a.id = w.auction_id
+JOIN users u ON u.id = w.user_id
+LEFT JOIN notification_preferences p ON p.user_id = w.user_id
+WHERE (a.visibility != 'invite_only'
+       OR EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = w.user_id))
+  AND ((w.starting_sent = 0 AND a.status != 'scheduled')
+    OR (w.starting_sent = 0 AND a.start_time != '' AND datetime(a.start_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'))
+    OR (w.ending_sent = 0 AND a.status IN ('closed', 'sold'))
+    OR (w.ending_sent = 0 AND a.status = 'active' AND a.end_time != '' AND datetime(a.end_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'))
+    OR (w.result_sent = 0 AND a.status IN ('closed', 'sold'))
+    OR a.current_bid != w.alerted_bid)
+ORDER BY w.id;
+
+-- name: UpdateWatchAlerted :exec
+UPDATE auction_watches SET alerted_bid = ?, starting_sent = ?, ending_sent = ?, result_sent = ? WHERE id = ?;
+
+-- name: DeleteUserWatches :exec
+DELETE FROM auction_watches WHERE user_id = ?;
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix marks MAQZONE API keys so they can be told apart from JWTs
+// and recognized by secret scanners.
+const APIKeyPrefix = "mqz_"
+
+const apiKeyIDLength = 8
+
+// GenerateAPIKey returns a new plaintext key of the form mqz_<id>_<secret>,
+// along with the public id and the hash that should be stored. The plaintext
+// is only shown once, at creation time.
+func GenerateAPIKey() (key, id, hash string, err error) {
+	idBytes := make([]byte, apiKeyIDLength/2)
+	if _, err = rand.Read(idBytes); err != nil {
+		return "", "", "", err
+	}
+	secretBytes := make([]byte, 32)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+	id = hex.EncodeToString(idBytes)
+	secret := hex.EncodeToString(secretBytes)
+	return APIKeyPrefix + id + "_" + secret, id, HashAPIKeySecret(secret), nil
+}
+
+// ParseAPIKey splits a plaintext key into its public id and secret.
+func ParseAPIKey(key string) (id, secret string, ok bool) {
+	rest, found := strings.CutPrefix(key, APIKeyPrefix)
+	if !found {
+		return "", "", false
+	}
+	id, secret, found = strings.Cut(rest, "_")
+	if !found || len(id) != apiKeyIDLength || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// HashAPIKeySecret hashes the secret part of a key. Secrets are 256 bits of
+// randomness, so a fast hash is sufficient and keeps per-request checks cheap.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func CheckAPIKeySecret(hash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(HashAPIKeySecret(secret))) == 1
+}
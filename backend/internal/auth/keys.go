@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenTTL is how long user session tokens stay valid. Retired verification
+// keys must be kept at least this long after a rotation.
+const tokenTTL = 24 * time.Hour
+
+// ImpersonationTTL bounds support sessions opened with an impersonation token.
+const ImpersonationTTL = 15 * time.Minute
+
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// KeySet signs tokens with a single active key and verifies them against every
+// configured key, selected by the token's kid header. This lets a new signing
+// key be rolled out while tokens signed by the previous one remain valid.
+type KeySet struct {
+	signing *key
+	verify  map[string]*key
+	// hmac verifies legacy HS256 tokens, which carry no kid.
+	hmac *key
+}
+
+// NewHMACKeySet returns a key set that signs and verifies with a shared secret.
+func NewHMACKeySet(secret string) *KeySet {
+	k := &key{method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}
+	return &KeySet{signing: k, verify: map[string]*key{}, hmac: k}
+}
+
+// LoadKeySet builds a key set from PEM files. The signing key must be an RSA
+// or Ed25519 private key; verification files may hold private or public keys.
+// If signingFile is empty the set falls back to HS256 with hmacSecret. A
+// non-empty hmacSecret alongside a signing file keeps previously issued HS256
+// tokens valid during migration.
+func LoadKeySet(signingFile string, verificationFiles []string, hmacSecret string) (*KeySet, error) {
+	if signingFile == "" {
+		return NewHMACKeySet(hmacSecret), nil
+	}
+	ks := &KeySet{verify: map[string]*key{}}
+	if hmacSecret != "" {
+		ks.hmac = &key{method: jwt.SigningMethodHS256, private: []byte(hmacSecret), public: []byte(hmacSecret)}
+	}
+
+	signing, err := loadKeyFile(signingFile)
+	if err != nil {
+		return nil, err
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("%s: signing key must be a private key", signingFile)
+	}
+	ks.signing = signing
+	ks.verify[signing.id] = signing
+
+	for _, path := range verificationFiles {
+		k, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.verify[k.id] = k
+	}
+	return ks, nil
+}
+
+func loadKeyFile(path string) (*key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var private, public any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse key %s: %w", path, err)
+	}
+	if signer, ok := private.(crypto.Signer); ok {
+		public = signer.Public()
+	}
+
+	k := &key{private: private, public: public}
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		k.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, pub)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, fmt.Errorf("marshal key %s: %w", path, err)
+	}
+	sum := sha256.Sum256(der)
+	k.id = base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+	return k, nil
+}
+
+// Sign issues a token for the given claims using the active signing key.
+func (ks *KeySet) Sign(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.id != "" {
+		token.Header["kid"] = ks.signing.id
+	}
+	return token.SignedString(ks.signing.private)
+}
+
+func (ks *KeySet) GenerateToken(userID int64, email string) (string, error) {
+	now := time.Now()
+	return ks.Sign(Claims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+}
+
+// GenerateImpersonationToken issues a short-lived token that acts as userID on
+// behalf of adminID. The returned claims carry the session id (jti) and expiry.
+func (ks *KeySet) GenerateImpersonationToken(userID int64, email string, adminID int64) (string, Claims, error) {
+	sessionID := make([]byte, 8)
+	if _, err := rand.Read(sessionID); err != nil {
+		return "", Claims{}, err
+	}
+	now := time.Now()
+	claims := Claims{
+		UserID:         userID,
+		Email:          email,
+		ImpersonatorID: adminID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(sessionID),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ImpersonationTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err := ks.Sign(claims)
+	return token, claims, err
+}
+
+func (ks *KeySet) ValidateToken(tokenStr string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (any, error) {
+		k := ks.hmac
+		if kid, ok := t.Header["kid"].(string); ok {
+			k = ks.verify[kid]
+		}
+		// The key, not the token header, decides the algorithm.
+		if k == nil || t.Method.Alg() != k.method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return k.public, nil
+	})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// JWK is the public half of a verification key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS lists the public verification keys. Shared HMAC secrets are never
+// published.
+func (ks *KeySet) JWKS() []JWK {
+	keys := []JWK{}
+	for _, k := range ks.verify {
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "RSA",
+				Kid: k.id,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "OKP",
+				Kid: k.id,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return keys
+}
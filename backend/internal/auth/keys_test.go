@@ -0,0 +1,113 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"maqzone/backend/internal/auth"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeRSAKey(t *testing.T) string {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(k))
+}
+
+func writeEd25519Key(t *testing.T) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "ed25519.pem", "PRIVATE KEY", der)
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := writeRSAKey(t)
+	newKey := writeEd25519Key(t)
+
+	before, err := auth.LoadKeySet(oldKey, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := before.GenerateToken(7, "buyer@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := auth.LoadKeySet(newKey, []string{oldKey}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := after.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("token signed by retired key should still verify: %v", err)
+	}
+	if claims.UserID != 7 {
+		t.Fatalf("expected user 7, got %d", claims.UserID)
+	}
+
+	jwks := after.JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("expected 2 published keys, got %d", len(jwks))
+	}
+
+	retired, err := auth.LoadKeySet(newKey, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := retired.ValidateToken(token); err == nil {
+		t.Fatal("token signed by a removed key should be rejected")
+	}
+}
+
+func TestLegacyHMACTokens(t *testing.T) {
+	legacy := auth.NewHMACKeySet("shared-secret")
+	token, err := legacy.GenerateToken(1, "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy.JWKS()) != 0 {
+		t.Fatal("HMAC secrets must not be published")
+	}
+
+	signingKey := writeEd25519Key(t)
+	migrating, err := auth.LoadKeySet(signingKey, nil, "shared-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrating.ValidateToken(token); err != nil {
+		t.Fatalf("HS256 token should verify while the legacy secret is configured: %v", err)
+	}
+
+	strict, err := auth.LoadKeySet(signingKey, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strict.ValidateToken(token); err == nil {
+		t.Fatal("HS256 token should be rejected without the legacy secret")
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+// Staff roles. Each role grants a fixed set of scopes; a user may hold several.
+const (
+	RoleSuperadmin            = "superadmin"
+	RoleRegistrationsReviewer = "registrations_reviewer"
+	RoleAuctionManager        = "auction_manager"
+	RoleFinance               = "finance"
+)
+
+var RoleScopes = map[string][]string{
+	RoleSuperadmin: AllScopes,
+	RoleRegistrationsReviewer: {
+		ScopeUsersRead,
+		ScopeUsersReview,
+	},
+	RoleAuctionManager: {
+		ScopeAuctionsRead,
+		ScopeAuctionsWrite,
+		ScopeListingsRead,
+		ScopeListingsWrite,
+		ScopeEnrollmentsWrite,
+		ScopeUsersRead,
+	},
+	RoleFinance: {
+		ScopeAuctionsRead,
+		ScopeUsersRead,
+		ScopeOpportunitiesWrite,
+	},
+}
+
+func ValidRole(role string) bool {
+	_, ok := RoleScopes[role]
+	return ok
+}
+
+// ScopesForRoles returns the union of scopes granted by roles, ignoring
+// unknown role names.
+func ScopesForRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, role := range roles {
+		for _, scope := range RoleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				out = append(out, scope)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package auth
+
+import "strings"
+
+// Scopes are the permissions that guard groups of admin endpoints. API keys
+// carry an explicit subset; staff users get the union of their roles' scopes.
+const (
+	ScopeAuctionsRead       = "auctions:read"
+	ScopeAuctionsWrite      = "auctions:write"
+	ScopeListingsRead       = "listings:read"
+	ScopeListingsWrite      = "listings:write"
+	ScopeEnrollmentsWrite   = "enrollments:write"
+	ScopeUsersRead          = "users:read"
+	ScopeUsersReview        = "users:review"
+	ScopeUsersManage        = "users:manage"
+	ScopeOpportunitiesWrite = "opportunities:write"
+	ScopeAPIKeysManage      = "apikeys:manage"
+	ScopeRolesManage        = "roles:manage"
+	ScopeAuditRead          = "audit:read"
+	ScopeUsersImpersonate   = "users:impersonate"
+)
+
+var AllScopes = []string{
+	ScopeAuctionsRead,
+	ScopeAuctionsWrite,
+	ScopeListingsRead,
+	ScopeListingsWrite,
+	ScopeEnrollmentsWrite,
+	ScopeUsersRead,
+	ScopeUsersReview,
+	ScopeUsersManage,
+	ScopeOpportunitiesWrite,
+	ScopeAPIKeysManage,
+	ScopeRolesManage,
+	ScopeAuditRead,
+	ScopeUsersImpersonate,
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinScopes and SplitScopes convert between the list form used in the API
+// and the comma-separated form stored in the database.
+func JoinScopes(scopes []string) string {
+	return strings.Join(scopes, ",")
+}
+
+func SplitScopes(raw string) []string {
+	out := []string{}
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -1,38 +1,99 @@
 package config
 
 import (
+  "errors"
   "os"
+  "strconv"
   "strings"
 )
 
+// DefaultJWTSecret is only suitable for local development; Validate rejects
+// it in production.
+const DefaultJWTSecret = "maqzone-dev-secret-change-in-production"
+
 type Config struct {
+  Env                string
   Port               string
   SQLitePath         string
   CorsAllowedOrigins []string
   CorsAllowAll       bool
   LogLevel           string
-  AdminToken         string
   JWTSecret          string
+  // JWTSigningKeyFile is a PEM RSA or Ed25519 private key. When set, tokens
+  // are signed with it instead of JWTSecret.
+  JWTSigningKeyFile string
+  // JWTVerificationKeyFiles are extra PEM keys still accepted for verification,
+  // e.g. the previous signing key during a rotation.
+  JWTVerificationKeyFiles []string
+  // UploadDir is where the local storage backend keeps uploaded files.
+  UploadDir string
+  // SMTP settings for outgoing email. Without SMTPHost messages are only logged.
+  SMTPHost     string
+  SMTPPort     string
+  SMTPUsername string
+  SMTPPassword string
+  MailFrom     string
+  // ReviewSLAHours is how long a registration may wait in the review queue
+  // before it is reported as over SLA.
+  ReviewSLAHours int
 }
 
 func Load() Config {
+  env := strings.ToLower(getEnv("APP_ENV", "development"))
   port := getEnv("PORT", "8080")
   sqlitePath := getEnv("SQLITE_PATH", "./data/maqzone.db")
   corsAllowAll := strings.ToLower(getEnv("CORS_ALLOW_ALL", "true")) == "true"
   origins := splitCSV(getEnv("CORS_ALLOWED_ORIGINS", ""))
   logLevel := getEnv("LOG_LEVEL", "info")
-  adminToken := getEnv("ADMIN_TOKEN", "")
-  jwtSecret := getEnv("JWT_SECRET", "maqzone-dev-secret-change-in-production")
+  jwtSecret := getEnv("JWT_SECRET", DefaultJWTSecret)
+  jwtSigningKeyFile := getEnv("JWT_SIGNING_KEY_FILE", "")
+  jwtVerificationKeyFiles := splitCSV(getEnv("JWT_VERIFICATION_KEY_FILES", ""))
+  uploadDir := getEnv("UPLOAD_DIR", "./data/uploads")
+  reviewSLAHours, err := strconv.Atoi(getEnv("REVIEW_SLA_HOURS", "48"))
+  if err != nil || reviewSLAHours <= 0 {
+    reviewSLAHours = 48
+  }
 
   return Config{
-    Port:               port,
-    SQLitePath:         sqlitePath,
-    CorsAllowedOrigins: origins,
-    CorsAllowAll:       corsAllowAll,
-    LogLevel:           logLevel,
-    AdminToken:         adminToken,
-    JWTSecret:          jwtSecret,
+    Env:                     env,
+    Port:                    port,
+    SQLitePath:              sqlitePath,
+    CorsAllowedOrigins:      origins,
+    CorsAllowAll:            corsAllowAll,
+    LogLevel:                logLevel,
+    JWTSecret:               jwtSecret,
+    JWTSigningKeyFile:       jwtSigningKeyFile,
+    JWTVerificationKeyFiles: jwtVerificationKeyFiles,
+    UploadDir:               uploadDir,
+    SMTPHost:                getEnv("SMTP_HOST", ""),
+    SMTPPort:                getEnv("SMTP_PORT", "587"),
+    SMTPUsername:            getEnv("SMTP_USERNAME", ""),
+    SMTPPassword:            getEnv("SMTP_PASSWORD", ""),
+    MailFrom:                getEnv("MAIL_FROM", "MAQZONE <no-reply@maqzone.mx>"),
+    ReviewSLAHours:          reviewSLAHours,
+  }
+}
+
+func (c Config) IsProduction() bool {
+  return c.Env == "production"
+}
+
+// Validate reports settings that are unsafe to run with.
+func (c Config) Validate() error {
+  if c.IsProduction() && c.JWTSigningKeyFile == "" && c.JWTSecret == DefaultJWTSecret {
+    return errors.New("refusing to start in production with the default JWT_SECRET; set JWT_SECRET or JWT_SIGNING_KEY_FILE")
+  }
+  return nil
+}
+
+// LegacyJWTSecret returns the shared secret that HS256 tokens are still
+// verified with, or "" if none should be accepted. The default development
+// secret is never trusted alongside a signing key file.
+func (c Config) LegacyJWTSecret() string {
+  if c.JWTSigningKeyFile != "" && c.JWTSecret == DefaultJWTSecret {
+    return ""
   }
+  return c.JWTSecret
 }
 
 func getEnv(key, fallback string) string {
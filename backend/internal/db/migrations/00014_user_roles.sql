@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id INTEGER NOT NULL,
+  role TEXT NOT NULL,
+  granted_by INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  PRIMARY KEY (user_id, role),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Existing admins keep full access.
+INSERT OR IGNORE INTO user_roles (user_id, role)
+SELECT id, 'superadmin' FROM users WHERE is_admin = 1;
+
+-- +goose Down
+DROP TABLE IF EXISTS user_roles;
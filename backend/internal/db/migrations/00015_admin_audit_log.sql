@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor_user_id INTEGER NOT NULL DEFAULT 0,
+  actor_api_key_id INTEGER NOT NULL DEFAULT 0,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL DEFAULT '',
+  target_id INTEGER NOT NULL DEFAULT 0,
+  before_json TEXT NOT NULL DEFAULT '',
+  after_json TEXT NOT NULL DEFAULT '',
+  changes_json TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT '',
+  ip TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+CREATE INDEX idx_audit_created ON admin_audit_log(created_at);
+CREATE INDEX idx_audit_target ON admin_audit_log(target_type, target_id);
+CREATE INDEX idx_audit_actor ON admin_audit_log(actor_user_id);
+
+-- The audit log is append-only.
+-- +goose StatementBegin
+CREATE TRIGGER admin_audit_log_no_update BEFORE UPDATE ON admin_audit_log
+BEGIN
+  SELECT RAISE(ABORT, 'admin_audit_log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER admin_audit_log_no_delete BEFORE DELETE ON admin_audit_log
+BEGIN
+  SELECT RAISE(ABORT, 'admin_audit_log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS admin_audit_log_no_delete;
+DROP TRIGGER IF EXISTS admin_audit_log_no_update;
+DROP TABLE IF EXISTS admin_audit_log;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- One row per required KYC document; re-uploading replaces the file and
+-- resets the review.
+CREATE TABLE user_documents (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  doc_type TEXT NOT NULL CHECK(doc_type IN ('registration_sheet','tax_certificate','representative_id','proof_of_address')),
+  filename TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size_bytes INTEGER NOT NULL DEFAULT 0,
+  storage_key TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','approved','rejected')),
+  review_note TEXT NOT NULL DEFAULT '',
+  reviewed_by INTEGER NOT NULL DEFAULT 0,
+  reviewed_at TEXT NOT NULL DEFAULT '',
+  uploaded_at TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(user_id, doc_type)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_documents;
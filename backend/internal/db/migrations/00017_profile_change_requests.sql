@@ -0,0 +1,24 @@
+-- +goose Up
+-- Changes to fiscal identity fields need admin review. Each row keeps the
+-- values in effect when it was filed, so approved rows form the history.
+CREATE TABLE profile_change_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  business_name TEXT NOT NULL,
+  legal_representative TEXT NOT NULL,
+  rfc TEXT NOT NULL,
+  previous_business_name TEXT NOT NULL,
+  previous_legal_representative TEXT NOT NULL,
+  previous_rfc TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','approved','rejected','superseded')),
+  review_note TEXT NOT NULL DEFAULT '',
+  reviewed_by INTEGER NOT NULL DEFAULT 0,
+  reviewed_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX idx_profile_change_requests_user ON profile_change_requests(user_id, id);
+CREATE UNIQUE INDEX idx_profile_change_requests_pending ON profile_change_requests(user_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS profile_change_requests;
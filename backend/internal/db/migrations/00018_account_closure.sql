@@ -0,0 +1,20 @@
+-- +goose Up
+-- closed_at is set once an account has been closed and anonymized.
+ALTER TABLE users ADD COLUMN closed_at TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE account_closure_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','approved','rejected','cancelled')),
+  review_note TEXT NOT NULL DEFAULT '',
+  reviewed_by INTEGER NOT NULL DEFAULT 0,
+  reviewed_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE UNIQUE INDEX idx_account_closure_requests_pending ON account_closure_requests(user_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS account_closure_requests;
+ALTER TABLE users DROP COLUMN closed_at;
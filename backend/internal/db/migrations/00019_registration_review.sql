@@ -0,0 +1,124 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- SQLite cannot alter a CHECK constraint, so users is rebuilt. Foreign keys
+-- must be off while the old table is dropped, otherwise ON DELETE CASCADE
+-- would wipe every table that references it; the pragma is a no-op inside a
+-- transaction, hence NO TRANSACTION and the explicit BEGIN/COMMIT.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE users_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT NOT NULL UNIQUE,
+  password_hash TEXT NOT NULL,
+  business_name TEXT NOT NULL DEFAULT '',
+  legal_representative TEXT NOT NULL DEFAULT '',
+  rfc TEXT NOT NULL DEFAULT '',
+  street_address TEXT NOT NULL DEFAULT '',
+  colony TEXT NOT NULL DEFAULT '',
+  municipality TEXT NOT NULL DEFAULT '',
+  postal_code TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  state TEXT NOT NULL DEFAULT '',
+  phone TEXT NOT NULL DEFAULT '',
+  mobile TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','info_requested','approved','rejected')),
+  guarantee_tier TEXT NOT NULL DEFAULT '' CHECK(guarantee_tier IN ('','50k','100k')),
+  remaining_opportunities INTEGER NOT NULL DEFAULT 0,
+  rejection_reason TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  must_change_password INTEGER NOT NULL DEFAULT 0,
+  is_admin INTEGER NOT NULL DEFAULT 0,
+  closed_at TEXT NOT NULL DEFAULT '',
+  -- Review workflow. submitted_at is when the registration last entered the
+  -- queue (registration or resubmission); reviewed_at is the last decision.
+  info_request TEXT NOT NULL DEFAULT '',
+  assigned_reviewer_id INTEGER NOT NULL DEFAULT 0,
+  assigned_at TEXT NOT NULL DEFAULT '',
+  submitted_at TEXT NOT NULL DEFAULT (datetime('now')),
+  reviewed_at TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO users_new (
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile, status, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at,
+  submitted_at
+)
+SELECT
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile, status, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at,
+  created_at
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+CREATE INDEX idx_users_review_queue ON users(status, submitted_at);
+
+CREATE TABLE user_review_notes (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  author_user_id INTEGER NOT NULL DEFAULT 0,
+  body TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX idx_user_review_notes_user ON user_review_notes(user_id, id);
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_review_notes;
+
+CREATE TABLE users_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT NOT NULL UNIQUE,
+  password_hash TEXT NOT NULL,
+  business_name TEXT NOT NULL DEFAULT '',
+  legal_representative TEXT NOT NULL DEFAULT '',
+  rfc TEXT NOT NULL DEFAULT '',
+  street_address TEXT NOT NULL DEFAULT '',
+  colony TEXT NOT NULL DEFAULT '',
+  municipality TEXT NOT NULL DEFAULT '',
+  postal_code TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  state TEXT NOT NULL DEFAULT '',
+  phone TEXT NOT NULL DEFAULT '',
+  mobile TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','approved','rejected')),
+  guarantee_tier TEXT NOT NULL DEFAULT '' CHECK(guarantee_tier IN ('','50k','100k')),
+  remaining_opportunities INTEGER NOT NULL DEFAULT 0,
+  rejection_reason TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  must_change_password INTEGER NOT NULL DEFAULT 0,
+  is_admin INTEGER NOT NULL DEFAULT 0,
+  closed_at TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO users_old (
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile, status, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at
+)
+SELECT
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile,
+  CASE status WHEN 'info_requested' THEN 'pending' ELSE status END, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,138 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Adds 'suspended' to the users status CHECK; see 00019 for why the table is
+-- rebuilt with foreign keys off.
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+CREATE TABLE users_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT NOT NULL UNIQUE,
+  password_hash TEXT NOT NULL,
+  business_name TEXT NOT NULL DEFAULT '',
+  legal_representative TEXT NOT NULL DEFAULT '',
+  rfc TEXT NOT NULL DEFAULT '',
+  street_address TEXT NOT NULL DEFAULT '',
+  colony TEXT NOT NULL DEFAULT '',
+  municipality TEXT NOT NULL DEFAULT '',
+  postal_code TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  state TEXT NOT NULL DEFAULT '',
+  phone TEXT NOT NULL DEFAULT '',
+  mobile TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','info_requested','approved','rejected','suspended')),
+  guarantee_tier TEXT NOT NULL DEFAULT '' CHECK(guarantee_tier IN ('','50k','100k')),
+  remaining_opportunities INTEGER NOT NULL DEFAULT 0,
+  rejection_reason TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  must_change_password INTEGER NOT NULL DEFAULT 0,
+  is_admin INTEGER NOT NULL DEFAULT 0,
+  closed_at TEXT NOT NULL DEFAULT '',
+  info_request TEXT NOT NULL DEFAULT '',
+  assigned_reviewer_id INTEGER NOT NULL DEFAULT 0,
+  assigned_at TEXT NOT NULL DEFAULT '',
+  submitted_at TEXT NOT NULL DEFAULT (datetime('now')),
+  reviewed_at TEXT NOT NULL DEFAULT '',
+  -- suspended_until is RFC3339; empty means the suspension has no end date.
+  suspension_reason TEXT NOT NULL DEFAULT '',
+  suspended_at TEXT NOT NULL DEFAULT '',
+  suspended_until TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO users_new (
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile, status, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at,
+  info_request, assigned_reviewer_id, assigned_at, submitted_at, reviewed_at
+)
+SELECT
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile, status, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at,
+  info_request, assigned_reviewer_id, assigned_at, submitted_at, reviewed_at
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+CREATE INDEX idx_users_review_queue ON users(status, submitted_at);
+
+-- One row per status transition. Actor columns are 0 for the scheduler; for
+-- self-service changes (registration, resubmission) the actor is the user.
+CREATE TABLE user_status_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  actor_user_id INTEGER NOT NULL DEFAULT 0,
+  actor_api_key_id INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX idx_user_status_history_user ON user_status_history(user_id, id);
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_status_history;
+
+CREATE TABLE users_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT NOT NULL UNIQUE,
+  password_hash TEXT NOT NULL,
+  business_name TEXT NOT NULL DEFAULT '',
+  legal_representative TEXT NOT NULL DEFAULT '',
+  rfc TEXT NOT NULL DEFAULT '',
+  street_address TEXT NOT NULL DEFAULT '',
+  colony TEXT NOT NULL DEFAULT '',
+  municipality TEXT NOT NULL DEFAULT '',
+  postal_code TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  state TEXT NOT NULL DEFAULT '',
+  phone TEXT NOT NULL DEFAULT '',
+  mobile TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','info_requested','approved','rejected')),
+  guarantee_tier TEXT NOT NULL DEFAULT '' CHECK(guarantee_tier IN ('','50k','100k')),
+  remaining_opportunities INTEGER NOT NULL DEFAULT 0,
+  rejection_reason TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  must_change_password INTEGER NOT NULL DEFAULT 0,
+  is_admin INTEGER NOT NULL DEFAULT 0,
+  closed_at TEXT NOT NULL DEFAULT '',
+  info_request TEXT NOT NULL DEFAULT '',
+  assigned_reviewer_id INTEGER NOT NULL DEFAULT 0,
+  assigned_at TEXT NOT NULL DEFAULT '',
+  submitted_at TEXT NOT NULL DEFAULT (datetime('now')),
+  reviewed_at TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO users_old (
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile, status, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at,
+  info_request, assigned_reviewer_id, assigned_at, submitted_at, reviewed_at
+)
+SELECT
+  id, email, password_hash, business_name, legal_representative, rfc, street_address, colony,
+  municipality, postal_code, city, state, phone, mobile,
+  CASE status WHEN 'suspended' THEN 'approved' ELSE status END, guarantee_tier,
+  remaining_opportunities, rejection_reason, created_at, must_change_password, is_admin, closed_at,
+  info_request, assigned_reviewer_id, assigned_at, submitted_at, reviewed_at
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+
+CREATE INDEX idx_users_review_queue ON users(status, submitted_at);
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
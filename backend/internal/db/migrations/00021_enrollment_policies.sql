@@ -0,0 +1,18 @@
+-- +goose Up
+-- Per-auction enrollment rules. An auction without a row keeps the original
+-- behaviour: unlimited, always open, every request reviewed by hand.
+CREATE TABLE auction_enrollment_policies (
+  auction_id INTEGER PRIMARY KEY REFERENCES auctions(id) ON DELETE CASCADE,
+  -- Comma-separated guarantee tiers ('50k', '100k') approved without review.
+  auto_approve_tiers TEXT NOT NULL DEFAULT '',
+  -- Lots valued at or above this amount always need an admin; 0 disables.
+  manual_review_above INTEGER NOT NULL DEFAULT 0,
+  -- Pending plus approved enrollments allowed; 0 is unlimited.
+  max_enrollees INTEGER NOT NULL DEFAULT 0,
+  -- Enrollment closes this many minutes before start_time; 0 disables.
+  deadline_minutes_before_start INTEGER NOT NULL DEFAULT 0,
+  updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS auction_enrollment_policies;
@@ -0,0 +1,67 @@
+-- +goose Up
+-- public auctions are listed for everyone, unlisted ones are reachable by
+-- link only, and invite_only ones exist only for invited users.
+ALTER TABLE auctions ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+  CHECK(visibility IN ('public','unlisted','invite_only'));
+
+CREATE TABLE auction_invites (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  auction_id INTEGER NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  invited_by INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(auction_id, user_id)
+);
+
+CREATE INDEX idx_auction_invites_user ON auction_invites(user_id);
+
+-- When set, requests past max_enrollees are queued instead of refused.
+ALTER TABLE auction_enrollment_policies ADD COLUMN waitlist INTEGER NOT NULL DEFAULT 0;
+
+-- Nothing references auction_enrollments, so it can be rebuilt in place to
+-- accept the waitlisted status.
+CREATE TABLE auction_enrollments_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  auction_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','approved','rejected','waitlisted')),
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  FOREIGN KEY (auction_id) REFERENCES auctions(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  UNIQUE(auction_id, user_id)
+);
+
+INSERT INTO auction_enrollments_new (id, auction_id, user_id, status, created_at)
+SELECT id, auction_id, user_id, status, created_at FROM auction_enrollments;
+
+DROP TABLE auction_enrollments;
+ALTER TABLE auction_enrollments_new RENAME TO auction_enrollments;
+
+CREATE INDEX idx_enrollments_auction ON auction_enrollments(auction_id);
+CREATE INDEX idx_enrollments_user ON auction_enrollments(user_id);
+
+-- +goose Down
+CREATE TABLE auction_enrollments_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  auction_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending','approved','rejected')),
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  FOREIGN KEY (auction_id) REFERENCES auctions(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  UNIQUE(auction_id, user_id)
+);
+
+INSERT INTO auction_enrollments_old (id, auction_id, user_id, status, created_at)
+SELECT id, auction_id, user_id, CASE status WHEN 'waitlisted' THEN 'pending' ELSE status END, created_at
+FROM auction_enrollments;
+
+DROP TABLE auction_enrollments;
+ALTER TABLE auction_enrollments_old RENAME TO auction_enrollments;
+
+CREATE INDEX idx_enrollments_auction ON auction_enrollments(auction_id);
+CREATE INDEX idx_enrollments_user ON auction_enrollments(user_id);
+
+ALTER TABLE auction_enrollment_policies DROP COLUMN waitlist;
+DROP TABLE IF EXISTS auction_invites;
+ALTER TABLE auctions DROP COLUMN visibility;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Categories become a tree: parent_id 0 is a top-level category. Slugs are
+-- what the frontend routes on (/categorias/:slug).
+ALTER TABLE categories ADD COLUMN slug TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN parent_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE categories ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+UPDATE categories SET slug = CASE name
+  WHEN 'Maquinaria de construcción' THEN 'maquinaria-de-construccion'
+  WHEN 'Equipo de elevación' THEN 'equipo-de-elevacion'
+  WHEN 'Transporte pesado' THEN 'transporte-pesado'
+  WHEN 'Montacargas' THEN 'montacargas'
+  WHEN 'Generación y energía' THEN 'generacion-y-energia'
+  WHEN 'Equipos industriales' THEN 'equipos-industriales'
+  ELSE 'categoria-' || id
+END;
+
+CREATE UNIQUE INDEX idx_categories_slug ON categories(slug);
+CREATE INDEX idx_categories_parent ON categories(parent_id, position);
+
+CREATE TABLE auction_categories (
+  auction_id INTEGER NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+  category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+  PRIMARY KEY (auction_id, category_id)
+);
+
+CREATE INDEX idx_auction_categories_category ON auction_categories(category_id);
+CREATE INDEX idx_listing_categories_category ON listing_categories(category_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_listing_categories_category;
+DROP TABLE IF EXISTS auction_categories;
+DROP INDEX IF EXISTS idx_categories_parent;
+DROP INDEX IF EXISTS idx_categories_slug;
+ALTER TABLE categories DROP COLUMN position;
+ALTER TABLE categories DROP COLUMN parent_id;
+ALTER TABLE categories DROP COLUMN slug;
@@ -0,0 +1,116 @@
+-- +goose Up
+-- Full-text index over listings and auctions. FTS5 needs go-sqlite3 built
+-- with the sqlite_fts5 tag. unicode61 with remove_diacritics folds case and
+-- accents, so "grua" finds "Grúa". Both kinds share one table: listing N is
+-- rowid 2N, auction N is rowid 2N+1. Triggers keep it in sync.
+CREATE VIRTUAL TABLE search_index USING fts5(
+  title, description, location, categories,
+  tokenize='unicode61 remove_diacritics 2'
+);
+
+INSERT INTO search_index (rowid, title, description, location, categories)
+SELECT l.id * 2, l.title, l.description, l.location,
+       (SELECT COALESCE(group_concat(c.name, ' '), '') FROM listing_categories lc JOIN categories c ON c.id = lc.category_id WHERE lc.listing_id = l.id)
+FROM listings l;
+
+INSERT INTO search_index (rowid, title, description, location, categories)
+SELECT a.id * 2 + 1, a.title, a.description, a.location,
+       (SELECT COALESCE(group_concat(c.name, ' '), '') FROM auction_categories ac JOIN categories c ON c.id = ac.category_id WHERE ac.auction_id = a.id)
+FROM auctions a;
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_search_ai AFTER INSERT ON listings BEGIN
+  INSERT INTO search_index (rowid, title, description, location, categories)
+  VALUES (new.id * 2, new.title, new.description, new.location, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_search_au AFTER UPDATE OF title, description, location ON listings BEGIN
+  UPDATE search_index SET title = new.title, description = new.description, location = new.location
+  WHERE rowid = new.id * 2;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_search_ad AFTER DELETE ON listings BEGIN
+  DELETE FROM search_index WHERE rowid = old.id * 2;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_search_ai AFTER INSERT ON auctions BEGIN
+  INSERT INTO search_index (rowid, title, description, location, categories)
+  VALUES (new.id * 2 + 1, new.title, new.description, new.location, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_search_au AFTER UPDATE OF title, description, location ON auctions BEGIN
+  UPDATE search_index SET title = new.title, description = new.description, location = new.location
+  WHERE rowid = new.id * 2 + 1;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_search_ad AFTER DELETE ON auctions BEGIN
+  DELETE FROM search_index WHERE rowid = old.id * 2 + 1;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listing_categories_search_ai AFTER INSERT ON listing_categories BEGIN
+  UPDATE search_index
+  SET categories = (SELECT COALESCE(group_concat(c.name, ' '), '') FROM listing_categories lc JOIN categories c ON c.id = lc.category_id WHERE lc.listing_id = new.listing_id)
+  WHERE rowid = new.listing_id * 2;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listing_categories_search_ad AFTER DELETE ON listing_categories BEGIN
+  UPDATE search_index
+  SET categories = (SELECT COALESCE(group_concat(c.name, ' '), '') FROM listing_categories lc JOIN categories c ON c.id = lc.category_id WHERE lc.listing_id = old.listing_id)
+  WHERE rowid = old.listing_id * 2;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auction_categories_search_ai AFTER INSERT ON auction_categories BEGIN
+  UPDATE search_index
+  SET categories = (SELECT COALESCE(group_concat(c.name, ' '), '') FROM auction_categories ac JOIN categories c ON c.id = ac.category_id WHERE ac.auction_id = new.auction_id)
+  WHERE rowid = new.auction_id * 2 + 1;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auction_categories_search_ad AFTER DELETE ON auction_categories BEGIN
+  UPDATE search_index
+  SET categories = (SELECT COALESCE(group_concat(c.name, ' '), '') FROM auction_categories ac JOIN categories c ON c.id = ac.category_id WHERE ac.auction_id = old.auction_id)
+  WHERE rowid = old.auction_id * 2 + 1;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER categories_search_au AFTER UPDATE OF name ON categories BEGIN
+  UPDATE search_index
+  SET categories = (SELECT COALESCE(group_concat(c.name, ' '), '') FROM listing_categories lc JOIN categories c ON c.id = lc.category_id WHERE lc.listing_id = search_index.rowid / 2)
+  WHERE rowid % 2 = 0 AND rowid / 2 IN (SELECT listing_id FROM listing_categories WHERE category_id = new.id);
+  UPDATE search_index
+  SET categories = (SELECT COALESCE(group_concat(c.name, ' '), '') FROM auction_categories ac JOIN categories c ON c.id = ac.category_id WHERE ac.auction_id = search_index.rowid / 2)
+  WHERE rowid % 2 = 1 AND rowid / 2 IN (SELECT auction_id FROM auction_categories WHERE category_id = new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS categories_search_au;
+DROP TRIGGER IF EXISTS auction_categories_search_ad;
+DROP TRIGGER IF EXISTS auction_categories_search_ai;
+DROP TRIGGER IF EXISTS listing_categories_search_ad;
+DROP TRIGGER IF EXISTS listing_categories_search_ai;
+DROP TRIGGER IF EXISTS auctions_search_ad;
+DROP TRIGGER IF EXISTS auctions_search_au;
+DROP TRIGGER IF EXISTS auctions_search_ai;
+DROP TRIGGER IF EXISTS listings_search_ad;
+DROP TRIGGER IF EXISTS listings_search_au;
+DROP TRIGGER IF EXISTS listings_search_ai;
+DROP TABLE IF EXISTS search_index;
@@ -0,0 +1,73 @@
+-- +goose Up
+-- Structured specifications. An attribute belongs to a category and applies
+-- to items in it or any subcategory; category_id 0 applies to every item.
+-- type is text, number or enum. Numbers are non-negative; min_value and
+-- max_value bound them, with max_value 0 meaning no upper bound. options is a
+-- JSON array of the allowed enum values.
+CREATE TABLE spec_attributes (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  category_id INTEGER NOT NULL DEFAULT 0,
+  key TEXT NOT NULL,
+  label TEXT NOT NULL,
+  type TEXT NOT NULL CHECK(type IN ('text','number','enum')),
+  unit TEXT NOT NULL DEFAULT '',
+  options TEXT NOT NULL DEFAULT '[]',
+  min_value REAL NOT NULL DEFAULT 0,
+  max_value REAL NOT NULL DEFAULT 0,
+  required INTEGER NOT NULL DEFAULT 0,
+  position INTEGER NOT NULL DEFAULT 0,
+  UNIQUE(category_id, key)
+);
+
+CREATE INDEX idx_spec_attributes_key ON spec_attributes(key);
+
+-- Values are stored as text; numbers are compared with CAST(value AS REAL).
+CREATE TABLE listing_specs (
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  attribute_id INTEGER NOT NULL REFERENCES spec_attributes(id) ON DELETE CASCADE,
+  value TEXT NOT NULL,
+  PRIMARY KEY (listing_id, attribute_id)
+);
+
+CREATE TABLE auction_specs (
+  auction_id INTEGER NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+  attribute_id INTEGER NOT NULL REFERENCES spec_attributes(id) ON DELETE CASCADE,
+  value TEXT NOT NULL,
+  PRIMARY KEY (auction_id, attribute_id)
+);
+
+CREATE INDEX idx_listing_specs_attribute ON listing_specs(attribute_id, value);
+CREATE INDEX idx_auction_specs_attribute ON auction_specs(attribute_id, value);
+
+-- category_id can be 0, so it is not a foreign key; drop a category's
+-- attributes with it.
+-- +goose StatementBegin
+CREATE TRIGGER categories_spec_attributes_ad AFTER DELETE ON categories BEGIN
+  DELETE FROM spec_attributes WHERE category_id = old.id;
+END;
+-- +goose StatementEnd
+
+INSERT INTO spec_attributes (category_id, key, label, type, unit, options, position) VALUES
+  (0, 'make', 'Marca', 'text', '', '[]', 1),
+  (0, 'model', 'Modelo', 'text', '', '[]', 2),
+  (0, 'serial_number', 'Número de serie', 'text', '', '[]', 3),
+  (0, 'hours', 'Horómetro', 'number', 'h', '[]', 4),
+  (0, 'condition', 'Condición', 'enum', '', '["nuevo","usado","reacondicionado"]', 5);
+
+INSERT INTO spec_attributes (category_id, key, label, type, unit, position)
+SELECT id, 'lift_capacity', 'Capacidad de carga', 'number', 't', 10 FROM categories WHERE slug = 'equipo-de-elevacion';
+INSERT INTO spec_attributes (category_id, key, label, type, unit, position)
+SELECT id, 'load_capacity', 'Capacidad de carga', 'number', 'kg', 10 FROM categories WHERE slug = 'montacargas';
+INSERT INTO spec_attributes (category_id, key, label, type, unit, position)
+SELECT id, 'power', 'Potencia', 'number', 'kVA', 10 FROM categories WHERE slug = 'generacion-y-energia';
+INSERT INTO spec_attributes (category_id, key, label, type, unit, position)
+SELECT id, 'operating_weight', 'Peso operativo', 'number', 't', 10 FROM categories WHERE slug = 'maquinaria-de-construccion';
+
+-- +goose Down
+DROP TRIGGER IF EXISTS categories_spec_attributes_ad;
+DROP INDEX IF EXISTS idx_auction_specs_attribute;
+DROP INDEX IF EXISTS idx_listing_specs_attribute;
+DROP TABLE IF EXISTS auction_specs;
+DROP TABLE IF EXISTS listing_specs;
+DROP INDEX IF EXISTS idx_spec_attributes_key;
+DROP TABLE IF EXISTS spec_attributes;
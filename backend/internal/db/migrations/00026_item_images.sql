@@ -0,0 +1,34 @@
+-- +goose Up
+-- Photo galleries for listings and auctions. item_type/item_id point at the
+-- owner the same way the audit log's target_type/target_id do. Each image has
+-- several stored variants (original, large, medium, thumb). The primary
+-- image's large variant is mirrored into the owner's image_url so existing
+-- clients keep showing a cover photo.
+CREATE TABLE item_images (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  item_type TEXT NOT NULL CHECK(item_type IN ('listing','auction')),
+  item_id INTEGER NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  caption TEXT NOT NULL DEFAULT '',
+  is_primary INTEGER NOT NULL DEFAULT 0,
+  filename TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_item_images_item ON item_images(item_type, item_id, position);
+
+CREATE TABLE item_image_variants (
+  image_id INTEGER NOT NULL REFERENCES item_images(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  storage_key TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  size_bytes INTEGER NOT NULL,
+  PRIMARY KEY (image_id, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS item_image_variants;
+DROP INDEX IF EXISTS idx_item_images_item;
+DROP TABLE IF EXISTS item_images;
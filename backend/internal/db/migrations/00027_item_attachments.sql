@@ -0,0 +1,24 @@
+-- +goose Up
+-- Files attached to listings and auctions, such as inspection reports,
+-- maintenance logs and videos. visibility 'enrolled' limits downloads to
+-- bidders with an approved enrollment (auctions) or approved accounts
+-- (listings); the title stays visible to everyone.
+CREATE TABLE item_attachments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  item_type TEXT NOT NULL CHECK(item_type IN ('listing','auction')),
+  item_id INTEGER NOT NULL,
+  title TEXT NOT NULL,
+  visibility TEXT NOT NULL DEFAULT 'public' CHECK(visibility IN ('public','enrolled')),
+  position INTEGER NOT NULL DEFAULT 0,
+  filename TEXT NOT NULL DEFAULT '',
+  content_type TEXT NOT NULL,
+  size_bytes INTEGER NOT NULL,
+  storage_key TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_item_attachments_item ON item_attachments(item_type, item_id, position);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_item_attachments_item;
+DROP TABLE IF EXISTS item_attachments;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Links an auction to the listing it was created from, or the listing an
+-- unsold auction was returned to. outcome stays '' while the auction runs and
+-- is then sold, returned (the listing is back in the catalog) or unsold
+-- (the listing was withdrawn).
+CREATE TABLE listing_auction_links (
+  auction_id INTEGER PRIMARY KEY REFERENCES auctions(id) ON DELETE CASCADE,
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  return_if_unsold INTEGER NOT NULL DEFAULT 0,
+  outcome TEXT NOT NULL DEFAULT '' CHECK(outcome IN ('','sold','returned','unsold')),
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_listing_auction_links_listing ON listing_auction_links(listing_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_listing_auction_links_listing;
+DROP TABLE IF EXISTS listing_auction_links;
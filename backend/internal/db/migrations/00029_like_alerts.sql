@@ -0,0 +1,50 @@
+-- +goose Up
+-- alerted_price/alerted_status record what the alert job last saw for each
+-- like, independently of seen_price/seen_status, which the favorites page
+-- resets whenever the user opens it.
+ALTER TABLE user_likes ADD COLUMN alerted_price INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_likes ADD COLUMN alerted_status TEXT NOT NULL DEFAULT '';
+UPDATE user_likes
+SET alerted_price  = (SELECT price  FROM listings WHERE id = listing_id),
+    alerted_status = (SELECT status FROM listings WHERE id = listing_id);
+
+-- In-app notification feed. email_state is pending until the notification
+-- is emailed on its own or in a digest, and none when email is off.
+CREATE TABLE notifications (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK(kind IN ('price_drop','status_change')),
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  old_price INTEGER NOT NULL DEFAULT 0,
+  new_price INTEGER NOT NULL DEFAULT 0,
+  old_status TEXT NOT NULL DEFAULT '',
+  new_status TEXT NOT NULL DEFAULT '',
+  email_state TEXT NOT NULL DEFAULT 'pending' CHECK(email_state IN ('pending','sent','none')),
+  read_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id);
+CREATE INDEX idx_notifications_email ON notifications(email_state, user_id);
+
+-- Users without a row get the defaults: every alert, instant email and
+-- WebSocket pushes.
+CREATE TABLE notification_preferences (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  price_drops INTEGER NOT NULL DEFAULT 1,
+  status_changes INTEGER NOT NULL DEFAULT 1,
+  email_mode TEXT NOT NULL DEFAULT 'instant' CHECK(email_mode IN ('instant','digest','off')),
+  websocket INTEGER NOT NULL DEFAULT 1,
+  last_digest_at TEXT NOT NULL DEFAULT '',
+  updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS idx_notifications_email;
+DROP INDEX IF EXISTS idx_notifications_user;
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE user_likes DROP COLUMN alerted_status;
+ALTER TABLE user_likes DROP COLUMN alerted_price;
@@ -0,0 +1,103 @@
+-- +goose Up
+-- Users follow auctions the way they like listings. lead_minutes is how long
+-- before the start and the end the "starting soon" and "ending soon" alerts
+-- fire; alerted_bid is the bid last reported, and the *_sent flags record
+-- which one-off alerts already went out.
+CREATE TABLE auction_watches (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  auction_id INTEGER NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+  lead_minutes INTEGER NOT NULL DEFAULT 60,
+  alerted_bid INTEGER NOT NULL DEFAULT 0,
+  starting_sent INTEGER NOT NULL DEFAULT 0,
+  ending_sent INTEGER NOT NULL DEFAULT 0,
+  result_sent INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(user_id, auction_id)
+);
+
+CREATE INDEX idx_auction_watches_auction ON auction_watches(auction_id);
+
+ALTER TABLE notification_preferences ADD COLUMN auction_starting INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE notification_preferences ADD COLUMN auction_ending INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE notification_preferences ADD COLUMN auction_bids INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE notification_preferences ADD COLUMN auction_results INTEGER NOT NULL DEFAULT 1;
+
+-- Notifications now point at a listing or an auction, like item_images;
+-- triggers stand in for the foreign key when either is deleted.
+CREATE TABLE notifications_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK(kind IN ('price_drop','status_change','auction_starting','auction_ending','auction_bid','auction_result')),
+  item_type TEXT NOT NULL CHECK(item_type IN ('listing','auction')),
+  item_id INTEGER NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  old_price INTEGER NOT NULL DEFAULT 0,
+  new_price INTEGER NOT NULL DEFAULT 0,
+  old_status TEXT NOT NULL DEFAULT '',
+  new_status TEXT NOT NULL DEFAULT '',
+  email_state TEXT NOT NULL DEFAULT 'pending' CHECK(email_state IN ('pending','sent','none')),
+  read_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO notifications_new (id, user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at)
+SELECT id, user_id, kind, 'listing', listing_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at
+FROM notifications;
+
+DROP TABLE notifications;
+ALTER TABLE notifications_new RENAME TO notifications;
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id);
+CREATE INDEX idx_notifications_email ON notifications(email_state, user_id);
+CREATE INDEX idx_notifications_item ON notifications(item_type, item_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_notifications_ad AFTER DELETE ON listings BEGIN
+  DELETE FROM notifications WHERE item_type = 'listing' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_notifications_ad AFTER DELETE ON auctions BEGIN
+  DELETE FROM notifications WHERE item_type = 'auction' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS auctions_notifications_ad;
+DROP TRIGGER IF EXISTS listings_notifications_ad;
+
+CREATE TABLE notifications_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK(kind IN ('price_drop','status_change')),
+  listing_id INTEGER NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  old_price INTEGER NOT NULL DEFAULT 0,
+  new_price INTEGER NOT NULL DEFAULT 0,
+  old_status TEXT NOT NULL DEFAULT '',
+  new_status TEXT NOT NULL DEFAULT '',
+  email_state TEXT NOT NULL DEFAULT 'pending' CHECK(email_state IN ('pending','sent','none')),
+  read_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO notifications_old (id, user_id, kind, listing_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at)
+SELECT id, user_id, kind, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at
+FROM notifications WHERE item_type = 'listing';
+
+DROP TABLE notifications;
+ALTER TABLE notifications_old RENAME TO notifications;
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id);
+CREATE INDEX idx_notifications_email ON notifications(email_state, user_id);
+
+ALTER TABLE notification_preferences DROP COLUMN auction_results;
+ALTER TABLE notification_preferences DROP COLUMN auction_bids;
+ALTER TABLE notification_preferences DROP COLUMN auction_ending;
+ALTER TABLE notification_preferences DROP COLUMN auction_starting;
+DROP INDEX IF EXISTS idx_auction_watches_auction;
+DROP TABLE IF EXISTS auction_watches;
@@ -0,0 +1,206 @@
+-- +goose Up
+-- A saved catalog query. query is the catalog query string as the user sent
+-- it; filter_json and match_query are its parsed form, which the match job
+-- evaluates. item_types is all, listing or auction. seen_match_id is the last
+-- match the user has seen, for "new since last visit" counts.
+CREATE TABLE saved_searches (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  item_types TEXT NOT NULL DEFAULT 'all' CHECK(item_types IN ('all','listing','auction')),
+  query TEXT NOT NULL DEFAULT '',
+  filter_json TEXT NOT NULL DEFAULT '{}',
+  match_query TEXT NOT NULL DEFAULT '',
+  notify INTEGER NOT NULL DEFAULT 1,
+  seen_match_id INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX idx_saved_searches_user ON saved_searches(user_id);
+
+-- Every item a saved search has matched. Items matching when the search is
+-- saved are recorded as baseline so they never count as new.
+CREATE TABLE saved_search_matches (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+  item_type TEXT NOT NULL CHECK(item_type IN ('listing','auction')),
+  item_id INTEGER NOT NULL,
+  baseline INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(search_id, item_type, item_id)
+);
+
+CREATE INDEX idx_saved_search_matches_item ON saved_search_matches(item_type, item_id);
+
+-- Items created or changed since the match job last ran. A change to an item
+-- already queued moves it to the end.
+CREATE TABLE catalog_changes (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  item_type TEXT NOT NULL,
+  item_id INTEGER NOT NULL,
+  UNIQUE(item_type, item_id)
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_changes_ai AFTER INSERT ON listings BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('listing', new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_changes_au AFTER UPDATE ON listings BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('listing', new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listing_categories_changes_ai AFTER INSERT ON listing_categories BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('listing', new.listing_id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listing_specs_changes_ai AFTER INSERT ON listing_specs BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('listing', new.listing_id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_changes_ai AFTER INSERT ON auctions BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('auction', new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_changes_au AFTER UPDATE ON auctions BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('auction', new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auction_categories_changes_ai AFTER INSERT ON auction_categories BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('auction', new.auction_id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auction_specs_changes_ai AFTER INSERT ON auction_specs BEGIN
+  INSERT OR REPLACE INTO catalog_changes (item_type, item_id) VALUES ('auction', new.auction_id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_saved_search_matches_ad AFTER DELETE ON listings BEGIN
+  DELETE FROM saved_search_matches WHERE item_type = 'listing' AND item_id = old.id;
+  DELETE FROM catalog_changes WHERE item_type = 'listing' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_saved_search_matches_ad AFTER DELETE ON auctions BEGIN
+  DELETE FROM saved_search_matches WHERE item_type = 'auction' AND item_id = old.id;
+  DELETE FROM catalog_changes WHERE item_type = 'auction' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- Accept search_match notifications. The delete triggers name the table, so
+-- they are dropped for the rebuild and created again after it.
+DROP TRIGGER IF EXISTS auctions_notifications_ad;
+DROP TRIGGER IF EXISTS listings_notifications_ad;
+
+CREATE TABLE notifications_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK(kind IN ('price_drop','status_change','auction_starting','auction_ending','auction_bid','auction_result','search_match')),
+  item_type TEXT NOT NULL CHECK(item_type IN ('listing','auction')),
+  item_id INTEGER NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  old_price INTEGER NOT NULL DEFAULT 0,
+  new_price INTEGER NOT NULL DEFAULT 0,
+  old_status TEXT NOT NULL DEFAULT '',
+  new_status TEXT NOT NULL DEFAULT '',
+  email_state TEXT NOT NULL DEFAULT 'pending' CHECK(email_state IN ('pending','sent','none')),
+  read_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO notifications_new SELECT * FROM notifications;
+
+DROP TABLE notifications;
+ALTER TABLE notifications_new RENAME TO notifications;
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id);
+CREATE INDEX idx_notifications_email ON notifications(email_state, user_id);
+CREATE INDEX idx_notifications_item ON notifications(item_type, item_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_notifications_ad AFTER DELETE ON listings BEGIN
+  DELETE FROM notifications WHERE item_type = 'listing' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_notifications_ad AFTER DELETE ON auctions BEGIN
+  DELETE FROM notifications WHERE item_type = 'auction' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS auctions_notifications_ad;
+DROP TRIGGER IF EXISTS listings_notifications_ad;
+
+CREATE TABLE notifications_old (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK(kind IN ('price_drop','status_change','auction_starting','auction_ending','auction_bid','auction_result')),
+  item_type TEXT NOT NULL CHECK(item_type IN ('listing','auction')),
+  item_id INTEGER NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  old_price INTEGER NOT NULL DEFAULT 0,
+  new_price INTEGER NOT NULL DEFAULT 0,
+  old_status TEXT NOT NULL DEFAULT '',
+  new_status TEXT NOT NULL DEFAULT '',
+  email_state TEXT NOT NULL DEFAULT 'pending' CHECK(email_state IN ('pending','sent','none')),
+  read_at TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO notifications_old SELECT * FROM notifications WHERE kind != 'search_match';
+
+DROP TABLE notifications;
+ALTER TABLE notifications_old RENAME TO notifications;
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id);
+CREATE INDEX idx_notifications_email ON notifications(email_state, user_id);
+CREATE INDEX idx_notifications_item ON notifications(item_type, item_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER listings_notifications_ad AFTER DELETE ON listings BEGIN
+  DELETE FROM notifications WHERE item_type = 'listing' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER auctions_notifications_ad AFTER DELETE ON auctions BEGIN
+  DELETE FROM notifications WHERE item_type = 'auction' AND item_id = old.id;
+END;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS auctions_saved_search_matches_ad;
+DROP TRIGGER IF EXISTS listings_saved_search_matches_ad;
+DROP TRIGGER IF EXISTS auction_specs_changes_ai;
+DROP TRIGGER IF EXISTS auction_categories_changes_ai;
+DROP TRIGGER IF EXISTS auctions_changes_au;
+DROP TRIGGER IF EXISTS auctions_changes_ai;
+DROP TRIGGER IF EXISTS listing_specs_changes_ai;
+DROP TRIGGER IF EXISTS listing_categories_changes_ai;
+DROP TRIGGER IF EXISTS listings_changes_au;
+DROP TRIGGER IF EXISTS listings_changes_ai;
+DROP TABLE IF EXISTS catalog_changes;
+DROP INDEX IF EXISTS idx_saved_search_matches_item;
+DROP TABLE IF EXISTS saved_search_matches;
+DROP INDEX IF EXISTS idx_saved_searches_user;
+DROP TABLE IF EXISTS saved_searches;
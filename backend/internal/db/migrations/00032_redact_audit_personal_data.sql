@@ -0,0 +1,31 @@
+-- +goose Up
+-- User snapshots in the audit log used to include contact and fiscal details,
+-- which an account closure must be able to erase. The log is append-only, so
+-- the update trigger is lifted just long enough to strip those fields.
+DROP TRIGGER admin_audit_log_no_update;
+
+UPDATE admin_audit_log
+SET before_json = CASE WHEN json_valid(before_json) AND json_type(before_json) = 'object' THEN json_remove(before_json,
+      '$.email', '$.business_name', '$.legal_representative', '$.rfc', '$.street_address', '$.colony',
+      '$.municipality', '$.postal_code', '$.city', '$.state', '$.phone', '$.mobile',
+      '$.previous_business_name', '$.previous_legal_representative', '$.previous_rfc') ELSE before_json END,
+    after_json = CASE WHEN json_valid(after_json) AND json_type(after_json) = 'object' THEN json_remove(after_json,
+      '$.email', '$.business_name', '$.legal_representative', '$.rfc', '$.street_address', '$.colony',
+      '$.municipality', '$.postal_code', '$.city', '$.state', '$.phone', '$.mobile',
+      '$.previous_business_name', '$.previous_legal_representative', '$.previous_rfc') ELSE after_json END,
+    changes_json = CASE WHEN json_valid(changes_json) AND json_type(changes_json) = 'object' THEN json_remove(changes_json,
+      '$.email', '$.business_name', '$.legal_representative', '$.rfc', '$.street_address', '$.colony',
+      '$.municipality', '$.postal_code', '$.city', '$.state', '$.phone', '$.mobile',
+      '$.previous_business_name', '$.previous_legal_representative', '$.previous_rfc') ELSE changes_json END
+WHERE target_type IN ('user', 'profile_change');
+
+-- +goose StatementBegin
+CREATE TRIGGER admin_audit_log_no_update BEFORE UPDATE ON admin_audit_log
+BEGIN
+  SELECT RAISE(ABORT, 'admin_audit_log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- The removed values cannot be restored.
+SELECT 1;
@@ -0,0 +1,108 @@
+package db
+
+import "context"
+
+type APIKey struct {
+	ID         int64  `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`
+	Prefix     string `json:"prefix" db:"prefix"`
+	SecretHash string `json:"-" db:"secret_hash"`
+	Scopes     string `json:"scopes" db:"scopes"`
+	CreatedBy  int64  `json:"created_by" db:"created_by"`
+	ExpiresAt  string `json:"expires_at" db:"expires_at"`
+	LastUsedAt string `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  string `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+}
+
+type CreateAPIKeyParams struct {
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scopes     string
+	CreatedBy  int64
+	ExpiresAt  string
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }, i *APIKey) error {
+	return row.Scan(
+		&i.ID, &i.Name, &i.Prefix, &i.SecretHash, &i.Scopes,
+		&i.CreatedBy, &i.ExpiresAt, &i.LastUsedAt, &i.RevokedAt, &i.CreatedAt,
+	)
+}
+
+const createAPIKey = `
+INSERT INTO api_keys (name, prefix, secret_hash, scopes, created_by, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at;
+`
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error) {
+	row := q.db.QueryRowContext(ctx, createAPIKey,
+		arg.Name, arg.Prefix, arg.SecretHash, arg.Scopes, arg.CreatedBy, arg.ExpiresAt,
+	)
+	var i APIKey
+	err := scanAPIKey(row, &i)
+	return i, err
+}
+
+const getAPIKeyByPrefix = `
+SELECT id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+WHERE prefix = ?;
+`
+
+func (q *Queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByPrefix, prefix)
+	var i APIKey
+	err := scanAPIKey(row, &i)
+	return i, err
+}
+
+const listAPIKeys = `
+SELECT id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at
+FROM api_keys
+ORDER BY created_at DESC, id DESC;
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := scanAPIKey(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// RevokeAPIKey marks a key as revoked. Returns sql.ErrNoRows if the key does
+// not exist or was already revoked.
+const revokeAPIKey = `
+UPDATE api_keys
+SET revoked_at = datetime('now')
+WHERE id = ? AND revoked_at = ''
+RETURNING id, name, prefix, secret_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at;
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int64) (APIKey, error) {
+	row := q.db.QueryRowContext(ctx, revokeAPIKey, id)
+	var i APIKey
+	err := scanAPIKey(row, &i)
+	return i, err
+}
+
+const touchAPIKey = `
+UPDATE api_keys SET last_used_at = datetime('now') WHERE id = ?;
+`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, touchAPIKey, id)
+	return err
+}
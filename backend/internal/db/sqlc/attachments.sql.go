@@ -0,0 +1,113 @@
+package db
+
+import "context"
+
+type ItemAttachment struct {
+	ID          int64  `json:"id" db:"id"`
+	ItemType    string `json:"item_type" db:"item_type"`
+	ItemID      int64  `json:"item_id" db:"item_id"`
+	Title       string `json:"title" db:"title"`
+	Visibility  string `json:"visibility" db:"visibility"`
+	Position    int64  `json:"position" db:"position"`
+	Filename    string `json:"filename" db:"filename"`
+	ContentType string `json:"content_type" db:"content_type"`
+	SizeBytes   int64  `json:"size_bytes" db:"size_bytes"`
+	StorageKey  string `json:"-" db:"storage_key"`
+	CreatedAt   string `json:"created_at" db:"created_at"`
+}
+
+const itemAttachmentColumns = `id, item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key, created_at`
+
+func scanItemAttachment(row interface{ Scan(dest ...any) error }, i *ItemAttachment) error {
+	return row.Scan(&i.ID, &i.ItemType, &i.ItemID, &i.Title, &i.Visibility, &i.Position, &i.Filename, &i.ContentType, &i.SizeBytes, &i.StorageKey, &i.CreatedAt)
+}
+
+type CreateItemAttachmentParams struct {
+	ItemType    string
+	ItemID      int64
+	Title       string
+	Visibility  string
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string
+}
+
+const createItemAttachment = `
+INSERT INTO item_attachments (item_type, item_id, title, visibility, position, filename, content_type, size_bytes, storage_key)
+VALUES (?1, ?2, ?3, ?4,
+        (SELECT COALESCE(MAX(position) + 1, 0) FROM item_attachments WHERE item_type = ?1 AND item_id = ?2),
+        ?5, ?6, ?7, ?8)
+RETURNING ` + itemAttachmentColumns + `;
+`
+
+// CreateItemAttachment appends an attachment after the item's existing ones.
+func (q *Queries) CreateItemAttachment(ctx context.Context, arg CreateItemAttachmentParams) (ItemAttachment, error) {
+	var i ItemAttachment
+	err := scanItemAttachment(q.db.QueryRowContext(ctx, createItemAttachment,
+		arg.ItemType, arg.ItemID, arg.Title, arg.Visibility, arg.Filename, arg.ContentType, arg.SizeBytes, arg.StorageKey,
+	), &i)
+	return i, err
+}
+
+const getItemAttachment = `
+SELECT ` + itemAttachmentColumns + ` FROM item_attachments WHERE id = ?;
+`
+
+func (q *Queries) GetItemAttachment(ctx context.Context, id int64) (ItemAttachment, error) {
+	var i ItemAttachment
+	err := scanItemAttachment(q.db.QueryRowContext(ctx, getItemAttachment, id), &i)
+	return i, err
+}
+
+const listItemAttachments = `
+SELECT ` + itemAttachmentColumns + ` FROM item_attachments
+WHERE item_type = ? AND item_id = ?
+ORDER BY position, id;
+`
+
+func (q *Queries) ListItemAttachments(ctx context.Context, itemType string, itemID int64) ([]ItemAttachment, error) {
+	rows, err := q.db.QueryContext(ctx, listItemAttachments, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ItemAttachment{}
+	for rows.Next() {
+		var i ItemAttachment
+		if err := scanItemAttachment(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type UpdateItemAttachmentParams struct {
+	ID         int64
+	Title      string
+	Visibility string
+	Position   int64
+}
+
+const updateItemAttachment = `
+UPDATE item_attachments SET title = ?, visibility = ?, position = ? WHERE id = ?
+RETURNING ` + itemAttachmentColumns + `;
+`
+
+func (q *Queries) UpdateItemAttachment(ctx context.Context, arg UpdateItemAttachmentParams) (ItemAttachment, error) {
+	var i ItemAttachment
+	err := scanItemAttachment(q.db.QueryRowContext(ctx, updateItemAttachment, arg.Title, arg.Visibility, arg.Position, arg.ID), &i)
+	return i, err
+}
+
+const deleteItemAttachment = `
+DELETE FROM item_attachments WHERE id = ?;
+`
+
+// DeleteItemAttachment removes the row; the stored file is the caller's to
+// delete.
+func (q *Queries) DeleteItemAttachment(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteItemAttachment, id)
+	return err
+}
@@ -4,7 +4,7 @@ import "context"
 
 const auctionColumns = `id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible`
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility`
 
 func scanAuction(row interface{ Scan(dest ...any) error }, i *Auction) error {
 	return row.Scan(
@@ -27,21 +27,28 @@ func scanAuction(row interface{ Scan(dest ...any) error }, i *Auction) error {
 		&i.AutoExtendMinutes,
 		&i.AutoExtendWindowMinutes,
 		&i.PriceVisible,
+		&i.Visibility,
 	)
 }
 
 const listActiveAuctions = `
 SELECT id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 WHERE status = 'active'
+  AND (visibility = 'public'
+       OR (visibility = 'invite_only'
+           AND EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = auctions.id AND ai.user_id = ?)))
 ORDER BY datetime(end_time) ASC
 LIMIT ?;
 `
 
-func (q *Queries) ListActiveAuctions(ctx context.Context, limit int64) ([]Auction, error) {
-	rows, err := q.db.QueryContext(ctx, listActiveAuctions, limit)
+// ListActiveAuctions lists the active auctions viewerID may browse: public
+// ones plus invite-only ones they were invited to. Unlisted auctions are
+// never listed; viewerID 0 is an anonymous visitor.
+func (q *Queries) ListActiveAuctions(ctx context.Context, viewerID, limit int64) ([]Auction, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveAuctions, viewerID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +70,7 @@ func (q *Queries) ListActiveAuctions(ctx context.Context, limit int64) ([]Auctio
 const getAuction = `
 SELECT id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 WHERE id = ?;
 `
@@ -78,11 +85,11 @@ func (q *Queries) GetAuction(ctx context.Context, id int64) (Auction, error) {
 const createAuction = `
 INSERT INTO auctions (title, description, location, current_bid, reserve_price, status, end_time, image_url,
                       start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct,
-                      auto_extend_minutes, auto_extend_window_minutes, price_visible)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+                      auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
           start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-          auto_extend_minutes, auto_extend_window_minutes, price_visible;
+          auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility;
 `
 
 func (q *Queries) CreateAuction(ctx context.Context, arg CreateAuctionParams) (Auction, error) {
@@ -103,6 +110,7 @@ func (q *Queries) CreateAuction(ctx context.Context, arg CreateAuctionParams) (A
 		arg.AutoExtendMinutes,
 		arg.AutoExtendWindowMinutes,
 		arg.PriceVisible,
+		arg.Visibility,
 	)
 	var i Auction
 	err := scanAuction(row, &i)
@@ -126,11 +134,12 @@ SET title = ?,
     buyer_premium_pct = ?,
     auto_extend_minutes = ?,
     auto_extend_window_minutes = ?,
-    price_visible = ?
+    price_visible = ?,
+    visibility = ?
 WHERE id = ?
 RETURNING id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
           start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-          auto_extend_minutes, auto_extend_window_minutes, price_visible;
+          auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility;
 `
 
 func (q *Queries) UpdateAuction(ctx context.Context, arg UpdateAuctionParams) (Auction, error) {
@@ -151,6 +160,7 @@ func (q *Queries) UpdateAuction(ctx context.Context, arg UpdateAuctionParams) (A
 		arg.AutoExtendMinutes,
 		arg.AutoExtendWindowMinutes,
 		arg.PriceVisible,
+		arg.Visibility,
 		arg.ID,
 	)
 	var i Auction
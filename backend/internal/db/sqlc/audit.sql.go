@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+type AuditEntry struct {
+	ID            int64  `json:"id" db:"id"`
+	ActorUserID   int64  `json:"actor_user_id" db:"actor_user_id"`
+	ActorAPIKeyID int64  `json:"actor_api_key_id" db:"actor_api_key_id"`
+	Action        string `json:"action" db:"action"`
+	TargetType    string `json:"target_type" db:"target_type"`
+	TargetID      int64  `json:"target_id" db:"target_id"`
+	BeforeJSON    string `json:"before_json" db:"before_json"`
+	AfterJSON     string `json:"after_json" db:"after_json"`
+	ChangesJSON   string `json:"changes_json" db:"changes_json"`
+	RequestID     string `json:"request_id" db:"request_id"`
+	IP            string `json:"ip" db:"ip"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
+}
+
+type CreateAuditEntryParams struct {
+	ActorUserID   int64
+	ActorAPIKeyID int64
+	Action        string
+	TargetType    string
+	TargetID      int64
+	BeforeJSON    string
+	AfterJSON     string
+	ChangesJSON   string
+	RequestID     string
+	IP            string
+}
+
+const auditColumns = `id, actor_user_id, actor_api_key_id, action, target_type, target_id,
+       before_json, after_json, changes_json, request_id, ip, created_at`
+
+func scanAuditEntry(row interface{ Scan(dest ...any) error }, i *AuditEntry) error {
+	return row.Scan(
+		&i.ID, &i.ActorUserID, &i.ActorAPIKeyID, &i.Action, &i.TargetType, &i.TargetID,
+		&i.BeforeJSON, &i.AfterJSON, &i.ChangesJSON, &i.RequestID, &i.IP, &i.CreatedAt,
+	)
+}
+
+const createAuditEntry = `
+INSERT INTO admin_audit_log (actor_user_id, actor_api_key_id, action, target_type, target_id,
+                             before_json, after_json, changes_json, request_id, ip)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + auditColumns + `;
+`
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditEntry, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEntry,
+		arg.ActorUserID, arg.ActorAPIKeyID, arg.Action, arg.TargetType, arg.TargetID,
+		arg.BeforeJSON, arg.AfterJSON, arg.ChangesJSON, arg.RequestID, arg.IP,
+	)
+	var i AuditEntry
+	err := scanAuditEntry(row, &i)
+	return i, err
+}
+
+// AuditFilter narrows audit queries. Zero values are ignored. From and To
+// bound created_at and accept anything SQLite's datetime() understands.
+type AuditFilter struct {
+	ActorUserID   int64
+	ActorAPIKeyID int64
+	Action        string
+	TargetType    string
+	TargetID      int64
+	From          string
+	To            string
+}
+
+func (f AuditFilter) where() (string, []any) {
+	var conds []string
+	var args []any
+	if f.ActorUserID != 0 {
+		conds = append(conds, "actor_user_id = ?")
+		args = append(args, f.ActorUserID)
+	}
+	if f.ActorAPIKeyID != 0 {
+		conds = append(conds, "actor_api_key_id = ?")
+		args = append(args, f.ActorAPIKeyID)
+	}
+	if f.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, f.Action)
+	}
+	if f.TargetType != "" {
+		conds = append(conds, "target_type = ?")
+		args = append(args, f.TargetType)
+	}
+	if f.TargetID != 0 {
+		conds = append(conds, "target_id = ?")
+		args = append(args, f.TargetID)
+	}
+	if f.From != "" {
+		conds = append(conds, "datetime(created_at) >= datetime(?)")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		conds = append(conds, "datetime(created_at) <= datetime(?)")
+		args = append(args, f.To)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (q *Queries) ListAuditEntries(ctx context.Context, f AuditFilter, limit, offset int64) ([]AuditEntry, error) {
+	where, args := f.where()
+	query := "SELECT " + auditColumns + " FROM admin_audit_log " + where + " ORDER BY id DESC LIMIT ? OFFSET ?"
+	rows, err := q.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEntry
+	for rows.Next() {
+		var i AuditEntry
+		if err := scanAuditEntry(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (q *Queries) CountAuditEntries(ctx context.Context, f AuditFilter) (int64, error) {
+	where, args := f.where()
+	var count int64
+	err := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM admin_audit_log "+where, args...).Scan(&count)
+	return count, err
+}
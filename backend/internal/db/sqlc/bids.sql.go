@@ -101,7 +101,7 @@ func (q *Queries) CloseExpiredAuctions(ctx context.Context) error {
 const listAllAuctions = `
 SELECT id, title, description, location, current_bid, reserve_price, status, end_time, image_url, created_at,
        start_time, sale_mode, fixed_price, min_bid_increment, buyer_premium_pct, highest_bidder_id,
-       auto_extend_minutes, auto_extend_window_minutes, price_visible
+       auto_extend_minutes, auto_extend_window_minutes, price_visible, visibility
 FROM auctions
 ORDER BY created_at DESC
 LIMIT ?;
@@ -132,3 +132,40 @@ func (q *Queries) ExtendAuctionEndTime(ctx context.Context, endTime string, id i
 	_, err := q.db.ExecContext(ctx, extendAuctionEndTime, endTime, id)
 	return err
 }
+
+const listBidsForUser = `
+SELECT id, auction_id, user_id, amount, created_at
+FROM bids
+WHERE user_id = ?
+ORDER BY id;
+`
+
+func (q *Queries) ListBidsForUser(ctx context.Context, userID int64) ([]Bid, error) {
+	rows, err := q.db.QueryContext(ctx, listBidsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bid{}
+	for rows.Next() {
+		var i Bid
+		if err := rows.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Amount, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const countLeadingAuctions = `
+SELECT COUNT(*)
+FROM auctions
+WHERE highest_bidder_id = ? AND status IN ('active', 'scheduled');
+`
+
+// CountLeadingAuctions counts open auctions where the user holds the highest bid.
+func (q *Queries) CountLeadingAuctions(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countLeadingAuctions, userID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,459 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+// CatalogFilter narrows the public listing and auction catalogs. Zero values
+// are ignored. Price bounds apply to price on listings and current_bid on
+// auctions; SaleType matches sale_type on listings and sale_mode on auctions.
+// Year bounds only apply to listings. CategoryID includes descendants.
+type CatalogFilter struct {
+	Status     string
+	PriceMin   int64
+	PriceMax   int64
+	YearMin    int64
+	YearMax    int64
+	Location   string
+	SaleType   string
+	CategoryID int64
+	Specs      []SpecFilter
+	// ViewerID is used for auction visibility: invite-only auctions are only
+	// listed for invited viewers and unlisted ones never are.
+	ViewerID int64
+}
+
+// likeEscaper makes user input match literally inside a LIKE pattern that
+// declares ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SpecFilter matches items by a specification key. Value is compared
+// case-insensitively; Min and Max bound number values and are ignored when
+// zero.
+type SpecFilter struct {
+	Key   string
+	Value string
+	Min   float64
+	Max   float64
+}
+
+type catalogTable struct {
+	name       string
+	price      string
+	saleType   string
+	year       string
+	joinTable  string
+	joinColumn string
+	specTable  string
+	visibility bool
+}
+
+var (
+	listingCatalog = catalogTable{
+		name: "listings", price: "listings.price", saleType: "listings.sale_type", year: "listings.year",
+		joinTable: "listing_categories", joinColumn: "listing_id", specTable: "listing_specs",
+	}
+	auctionCatalog = catalogTable{
+		name: "auctions", price: "auctions.current_bid", saleType: "auctions.sale_mode",
+		joinTable: "auction_categories", joinColumn: "auction_id", specTable: "auction_specs", visibility: true,
+	}
+)
+
+// conds returns the filter as SQL conditions on t. Queries using it must be
+// prefixed with categorySubtree.
+func (f CatalogFilter) conds(t catalogTable) ([]string, []any) {
+	var conds []string
+	var args []any
+	if t.visibility {
+		conds = append(conds, `(auctions.visibility = 'public'
+       OR (auctions.visibility = 'invite_only'
+           AND EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = auctions.id AND ai.user_id = ?)))`)
+		args = append(args, f.ViewerID)
+	}
+	if f.Status != "" {
+		conds = append(conds, t.name+".status = ?")
+		args = append(args, f.Status)
+	}
+	if f.PriceMin != 0 {
+		conds = append(conds, t.price+" >= ?")
+		args = append(args, f.PriceMin)
+	}
+	if f.PriceMax != 0 {
+		conds = append(conds, t.price+" <= ?")
+		args = append(args, f.PriceMax)
+	}
+	if t.year != "" && f.YearMin != 0 {
+		conds = append(conds, t.year+" >= ?")
+		args = append(args, f.YearMin)
+	}
+	if t.year != "" && f.YearMax != 0 {
+		conds = append(conds, t.year+" <= ?")
+		args = append(args, f.YearMax)
+	}
+	if f.Location != "" {
+		conds = append(conds, t.name+".location LIKE '%' || ? || '%' ESCAPE '\\'")
+		args = append(args, likeEscaper.Replace(f.Location))
+	}
+	if f.SaleType != "" {
+		conds = append(conds, t.saleType+" = ?")
+		args = append(args, f.SaleType)
+	}
+	if f.CategoryID != 0 {
+		conds = append(conds, t.name+".id IN (SELECT j."+t.joinColumn+" FROM "+t.joinTable+" j JOIN subtree s ON s.id = j.category_id WHERE s.root_id = ?)")
+		args = append(args, f.CategoryID)
+	}
+	for _, spec := range f.Specs {
+		match := []string{"sa.key = ?"}
+		specArgs := []any{spec.Key}
+		if spec.Value != "" {
+			match = append(match, "v.value = ? COLLATE NOCASE")
+			specArgs = append(specArgs, spec.Value)
+		}
+		if spec.Min != 0 {
+			match = append(match, "CAST(v.value AS REAL) >= ?")
+			specArgs = append(specArgs, spec.Min)
+		}
+		if spec.Max != 0 {
+			match = append(match, "CAST(v.value AS REAL) <= ?")
+			specArgs = append(specArgs, spec.Max)
+		}
+		conds = append(conds, t.name+".id IN (SELECT v."+t.joinColumn+" FROM "+t.specTable+" v JOIN spec_attributes sa ON sa.id = v.attribute_id WHERE "+strings.Join(match, " AND ")+")")
+		args = append(args, specArgs...)
+	}
+	return conds, args
+}
+
+func (f CatalogFilter) where(t catalogTable) (string, []any) {
+	conds, args := f.conds(t)
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+type catalogSort struct {
+	expr    string
+	desc    bool
+	numeric bool
+}
+
+// ListingSorts and AuctionSorts are the sort orders each catalog accepts;
+// the first is the default.
+var (
+	ListingSorts = []string{"newest", "price_asc", "price_desc", "year_asc", "year_desc"}
+	AuctionSorts = []string{"ending_soon", "newest", "price_asc", "price_desc"}
+)
+
+var (
+	listingSorts = map[string]catalogSort{
+		"newest":     {expr: "listings.created_at", desc: true},
+		"price_asc":  {expr: "listings.price", numeric: true},
+		"price_desc": {expr: "listings.price", desc: true, numeric: true},
+		"year_asc":   {expr: "listings.year", numeric: true},
+		"year_desc":  {expr: "listings.year", desc: true, numeric: true},
+	}
+	auctionSorts = map[string]catalogSort{
+		"ending_soon": {expr: "COALESCE(datetime(auctions.end_time), '')"},
+		"newest":      {expr: "auctions.created_at", desc: true},
+		"price_asc":   {expr: "auctions.current_bid", numeric: true},
+		"price_desc":  {expr: "auctions.current_bid", desc: true, numeric: true},
+	}
+)
+
+// CatalogCursor is the sort key and id of the last row on a page; the next
+// page starts strictly after it.
+type CatalogCursor struct {
+	Key string
+	ID  int64
+}
+
+// catalogQuery builds a keyset-paginated page query. It selects columns plus
+// the sort key as text ('' when it is NULL) and fetches one extra row to tell
+// whether another page follows.
+func catalogQuery(t catalogTable, columns string, f CatalogFilter, sort catalogSort, after *CatalogCursor, limit int64) (string, []any) {
+	conds, args := f.conds(t)
+	dir, cmp := "ASC", ">"
+	if sort.desc {
+		dir, cmp = "DESC", "<"
+	}
+	if after != nil {
+		param := "?"
+		if sort.numeric {
+			param = "CAST(? AS INTEGER)"
+		}
+		conds = append(conds, "("+sort.expr+" "+cmp+" "+param+" OR ("+sort.expr+" = "+param+" AND "+t.name+".id "+cmp+" ?))")
+		args = append(args, after.Key, after.Key, after.ID)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	query := categorySubtree + `
+SELECT ` + columns + `, COALESCE(CAST(` + sort.expr + ` AS TEXT), '')
+FROM ` + t.name + `
+` + where + `
+ORDER BY ` + sort.expr + ` ` + dir + `, ` + t.name + `.id ` + dir + `
+LIMIT ?`
+	return query, append(args, limit+1)
+}
+
+// ListCatalogListings returns one page of listings matching f in the named
+// sort order (see ListingSorts), starting after the cursor when one is
+// given. next is nil on the last page.
+func (q *Queries) ListCatalogListings(ctx context.Context, f CatalogFilter, sortName string, after *CatalogCursor, limit int64) (items []Listing, next *CatalogCursor, err error) {
+	sort, ok := listingSorts[sortName]
+	if !ok {
+		sort = listingSorts[ListingSorts[0]]
+	}
+	query, args := catalogQuery(listingCatalog, `id, title, description, location, price, sale_type, year, status, image_url, created_at`, f, sort, after, limit)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	items = []Listing{}
+	var keys []string
+	for rows.Next() {
+		var i Listing
+		var key string
+		if err := rows.Scan(
+			&i.ID, &i.Title, &i.Description, &i.Location, &i.Price,
+			&i.SaleType, &i.Year, &i.Status, &i.ImageURL, &i.CreatedAt, &key,
+		); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, i)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if int64(len(items)) > limit {
+		items = items[:limit]
+		next = &CatalogCursor{Key: keys[limit-1], ID: items[limit-1].ID}
+	}
+	return items, next, nil
+}
+
+// ListCatalogAuctions is ListCatalogListings for auctions (see AuctionSorts).
+func (q *Queries) ListCatalogAuctions(ctx context.Context, f CatalogFilter, sortName string, after *CatalogCursor, limit int64) (items []Auction, next *CatalogCursor, err error) {
+	sort, ok := auctionSorts[sortName]
+	if !ok {
+		sort = auctionSorts[AuctionSorts[0]]
+	}
+	query, args := catalogQuery(auctionCatalog, auctionColumns, f, sort, after, limit)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	items = []Auction{}
+	var keys []string
+	for rows.Next() {
+		var i Auction
+		var key string
+		if err := scanAuction(scanWithExtra{rows, &key}, &i); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, i)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if int64(len(items)) > limit {
+		items = items[:limit]
+		next = &CatalogCursor{Key: keys[limit-1], ID: items[limit-1].ID}
+	}
+	return items, next, nil
+}
+
+// scanWithExtra lets a scanX helper read a row that carries extra trailing
+// columns.
+type scanWithExtra struct {
+	row   interface{ Scan(dest ...any) error }
+	extra any
+}
+
+func (s scanWithExtra) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra)...)
+}
+
+// FacetCount is how many catalog items have a given value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// FacetRange is the span of a numeric field across matching items.
+type FacetRange struct {
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+// CatalogFacets summarizes the items matching a filter. Each facet ignores
+// its own filter, so a client can show the alternatives to what is selected.
+type CatalogFacets struct {
+	Status   []FacetCount `json:"status"`
+	SaleType []FacetCount `json:"sale_type"`
+	Location []FacetCount `json:"location"`
+	Category []FacetCount `json:"category"`
+	Price    FacetRange   `json:"price"`
+	Year     *FacetRange  `json:"year,omitempty"`
+}
+
+// ListingFacets computes the facets for listings matching f.
+func (q *Queries) ListingFacets(ctx context.Context, f CatalogFilter) (CatalogFacets, error) {
+	return q.catalogFacets(ctx, listingCatalog, f)
+}
+
+// AuctionFacets computes the facets for auctions matching f.
+func (q *Queries) AuctionFacets(ctx context.Context, f CatalogFilter) (CatalogFacets, error) {
+	return q.catalogFacets(ctx, auctionCatalog, f)
+}
+
+func (q *Queries) catalogFacets(ctx context.Context, t catalogTable, f CatalogFilter) (CatalogFacets, error) {
+	var out CatalogFacets
+	var err error
+
+	g := f
+	g.Status = ""
+	if out.Status, err = q.facetCounts(ctx, t, g, t.name+".status"); err != nil {
+		return out, err
+	}
+	g = f
+	g.SaleType = ""
+	if out.SaleType, err = q.facetCounts(ctx, t, g, t.saleType); err != nil {
+		return out, err
+	}
+	g = f
+	g.Location = ""
+	if out.Location, err = q.facetCounts(ctx, t, g, t.name+".location"); err != nil {
+		return out, err
+	}
+
+	g = f
+	g.CategoryID = 0
+	where, args := g.where(t)
+	query := categorySubtree + `
+SELECT c.slug, COUNT(DISTINCT ` + t.name + `.id)
+FROM subtree s
+JOIN categories c ON c.id = s.root_id
+JOIN ` + t.joinTable + ` j ON j.category_id = s.id
+JOIN ` + t.name + ` ON ` + t.name + `.id = j.` + t.joinColumn + `
+` + where + `
+GROUP BY c.slug
+ORDER BY COUNT(DISTINCT ` + t.name + `.id) DESC, c.slug`
+	if out.Category, err = q.scanFacetCounts(ctx, query, args); err != nil {
+		return out, err
+	}
+
+	g = f
+	g.PriceMin, g.PriceMax = 0, 0
+	if out.Price, err = q.facetRange(ctx, t, g, t.price); err != nil {
+		return out, err
+	}
+	if t.year != "" {
+		g = f
+		g.YearMin, g.YearMax = 0, 0
+		year, err := q.facetRange(ctx, t, g, t.year)
+		if err != nil {
+			return out, err
+		}
+		out.Year = &year
+	}
+	return out, nil
+}
+
+func (q *Queries) facetCounts(ctx context.Context, t catalogTable, f CatalogFilter, column string) ([]FacetCount, error) {
+	where, args := f.where(t)
+	query := categorySubtree + `
+SELECT ` + column + `, COUNT(*)
+FROM ` + t.name + `
+` + where + `
+GROUP BY ` + column + `
+ORDER BY COUNT(*) DESC, ` + column
+	return q.scanFacetCounts(ctx, query, args)
+}
+
+func (q *Queries) scanFacetCounts(ctx context.Context, query string, args []any) ([]FacetCount, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FacetCount{}
+	for rows.Next() {
+		var i FacetCount
+		if err := rows.Scan(&i.Value, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (q *Queries) facetRange(ctx context.Context, t catalogTable, f CatalogFilter, column string) (FacetRange, error) {
+	where, args := f.where(t)
+	query := categorySubtree + `
+SELECT COALESCE(MIN(` + column + `), 0), COALESCE(MAX(` + column + `), 0)
+FROM ` + t.name + `
+` + where
+	var r FacetRange
+	err := q.db.QueryRowContext(ctx, query, args...).Scan(&r.Min, &r.Max)
+	return r, err
+}
+
+// CatalogMatch is an item found by MatchCatalogItems.
+type CatalogMatch struct {
+	ID    int64
+	Title string
+}
+
+// MatchCatalogItems returns the items of itemType ("listing" or "auction")
+// matching f and, when match is not empty, the full-text query match. ids,
+// when not nil, restricts the check to those items.
+func (q *Queries) MatchCatalogItems(ctx context.Context, itemType string, f CatalogFilter, match string, ids []int64) ([]CatalogMatch, error) {
+	if ids != nil && len(ids) == 0 {
+		return []CatalogMatch{}, nil
+	}
+	t, parity := listingCatalog, 0
+	if itemType == "auction" {
+		t, parity = auctionCatalog, 1
+	}
+	conds, args := f.conds(t)
+	if match != "" {
+		conds = append(conds, t.name+".id IN (SELECT rowid / 2 FROM search_index WHERE search_index MATCH ? AND rowid % 2 = ?)")
+		args = append(args, match, parity)
+	}
+	if ids != nil {
+		conds = append(conds, t.name+".id IN (?"+strings.Repeat(", ?", len(ids)-1)+")")
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	query := categorySubtree + `
+SELECT ` + t.name + `.id, ` + t.name + `.title
+FROM ` + t.name + `
+` + where + `
+ORDER BY ` + t.name + `.id`
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CatalogMatch{}
+	for rows.Next() {
+		var i CatalogMatch
+		if err := rows.Scan(&i.ID, &i.Title); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,249 @@
+package db
+
+import "context"
+
+type Category struct {
+	ID       int64  `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Slug     string `json:"slug" db:"slug"`
+	ParentID int64  `json:"parent_id" db:"parent_id"`
+	Position int64  `json:"position" db:"position"`
+}
+
+const categoryColumns = `id, name, slug, parent_id, position`
+
+func scanCategory(row interface{ Scan(dest ...any) error }, i *Category) error {
+	return row.Scan(&i.ID, &i.Name, &i.Slug, &i.ParentID, &i.Position)
+}
+
+func (q *Queries) listCategories(ctx context.Context, query string, args ...any) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Category{}
+	for rows.Next() {
+		var i Category
+		if err := scanCategory(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// categorySubtree expands each category into itself plus all descendants as
+// (root_id, id) pairs.
+const categorySubtree = `
+WITH RECURSIVE subtree(root_id, id) AS (
+  SELECT id, id FROM categories
+  UNION ALL
+  SELECT s.root_id, c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)`
+
+type CategoryWithCounts struct {
+	Category
+	ListingCount int64 `json:"listing_count"`
+	AuctionCount int64 `json:"auction_count"`
+}
+
+const listCategoriesWithCounts = categorySubtree + `
+SELECT c.id, c.name, c.slug, c.parent_id, c.position,
+       (SELECT COUNT(DISTINCT lc.listing_id)
+        FROM subtree s
+        JOIN listing_categories lc ON lc.category_id = s.id
+        JOIN listings l ON l.id = lc.listing_id
+        WHERE s.root_id = c.id AND l.status = 'active'),
+       (SELECT COUNT(DISTINCT ac.auction_id)
+        FROM subtree s
+        JOIN auction_categories ac ON ac.category_id = s.id
+        JOIN auctions a ON a.id = ac.auction_id
+        WHERE s.root_id = c.id AND a.status = 'active' AND a.visibility = 'public')
+FROM categories c
+ORDER BY c.parent_id, c.position, c.name;
+`
+
+// ListCategoriesWithCounts returns every category with the number of active
+// listings and public active auctions in it or any of its descendants.
+func (q *Queries) ListCategoriesWithCounts(ctx context.Context) ([]CategoryWithCounts, error) {
+	rows, err := q.db.QueryContext(ctx, listCategoriesWithCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CategoryWithCounts{}
+	for rows.Next() {
+		var i CategoryWithCounts
+		if err := rows.Scan(&i.ID, &i.Name, &i.Slug, &i.ParentID, &i.Position, &i.ListingCount, &i.AuctionCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const getCategory = `
+SELECT ` + categoryColumns + ` FROM categories WHERE id = ?;
+`
+
+func (q *Queries) GetCategory(ctx context.Context, id int64) (Category, error) {
+	var i Category
+	err := scanCategory(q.db.QueryRowContext(ctx, getCategory, id), &i)
+	return i, err
+}
+
+const getCategoryBySlug = `
+SELECT ` + categoryColumns + ` FROM categories WHERE slug = ?;
+`
+
+func (q *Queries) GetCategoryBySlug(ctx context.Context, slug string) (Category, error) {
+	var i Category
+	err := scanCategory(q.db.QueryRowContext(ctx, getCategoryBySlug, slug), &i)
+	return i, err
+}
+
+const listChildCategories = `
+SELECT ` + categoryColumns + ` FROM categories WHERE parent_id = ? ORDER BY position, name;
+`
+
+func (q *Queries) ListChildCategories(ctx context.Context, parentID int64) ([]Category, error) {
+	return q.listCategories(ctx, listChildCategories, parentID)
+}
+
+const listCategoryAncestors = `
+WITH RECURSIVE ancestors(id, depth) AS (
+  SELECT parent_id, 1 FROM categories WHERE id = ? AND parent_id != 0
+  UNION ALL
+  SELECT c.parent_id, a.depth + 1 FROM categories c JOIN ancestors a ON c.id = a.id WHERE c.parent_id != 0
+)
+SELECT c.id, c.name, c.slug, c.parent_id, c.position
+FROM ancestors a
+JOIN categories c ON c.id = a.id
+ORDER BY a.depth DESC;
+`
+
+// ListCategoryAncestors returns the path from the root down to the
+// category's parent, for breadcrumbs.
+func (q *Queries) ListCategoryAncestors(ctx context.Context, id int64) ([]Category, error) {
+	return q.listCategories(ctx, listCategoryAncestors, id)
+}
+
+const isCategoryInSubtree = categorySubtree + `
+SELECT EXISTS (SELECT 1 FROM subtree WHERE root_id = ? AND id = ?);
+`
+
+// IsCategoryInSubtree reports whether id is rootID or one of its descendants.
+func (q *Queries) IsCategoryInSubtree(ctx context.Context, rootID, id int64) (bool, error) {
+	var ok bool
+	err := q.db.QueryRowContext(ctx, isCategoryInSubtree, rootID, id).Scan(&ok)
+	return ok, err
+}
+
+type CreateCategoryParams struct {
+	Name     string
+	Slug     string
+	ParentID int64
+	Position int64
+}
+
+const createCategory = `
+INSERT INTO categories (name, slug, parent_id, position)
+VALUES (?, ?, ?, ?)
+RETURNING ` + categoryColumns + `;
+`
+
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	var i Category
+	err := scanCategory(q.db.QueryRowContext(ctx, createCategory, arg.Name, arg.Slug, arg.ParentID, arg.Position), &i)
+	return i, err
+}
+
+type UpdateCategoryParams struct {
+	ID       int64
+	Name     string
+	Slug     string
+	ParentID int64
+	Position int64
+}
+
+const updateCategory = `
+UPDATE categories SET name = ?, slug = ?, parent_id = ?, position = ?
+WHERE id = ?
+RETURNING ` + categoryColumns + `;
+`
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error) {
+	var i Category
+	err := scanCategory(q.db.QueryRowContext(ctx, updateCategory, arg.Name, arg.Slug, arg.ParentID, arg.Position, arg.ID), &i)
+	return i, err
+}
+
+const deleteCategory = `
+DELETE FROM categories WHERE id = ?;
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCategory, id)
+	return err
+}
+
+const listCategoriesForListing = `
+SELECT c.id, c.name, c.slug, c.parent_id, c.position
+FROM listing_categories lc
+JOIN categories c ON c.id = lc.category_id
+WHERE lc.listing_id = ?
+ORDER BY c.name;
+`
+
+func (q *Queries) ListCategoriesForListing(ctx context.Context, listingID int64) ([]Category, error) {
+	return q.listCategories(ctx, listCategoriesForListing, listingID)
+}
+
+const listCategoriesForAuction = `
+SELECT c.id, c.name, c.slug, c.parent_id, c.position
+FROM auction_categories ac
+JOIN categories c ON c.id = ac.category_id
+WHERE ac.auction_id = ?
+ORDER BY c.name;
+`
+
+func (q *Queries) ListCategoriesForAuction(ctx context.Context, auctionID int64) ([]Category, error) {
+	return q.listCategories(ctx, listCategoriesForAuction, auctionID)
+}
+
+const clearListingCategories = `
+DELETE FROM listing_categories WHERE listing_id = ?;
+`
+
+func (q *Queries) ClearListingCategories(ctx context.Context, listingID int64) error {
+	_, err := q.db.ExecContext(ctx, clearListingCategories, listingID)
+	return err
+}
+
+const addListingCategory = `
+INSERT OR IGNORE INTO listing_categories (listing_id, category_id) VALUES (?, ?);
+`
+
+func (q *Queries) AddListingCategory(ctx context.Context, listingID, categoryID int64) error {
+	_, err := q.db.ExecContext(ctx, addListingCategory, listingID, categoryID)
+	return err
+}
+
+const clearAuctionCategories = `
+DELETE FROM auction_categories WHERE auction_id = ?;
+`
+
+func (q *Queries) ClearAuctionCategories(ctx context.Context, auctionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearAuctionCategories, auctionID)
+	return err
+}
+
+const addAuctionCategory = `
+INSERT OR IGNORE INTO auction_categories (auction_id, category_id) VALUES (?, ?);
+`
+
+func (q *Queries) AddAuctionCategory(ctx context.Context, auctionID, categoryID int64) error {
+	_, err := q.db.ExecContext(ctx, addAuctionCategory, auctionID, categoryID)
+	return err
+}
@@ -0,0 +1,119 @@
+package db
+
+import "context"
+
+type AccountClosureRequest struct {
+	ID         int64  `json:"id" db:"id"`
+	UserID     int64  `json:"user_id" db:"user_id"`
+	Reason     string `json:"reason" db:"reason"`
+	Status     string `json:"status" db:"status"`
+	ReviewNote string `json:"review_note" db:"review_note"`
+	ReviewedBy int64  `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt string `json:"reviewed_at" db:"reviewed_at"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+}
+
+const closureColumns = `id, user_id, reason, status, review_note, reviewed_by, reviewed_at, created_at`
+
+func scanClosure(row interface{ Scan(dest ...any) error }, i *AccountClosureRequest) error {
+	return row.Scan(&i.ID, &i.UserID, &i.Reason, &i.Status, &i.ReviewNote, &i.ReviewedBy, &i.ReviewedAt, &i.CreatedAt)
+}
+
+const createClosureRequest = `
+INSERT INTO account_closure_requests (user_id, reason)
+VALUES (?, ?)
+RETURNING ` + closureColumns + `;
+`
+
+func (q *Queries) CreateClosureRequest(ctx context.Context, userID int64, reason string) (AccountClosureRequest, error) {
+	var i AccountClosureRequest
+	err := scanClosure(q.db.QueryRowContext(ctx, createClosureRequest, userID, reason), &i)
+	return i, err
+}
+
+const getClosureRequest = `
+SELECT ` + closureColumns + `
+FROM account_closure_requests
+WHERE id = ?;
+`
+
+func (q *Queries) GetClosureRequest(ctx context.Context, id int64) (AccountClosureRequest, error) {
+	var i AccountClosureRequest
+	err := scanClosure(q.db.QueryRowContext(ctx, getClosureRequest, id), &i)
+	return i, err
+}
+
+const getLatestClosureRequest = `
+SELECT ` + closureColumns + `
+FROM account_closure_requests
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT 1;
+`
+
+func (q *Queries) GetLatestClosureRequest(ctx context.Context, userID int64) (AccountClosureRequest, error) {
+	var i AccountClosureRequest
+	err := scanClosure(q.db.QueryRowContext(ctx, getLatestClosureRequest, userID), &i)
+	return i, err
+}
+
+const listClosureRequestsByStatus = `
+SELECT ` + closureColumns + `
+FROM account_closure_requests
+WHERE status = ?
+ORDER BY id
+LIMIT ?;
+`
+
+func (q *Queries) ListClosureRequestsByStatus(ctx context.Context, status string, limit int64) ([]AccountClosureRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listClosureRequestsByStatus, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountClosureRequest{}
+	for rows.Next() {
+		var i AccountClosureRequest
+		if err := scanClosure(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type ReviewClosureRequestParams struct {
+	ID         int64
+	Status     string
+	ReviewNote string
+	ReviewedBy int64
+}
+
+const reviewClosureRequest = `
+UPDATE account_closure_requests
+SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = datetime('now')
+WHERE id = ? AND status = 'pending'
+RETURNING ` + closureColumns + `;
+`
+
+// ReviewClosureRequest only transitions pending requests; it returns
+// sql.ErrNoRows otherwise.
+func (q *Queries) ReviewClosureRequest(ctx context.Context, arg ReviewClosureRequestParams) (AccountClosureRequest, error) {
+	row := q.db.QueryRowContext(ctx, reviewClosureRequest, arg.Status, arg.ReviewNote, arg.ReviewedBy, arg.ID)
+	var i AccountClosureRequest
+	err := scanClosure(row, &i)
+	return i, err
+}
+
+const cancelClosureRequest = `
+UPDATE account_closure_requests
+SET status = 'cancelled'
+WHERE user_id = ? AND status = 'pending'
+RETURNING ` + closureColumns + `;
+`
+
+func (q *Queries) CancelClosureRequest(ctx context.Context, userID int64) (AccountClosureRequest, error) {
+	var i AccountClosureRequest
+	err := scanClosure(q.db.QueryRowContext(ctx, cancelClosureRequest, userID), &i)
+	return i, err
+}
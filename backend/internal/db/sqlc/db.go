@@ -3,6 +3,7 @@ package db
 import (
   "context"
   "database/sql"
+  "fmt"
 )
 
 type DBTX interface {
@@ -20,8 +21,30 @@ func New(db DBTX) *Queries {
   return &Queries{db: db}
 }
 
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+  return &Queries{db: tx}
+}
+
+// ExecTx runs fn inside a transaction, committing if it returns nil and
+// rolling back otherwise. If q is already bound to a transaction, fn joins it.
+func (q *Queries) ExecTx(ctx context.Context, fn func(*Queries) error) error {
+  db, ok := q.db.(*sql.DB)
+  if !ok {
+    return fn(q)
+  }
+  tx, err := db.BeginTx(ctx, nil)
+  if err != nil {
+    return fmt.Errorf("begin tx: %w", err)
+  }
+  if err := fn(q.WithTx(tx)); err != nil {
+    _ = tx.Rollback()
+    return err
+  }
+  return tx.Commit()
+}
+
 type Querier interface {
-  ListActiveAuctions(ctx context.Context, limit int64) ([]Auction, error)
+  ListActiveAuctions(ctx context.Context, viewerID, limit int64) ([]Auction, error)
   GetAuction(ctx context.Context, id int64) (Auction, error)
   CreateAuction(ctx context.Context, arg CreateAuctionParams) (Auction, error)
   UpdateAuction(ctx context.Context, arg UpdateAuctionParams) (Auction, error)
@@ -42,14 +65,203 @@ type Querier interface {
   RejectUser(ctx context.Context, reason string, id int64) (User, error)
   UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
   UpdateUserAdmin(ctx context.Context, arg UpdateUserAdminParams) (User, error)
+  UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
+  UpdateUserFiscalIdentity(ctx context.Context, arg UpdateUserFiscalIdentityParams) (User, error)
+  AnonymizeUser(ctx context.Context, email string, id int64) (User, error)
   DecrementOpportunities(ctx context.Context, id int64) error
 
   PlaceBid(ctx context.Context, arg PlaceBidParams) (Bid, error)
   ListBidsForAuction(ctx context.Context, auctionID int64, limit int64) ([]Bid, error)
+  ListBidsForUser(ctx context.Context, userID int64) ([]Bid, error)
+  CountLeadingAuctions(ctx context.Context, userID int64) (int64, error)
   GetHighestBid(ctx context.Context, auctionID int64) (Bid, error)
   UpdateAuctionBid(ctx context.Context, currentBid int64, highestBidderID int64, id int64) error
 
   ActivateScheduledAuctions(ctx context.Context) error
   CloseExpiredAuctions(ctx context.Context) error
   ListAllAuctions(ctx context.Context, limit int64) ([]Auction, error)
+
+  CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error)
+  GetAPIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error)
+  ListAPIKeys(ctx context.Context) ([]APIKey, error)
+  RevokeAPIKey(ctx context.Context, id int64) (APIKey, error)
+  TouchAPIKey(ctx context.Context, id int64) error
+
+  ListUserRoles(ctx context.Context, userID int64) ([]string, error)
+  AddUserRole(ctx context.Context, arg AddUserRoleParams) error
+  DeleteUserRoles(ctx context.Context, userID int64) error
+
+  CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditEntry, error)
+  ListAuditEntries(ctx context.Context, f AuditFilter, limit, offset int64) ([]AuditEntry, error)
+  CountAuditEntries(ctx context.Context, f AuditFilter) (int64, error)
+
+  UpsertUserDocument(ctx context.Context, arg UpsertUserDocumentParams) (UserDocument, error)
+  GetUserDocument(ctx context.Context, id int64) (UserDocument, error)
+  GetUserDocumentByType(ctx context.Context, userID int64, docType string) (UserDocument, error)
+  ListUserDocuments(ctx context.Context, userID int64) ([]UserDocument, error)
+  ReviewUserDocument(ctx context.Context, arg ReviewUserDocumentParams) (UserDocument, error)
+  DeleteUserDocuments(ctx context.Context, userID int64) error
+
+  SupersedeProfileChanges(ctx context.Context, userID int64) error
+  CreateProfileChange(ctx context.Context, arg CreateProfileChangeParams) (ProfileChangeRequest, error)
+  GetProfileChange(ctx context.Context, id int64) (ProfileChangeRequest, error)
+  GetPendingProfileChange(ctx context.Context, userID int64) (ProfileChangeRequest, error)
+  ListProfileChangesForUser(ctx context.Context, userID int64) ([]ProfileChangeRequest, error)
+  ListProfileChangesByStatus(ctx context.Context, status string, limit int64) ([]ProfileChangeRequest, error)
+  ReviewProfileChange(ctx context.Context, arg ReviewProfileChangeParams) (ProfileChangeRequest, error)
+  DeleteProfileChangesForUser(ctx context.Context, userID int64) error
+
+  CreateClosureRequest(ctx context.Context, userID int64, reason string) (AccountClosureRequest, error)
+  GetClosureRequest(ctx context.Context, id int64) (AccountClosureRequest, error)
+  GetLatestClosureRequest(ctx context.Context, userID int64) (AccountClosureRequest, error)
+  ListClosureRequestsByStatus(ctx context.Context, status string, limit int64) ([]AccountClosureRequest, error)
+  ReviewClosureRequest(ctx context.Context, arg ReviewClosureRequestParams) (AccountClosureRequest, error)
+  CancelClosureRequest(ctx context.Context, userID int64) (AccountClosureRequest, error)
+
+  ListReviewQueue(ctx context.Context, f ReviewQueueFilter, limit int64) ([]User, error)
+  ClaimUserReview(ctx context.Context, reviewerID, id int64) (User, error)
+  AssignUserReviewer(ctx context.Context, reviewerID, id int64) (User, error)
+  RequestUserInfo(ctx context.Context, message string, id int64) (User, error)
+  ResubmitUser(ctx context.Context, id int64) (User, error)
+  CreateReviewNote(ctx context.Context, userID, authorUserID int64, body string) (UserReviewNote, error)
+  ListReviewNotes(ctx context.Context, userID int64) ([]UserReviewNote, error)
+  GetReviewQueueStats(ctx context.Context, slaHours int64) (ReviewQueueStats, error)
+  ListReviewerWorkload(ctx context.Context) ([]ReviewerWorkload, error)
+
+  SuspendUser(ctx context.Context, reason, until string, id int64) (User, error)
+  ReinstateUser(ctx context.Context, id int64) (User, error)
+  ReinstateExpiredSuspensions(ctx context.Context) ([]User, error)
+  CreateStatusChange(ctx context.Context, arg CreateStatusChangeParams) error
+  ListStatusHistory(ctx context.Context, userID int64) ([]UserStatusChange, error)
+
+  GetEnrollmentPolicy(ctx context.Context, auctionID int64) (EnrollmentPolicy, error)
+  UpsertEnrollmentPolicy(ctx context.Context, arg UpsertEnrollmentPolicyParams) (EnrollmentPolicy, error)
+  DeleteEnrollmentPolicy(ctx context.Context, auctionID int64) error
+  CountActiveEnrollments(ctx context.Context, auctionID int64) (int64, error)
+  ListEnrollmentsByFilter(ctx context.Context, f EnrollmentFilter) ([]EnrollmentCandidate, error)
+  ListMyEnrollments(ctx context.Context, userID int64) ([]MyEnrollment, error)
+  UpsertApprovedEnrollment(ctx context.Context, auctionID, userID int64) (AuctionEnrollment, error)
+  NextWaitlistedEnrollment(ctx context.Context, auctionID int64) (AuctionEnrollment, error)
+  SetEnrollmentStatus(ctx context.Context, status string, id int64) (AuctionEnrollment, error)
+
+  CreateAuctionInvite(ctx context.Context, auctionID, userID, invitedBy int64) (AuctionInvite, error)
+  ListAuctionInvites(ctx context.Context, auctionID int64) ([]AuctionInvite, error)
+  DeleteAuctionInvite(ctx context.Context, auctionID, userID int64) error
+  IsInvited(ctx context.Context, auctionID, userID int64) (bool, error)
+
+  ListCategoriesWithCounts(ctx context.Context) ([]CategoryWithCounts, error)
+  GetCategory(ctx context.Context, id int64) (Category, error)
+  GetCategoryBySlug(ctx context.Context, slug string) (Category, error)
+  ListChildCategories(ctx context.Context, parentID int64) ([]Category, error)
+  ListCategoryAncestors(ctx context.Context, id int64) ([]Category, error)
+  IsCategoryInSubtree(ctx context.Context, rootID, id int64) (bool, error)
+  CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+  UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error)
+  DeleteCategory(ctx context.Context, id int64) error
+  ListCategoriesForListing(ctx context.Context, listingID int64) ([]Category, error)
+  ListCategoriesForAuction(ctx context.Context, auctionID int64) ([]Category, error)
+  ClearListingCategories(ctx context.Context, listingID int64) error
+  AddListingCategory(ctx context.Context, listingID, categoryID int64) error
+  ClearAuctionCategories(ctx context.Context, auctionID int64) error
+  AddAuctionCategory(ctx context.Context, auctionID, categoryID int64) error
+
+  SearchIndex(ctx context.Context, match, kind string, viewerID, limit int64) ([]SearchHit, error)
+  CountSearchIndex(ctx context.Context, match, kind string, viewerID int64) (int64, error)
+
+  ListCatalogListings(ctx context.Context, f CatalogFilter, sortName string, after *CatalogCursor, limit int64) ([]Listing, *CatalogCursor, error)
+  ListCatalogAuctions(ctx context.Context, f CatalogFilter, sortName string, after *CatalogCursor, limit int64) ([]Auction, *CatalogCursor, error)
+  ListingFacets(ctx context.Context, f CatalogFilter) (CatalogFacets, error)
+  AuctionFacets(ctx context.Context, f CatalogFilter) (CatalogFacets, error)
+
+  ListSpecAttributes(ctx context.Context) ([]SpecAttribute, error)
+  GetSpecAttribute(ctx context.Context, id int64) (SpecAttribute, error)
+  ListSpecAttributesForCategory(ctx context.Context, categoryID int64) ([]SpecAttribute, error)
+  ListSpecAttributesForListing(ctx context.Context, listingID int64) ([]SpecAttribute, error)
+  ListSpecAttributesForAuction(ctx context.Context, auctionID int64) ([]SpecAttribute, error)
+  SpecKeyInUse(ctx context.Context, categoryID int64, key string) (bool, error)
+  CreateSpecAttribute(ctx context.Context, arg CreateSpecAttributeParams) (SpecAttribute, error)
+  UpdateSpecAttribute(ctx context.Context, arg UpdateSpecAttributeParams) (SpecAttribute, error)
+  DeleteSpecAttribute(ctx context.Context, id int64) error
+  ListListingSpecs(ctx context.Context, listingID int64) ([]SpecValue, error)
+  ListAuctionSpecs(ctx context.Context, auctionID int64) ([]SpecValue, error)
+  ClearListingSpecs(ctx context.Context, listingID int64) error
+  SetListingSpec(ctx context.Context, listingID, attributeID int64, value string) error
+  ClearAuctionSpecs(ctx context.Context, auctionID int64) error
+  SetAuctionSpec(ctx context.Context, auctionID, attributeID int64, value string) error
+
+  CreateItemImage(ctx context.Context, arg CreateItemImageParams) (ItemImage, error)
+  AddImageVariant(ctx context.Context, arg ImageVariant) error
+  GetItemImage(ctx context.Context, id int64) (ItemImage, error)
+  ListItemImages(ctx context.Context, itemType string, itemID int64) ([]ItemImage, error)
+  ListItemImageVariants(ctx context.Context, itemType string, itemID int64) ([]ImageVariant, error)
+  ListImageVariants(ctx context.Context, imageID int64) ([]ImageVariant, error)
+  GetImageVariant(ctx context.Context, imageID int64, name string) (ImageVariant, error)
+  UpdateItemImageCaption(ctx context.Context, id int64, caption string) (ItemImage, error)
+  SetPrimaryItemImage(ctx context.Context, itemType string, itemID, imageID int64) error
+  EnsurePrimaryItemImage(ctx context.Context, itemType string, itemID int64) error
+  SetItemImagePosition(ctx context.Context, id, position int64) error
+  DeleteItemImage(ctx context.Context, id int64) error
+  SetListingImageURL(ctx context.Context, id int64, url string) error
+  SetAuctionImageURL(ctx context.Context, id int64, url string) error
+
+  CreateItemAttachment(ctx context.Context, arg CreateItemAttachmentParams) (ItemAttachment, error)
+  GetItemAttachment(ctx context.Context, id int64) (ItemAttachment, error)
+  ListItemAttachments(ctx context.Context, itemType string, itemID int64) ([]ItemAttachment, error)
+  UpdateItemAttachment(ctx context.Context, arg UpdateItemAttachmentParams) (ItemAttachment, error)
+  DeleteItemAttachment(ctx context.Context, id int64) error
+
+  CreateListingAuctionLink(ctx context.Context, arg CreateListingAuctionLinkParams) (ListingAuctionLink, error)
+  GetLinkForAuction(ctx context.Context, auctionID int64) (ListingAuctionLink, error)
+  GetLatestLinkForListing(ctx context.Context, listingID int64) (ListingAuctionLink, error)
+  ListEndedListingAuctionLinks(ctx context.Context) ([]EndedLink, error)
+  ResolveListingAuctionLink(ctx context.Context, auctionID int64, outcome string) error
+  SetListingStatus(ctx context.Context, id int64, status string) error
+  SendListingToAuction(ctx context.Context, id int64) (int64, error)
+  RelistListing(ctx context.Context, id, price int64) (Listing, error)
+  CopyListingCategoriesToAuction(ctx context.Context, listingID, auctionID int64) error
+  CopyAuctionCategoriesToListing(ctx context.Context, auctionID, listingID int64) error
+  CopyListingSpecsToAuction(ctx context.Context, listingID, auctionID int64) error
+  CopyAuctionSpecsToListing(ctx context.Context, auctionID, listingID int64) error
+
+  GetNotificationPreferences(ctx context.Context, userID int64) (NotificationPreferences, error)
+  UpsertNotificationPreferences(ctx context.Context, arg UpsertNotificationPreferencesParams) (NotificationPreferences, error)
+  ListLikeChanges(ctx context.Context) ([]LikeChange, error)
+  UpdateLikeAlerted(ctx context.Context, likeID, price int64, status string) error
+  CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+  ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+  CountUnreadNotifications(ctx context.Context, userID int64) (int64, error)
+  MarkNotificationRead(ctx context.Context, userID, id int64) (int64, error)
+  MarkAllNotificationsRead(ctx context.Context, userID int64) (int64, error)
+  ListPendingEmails(ctx context.Context) ([]PendingEmail, error)
+  ListPendingEmailNotifications(ctx context.Context, userID int64) ([]Notification, error)
+  MarkNotificationsEmailed(ctx context.Context, userID, maxID int64) error
+  SkipPendingNotificationEmails(ctx context.Context, userID int64) error
+  SetLastDigestAt(ctx context.Context, userID int64) error
+  ListUserNotifications(ctx context.Context, userID int64) ([]Notification, error)
+  DeleteUserNotifications(ctx context.Context, userID int64) error
+  DeleteNotificationPreferences(ctx context.Context, userID int64) error
+
+  WatchAuction(ctx context.Context, userID, auctionID, leadMinutes int64) (AuctionWatch, error)
+  GetAuctionWatch(ctx context.Context, userID, auctionID int64) (AuctionWatch, error)
+  UnwatchAuction(ctx context.Context, userID, auctionID int64) error
+  ListUserWatches(ctx context.Context, userID int64) ([]WatchedAuction, error)
+  ListWatchChanges(ctx context.Context) ([]WatchChange, error)
+  UpdateWatchAlerted(ctx context.Context, arg UpdateWatchAlertedParams) error
+  DeleteUserWatches(ctx context.Context, userID int64) error
+
+  MatchCatalogItems(ctx context.Context, itemType string, f CatalogFilter, match string, ids []int64) ([]CatalogMatch, error)
+  CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) (SavedSearch, error)
+  GetSavedSearch(ctx context.Context, id int64) (SavedSearch, error)
+  CountSavedSearches(ctx context.Context, userID int64) (int64, error)
+  ListSavedSearches(ctx context.Context, userID int64) ([]SavedSearchSummary, error)
+  UpdateSavedSearch(ctx context.Context, arg UpdateSavedSearchParams) (SavedSearch, error)
+  DeleteSavedSearch(ctx context.Context, id int64) error
+  DeleteUserSavedSearches(ctx context.Context, userID int64) error
+  ResetSavedSearchMatches(ctx context.Context, searchID int64) error
+  AddSavedSearchMatch(ctx context.Context, searchID int64, itemType string, itemID, baseline int64) (bool, error)
+  ListSavedSearchMatches(ctx context.Context, searchID int64, newOnly bool, limit int64) ([]SavedSearchMatch, error)
+  MarkSavedSearchSeen(ctx context.Context, id int64) error
+  ListSavedSearchesForMatching(ctx context.Context) ([]SavedSearchOwner, error)
+  ListCatalogChanges(ctx context.Context, limit int64) ([]CatalogChange, error)
+  DeleteCatalogChanges(ctx context.Context, maxID int64) error
 }
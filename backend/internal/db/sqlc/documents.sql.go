@@ -0,0 +1,140 @@
+package db
+
+import "context"
+
+type UserDocument struct {
+	ID          int64  `json:"id" db:"id"`
+	UserID      int64  `json:"user_id" db:"user_id"`
+	DocType     string `json:"doc_type" db:"doc_type"`
+	Filename    string `json:"filename" db:"filename"`
+	ContentType string `json:"content_type" db:"content_type"`
+	SizeBytes   int64  `json:"size_bytes" db:"size_bytes"`
+	StorageKey  string `json:"-" db:"storage_key"`
+	Status      string `json:"status" db:"status"`
+	ReviewNote  string `json:"review_note" db:"review_note"`
+	ReviewedBy  int64  `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt  string `json:"reviewed_at" db:"reviewed_at"`
+	UploadedAt  string `json:"uploaded_at" db:"uploaded_at"`
+}
+
+const userDocumentColumns = `id, user_id, doc_type, filename, content_type, size_bytes, storage_key,
+       status, review_note, reviewed_by, reviewed_at, uploaded_at`
+
+func scanUserDocument(row interface{ Scan(dest ...any) error }, i *UserDocument) error {
+	return row.Scan(
+		&i.ID, &i.UserID, &i.DocType, &i.Filename, &i.ContentType, &i.SizeBytes, &i.StorageKey,
+		&i.Status, &i.ReviewNote, &i.ReviewedBy, &i.ReviewedAt, &i.UploadedAt,
+	)
+}
+
+type UpsertUserDocumentParams struct {
+	UserID      int64
+	DocType     string
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string
+}
+
+const upsertUserDocument = `
+INSERT INTO user_documents (user_id, doc_type, filename, content_type, size_bytes, storage_key)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id, doc_type) DO UPDATE SET
+  filename = excluded.filename,
+  content_type = excluded.content_type,
+  size_bytes = excluded.size_bytes,
+  storage_key = excluded.storage_key,
+  status = 'pending',
+  review_note = '',
+  reviewed_by = 0,
+  reviewed_at = '',
+  uploaded_at = datetime('now')
+RETURNING ` + userDocumentColumns + `;
+`
+
+func (q *Queries) UpsertUserDocument(ctx context.Context, arg UpsertUserDocumentParams) (UserDocument, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserDocument,
+		arg.UserID, arg.DocType, arg.Filename, arg.ContentType, arg.SizeBytes, arg.StorageKey,
+	)
+	var i UserDocument
+	err := scanUserDocument(row, &i)
+	return i, err
+}
+
+const getUserDocument = `
+SELECT ` + userDocumentColumns + `
+FROM user_documents
+WHERE id = ?;
+`
+
+func (q *Queries) GetUserDocument(ctx context.Context, id int64) (UserDocument, error) {
+	var i UserDocument
+	err := scanUserDocument(q.db.QueryRowContext(ctx, getUserDocument, id), &i)
+	return i, err
+}
+
+const getUserDocumentByType = `
+SELECT ` + userDocumentColumns + `
+FROM user_documents
+WHERE user_id = ? AND doc_type = ?;
+`
+
+func (q *Queries) GetUserDocumentByType(ctx context.Context, userID int64, docType string) (UserDocument, error) {
+	var i UserDocument
+	err := scanUserDocument(q.db.QueryRowContext(ctx, getUserDocumentByType, userID, docType), &i)
+	return i, err
+}
+
+const listUserDocuments = `
+SELECT ` + userDocumentColumns + `
+FROM user_documents
+WHERE user_id = ?
+ORDER BY doc_type;
+`
+
+func (q *Queries) ListUserDocuments(ctx context.Context, userID int64) ([]UserDocument, error) {
+	rows, err := q.db.QueryContext(ctx, listUserDocuments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserDocument{}
+	for rows.Next() {
+		var i UserDocument
+		if err := scanUserDocument(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type ReviewUserDocumentParams struct {
+	ID         int64
+	Status     string
+	ReviewNote string
+	ReviewedBy int64
+}
+
+const reviewUserDocument = `
+UPDATE user_documents
+SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = datetime('now')
+WHERE id = ?
+RETURNING ` + userDocumentColumns + `;
+`
+
+func (q *Queries) ReviewUserDocument(ctx context.Context, arg ReviewUserDocumentParams) (UserDocument, error) {
+	row := q.db.QueryRowContext(ctx, reviewUserDocument, arg.Status, arg.ReviewNote, arg.ReviewedBy, arg.ID)
+	var i UserDocument
+	err := scanUserDocument(row, &i)
+	return i, err
+}
+
+const deleteUserDocuments = `
+DELETE FROM user_documents WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteUserDocuments(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserDocuments, userID)
+	return err
+}
@@ -0,0 +1,89 @@
+package db
+
+import "context"
+
+type EnrollmentPolicy struct {
+	AuctionID                  int64  `json:"auction_id" db:"auction_id"`
+	AutoApproveTiers           string `json:"auto_approve_tiers" db:"auto_approve_tiers"`
+	ManualReviewAbove          int64  `json:"manual_review_above" db:"manual_review_above"`
+	MaxEnrollees               int64  `json:"max_enrollees" db:"max_enrollees"`
+	DeadlineMinutesBeforeStart int64  `json:"deadline_minutes_before_start" db:"deadline_minutes_before_start"`
+	Waitlist                   int64  `json:"waitlist" db:"waitlist"`
+	UpdatedAt                  string `json:"updated_at" db:"updated_at"`
+}
+
+const enrollmentPolicyColumns = `auction_id, auto_approve_tiers, manual_review_above, max_enrollees,
+       deadline_minutes_before_start, waitlist, updated_at`
+
+func scanEnrollmentPolicy(row interface{ Scan(dest ...any) error }, i *EnrollmentPolicy) error {
+	return row.Scan(&i.AuctionID, &i.AutoApproveTiers, &i.ManualReviewAbove, &i.MaxEnrollees,
+		&i.DeadlineMinutesBeforeStart, &i.Waitlist, &i.UpdatedAt)
+}
+
+const getEnrollmentPolicy = `
+SELECT ` + enrollmentPolicyColumns + `
+FROM auction_enrollment_policies
+WHERE auction_id = ?;
+`
+
+func (q *Queries) GetEnrollmentPolicy(ctx context.Context, auctionID int64) (EnrollmentPolicy, error) {
+	var i EnrollmentPolicy
+	err := scanEnrollmentPolicy(q.db.QueryRowContext(ctx, getEnrollmentPolicy, auctionID), &i)
+	return i, err
+}
+
+type UpsertEnrollmentPolicyParams struct {
+	AuctionID                  int64
+	AutoApproveTiers           string
+	ManualReviewAbove          int64
+	MaxEnrollees               int64
+	DeadlineMinutesBeforeStart int64
+	Waitlist                   int64
+}
+
+const upsertEnrollmentPolicy = `
+INSERT INTO auction_enrollment_policies (auction_id, auto_approve_tiers, manual_review_above, max_enrollees,
+                                         deadline_minutes_before_start, waitlist)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(auction_id) DO UPDATE SET
+  auto_approve_tiers = excluded.auto_approve_tiers,
+  manual_review_above = excluded.manual_review_above,
+  max_enrollees = excluded.max_enrollees,
+  deadline_minutes_before_start = excluded.deadline_minutes_before_start,
+  waitlist = excluded.waitlist,
+  updated_at = datetime('now')
+RETURNING ` + enrollmentPolicyColumns + `;
+`
+
+func (q *Queries) UpsertEnrollmentPolicy(ctx context.Context, arg UpsertEnrollmentPolicyParams) (EnrollmentPolicy, error) {
+	row := q.db.QueryRowContext(ctx, upsertEnrollmentPolicy,
+		arg.AuctionID, arg.AutoApproveTiers, arg.ManualReviewAbove, arg.MaxEnrollees, arg.DeadlineMinutesBeforeStart,
+		arg.Waitlist,
+	)
+	var i EnrollmentPolicy
+	err := scanEnrollmentPolicy(row, &i)
+	return i, err
+}
+
+const deleteEnrollmentPolicy = `
+DELETE FROM auction_enrollment_policies WHERE auction_id = ?;
+`
+
+func (q *Queries) DeleteEnrollmentPolicy(ctx context.Context, auctionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteEnrollmentPolicy, auctionID)
+	return err
+}
+
+const countActiveEnrollments = `
+SELECT COUNT(*)
+FROM auction_enrollments
+WHERE auction_id = ? AND status IN ('pending', 'approved');
+`
+
+// CountActiveEnrollments counts the enrollments that hold a seat against
+// max_enrollees; rejected requests free theirs.
+func (q *Queries) CountActiveEnrollments(ctx context.Context, auctionID int64) (int64, error) {
+	var count int64
+	err := q.db.QueryRowContext(ctx, countActiveEnrollments, auctionID).Scan(&count)
+	return count, err
+}
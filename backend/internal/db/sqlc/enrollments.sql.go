@@ -1,15 +1,20 @@
 package db
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 const requestEnrollment = `
-INSERT INTO auction_enrollments (auction_id, user_id)
-VALUES (?, ?)
+INSERT INTO auction_enrollments (auction_id, user_id, status)
+VALUES (?, ?, ?)
 RETURNING id, auction_id, user_id, status, created_at;
 `
 
-func (q *Queries) RequestEnrollment(ctx context.Context, auctionID int64, userID int64) (AuctionEnrollment, error) {
-	row := q.db.QueryRowContext(ctx, requestEnrollment, auctionID, userID)
+// RequestEnrollment creates the enrollment as 'pending', or 'approved' when
+// the auction's enrollment policy approves it outright.
+func (q *Queries) RequestEnrollment(ctx context.Context, auctionID int64, userID int64, status string) (AuctionEnrollment, error) {
+	row := q.db.QueryRowContext(ctx, requestEnrollment, auctionID, userID, status)
 	var i AuctionEnrollment
 	err := row.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt)
 	return i, err
@@ -82,3 +87,185 @@ func (q *Queries) RejectEnrollment(ctx context.Context, auctionID int64, userID
 	err := row.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt)
 	return i, err
 }
+
+const listEnrollmentsForUser = `
+SELECT id, auction_id, user_id, status, created_at
+FROM auction_enrollments
+WHERE user_id = ?
+ORDER BY id;
+`
+
+func (q *Queries) ListEnrollmentsForUser(ctx context.Context, userID int64) ([]AuctionEnrollment, error) {
+	rows, err := q.db.QueryContext(ctx, listEnrollmentsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuctionEnrollment{}
+	for rows.Next() {
+		var i AuctionEnrollment
+		if err := rows.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// EnrollmentFilter selects enrollments for bulk review. An empty Status means
+// pending. RegisteredFrom and RegisteredTo bound the user's registration date;
+// callers pass RFC3339 timestamps or YYYY-MM-DD dates.
+type EnrollmentFilter struct {
+	AuctionIDs     []int64
+	Status         string
+	GuaranteeTier  string
+	RegisteredFrom string
+	RegisteredTo   string
+}
+
+func (f EnrollmentFilter) where() (string, []any) {
+	status := f.Status
+	if status == "" {
+		status = "pending"
+	}
+	conds := []string{"ae.status = ?"}
+	args := []any{status}
+	if len(f.AuctionIDs) > 0 {
+		marks := strings.TrimSuffix(strings.Repeat("?,", len(f.AuctionIDs)), ",")
+		conds = append(conds, "ae.auction_id IN ("+marks+")")
+		for _, id := range f.AuctionIDs {
+			args = append(args, id)
+		}
+	}
+	if f.GuaranteeTier != "" {
+		conds = append(conds, "u.guarantee_tier = ?")
+		args = append(args, f.GuaranteeTier)
+	}
+	if f.RegisteredFrom != "" {
+		conds = append(conds, "datetime(u.created_at) >= datetime(?)")
+		args = append(args, f.RegisteredFrom)
+	}
+	if f.RegisteredTo != "" {
+		conds = append(conds, "datetime(u.created_at) <= datetime(?)")
+		args = append(args, f.RegisteredTo)
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// EnrollmentCandidate is an enrollment plus the account state bulk review
+// needs to decide whether it may be approved.
+type EnrollmentCandidate struct {
+	EnrollmentWithUser
+	UserStatus   string `json:"user_status"`
+	UserClosedAt string `json:"user_closed_at"`
+	RegisteredAt string `json:"registered_at"`
+}
+
+func (q *Queries) ListEnrollmentsByFilter(ctx context.Context, f EnrollmentFilter) ([]EnrollmentCandidate, error) {
+	where, args := f.where()
+	query := `SELECT ae.id, ae.auction_id, ae.user_id, ae.status, ae.created_at,
+       u.email, u.business_name, u.guarantee_tier, u.status, u.closed_at, u.created_at
+FROM auction_enrollments ae
+JOIN users u ON u.id = ae.user_id
+` + where + " ORDER BY ae.auction_id, ae.id"
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EnrollmentCandidate{}
+	for rows.Next() {
+		var i EnrollmentCandidate
+		if err := rows.Scan(
+			&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt,
+			&i.Email, &i.BusinessName, &i.GuaranteeTier, &i.UserStatus, &i.UserClosedAt, &i.RegisteredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type MyEnrollment struct {
+	AuctionEnrollment
+	AuctionTitle     string `json:"auction_title"`
+	AuctionStatus    string `json:"auction_status"`
+	AuctionStartTime string `json:"auction_start_time"`
+	AuctionEndTime   string `json:"auction_end_time"`
+}
+
+const listMyEnrollments = `
+SELECT ae.id, ae.auction_id, ae.user_id, ae.status, ae.created_at,
+       a.title, a.status, a.start_time, a.end_time
+FROM auction_enrollments ae
+JOIN auctions a ON a.id = ae.auction_id
+WHERE ae.user_id = ?
+ORDER BY ae.id DESC;
+`
+
+// ListMyEnrollments returns a user's enrollments with enough of each auction
+// to render a dashboard, newest first.
+func (q *Queries) ListMyEnrollments(ctx context.Context, userID int64) ([]MyEnrollment, error) {
+	rows, err := q.db.QueryContext(ctx, listMyEnrollments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MyEnrollment{}
+	for rows.Next() {
+		var i MyEnrollment
+		if err := rows.Scan(
+			&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt,
+			&i.AuctionTitle, &i.AuctionStatus, &i.AuctionStartTime, &i.AuctionEndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const upsertApprovedEnrollment = `
+INSERT INTO auction_enrollments (auction_id, user_id, status)
+VALUES (?, ?, 'approved')
+ON CONFLICT(auction_id, user_id) DO UPDATE SET status = 'approved'
+RETURNING id, auction_id, user_id, status, created_at;
+`
+
+// UpsertApprovedEnrollment enrolls an invited user outright, overriding any
+// earlier pending, waitlisted or rejected request.
+func (q *Queries) UpsertApprovedEnrollment(ctx context.Context, auctionID, userID int64) (AuctionEnrollment, error) {
+	row := q.db.QueryRowContext(ctx, upsertApprovedEnrollment, auctionID, userID)
+	var i AuctionEnrollment
+	err := row.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt)
+	return i, err
+}
+
+const nextWaitlistedEnrollment = `
+SELECT id, auction_id, user_id, status, created_at
+FROM auction_enrollments
+WHERE auction_id = ? AND status = 'waitlisted'
+ORDER BY id
+LIMIT 1;
+`
+
+func (q *Queries) NextWaitlistedEnrollment(ctx context.Context, auctionID int64) (AuctionEnrollment, error) {
+	row := q.db.QueryRowContext(ctx, nextWaitlistedEnrollment, auctionID)
+	var i AuctionEnrollment
+	err := row.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt)
+	return i, err
+}
+
+const setEnrollmentStatus = `
+UPDATE auction_enrollments SET status = ?
+WHERE id = ?
+RETURNING id, auction_id, user_id, status, created_at;
+`
+
+func (q *Queries) SetEnrollmentStatus(ctx context.Context, status string, id int64) (AuctionEnrollment, error) {
+	row := q.db.QueryRowContext(ctx, setEnrollmentStatus, status, id)
+	var i AuctionEnrollment
+	err := row.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.Status, &i.CreatedAt)
+	return i, err
+}
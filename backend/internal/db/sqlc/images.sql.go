@@ -0,0 +1,224 @@
+package db
+
+import "context"
+
+type ItemImage struct {
+	ID        int64  `json:"id" db:"id"`
+	ItemType  string `json:"item_type" db:"item_type"`
+	ItemID    int64  `json:"item_id" db:"item_id"`
+	Position  int64  `json:"position" db:"position"`
+	Caption   string `json:"caption" db:"caption"`
+	IsPrimary int64  `json:"is_primary" db:"is_primary"`
+	Filename  string `json:"filename" db:"filename"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+const itemImageColumns = `id, item_type, item_id, position, caption, is_primary, filename, created_at`
+
+func scanItemImage(row interface{ Scan(dest ...any) error }, i *ItemImage) error {
+	return row.Scan(&i.ID, &i.ItemType, &i.ItemID, &i.Position, &i.Caption, &i.IsPrimary, &i.Filename, &i.CreatedAt)
+}
+
+type ImageVariant struct {
+	ImageID     int64  `json:"image_id" db:"image_id"`
+	Name        string `json:"name" db:"name"`
+	StorageKey  string `json:"-" db:"storage_key"`
+	ContentType string `json:"content_type" db:"content_type"`
+	Width       int64  `json:"width" db:"width"`
+	Height      int64  `json:"height" db:"height"`
+	SizeBytes   int64  `json:"size_bytes" db:"size_bytes"`
+}
+
+const imageVariantColumns = `image_id, name, storage_key, content_type, width, height, size_bytes`
+
+func scanImageVariant(row interface{ Scan(dest ...any) error }, i *ImageVariant) error {
+	return row.Scan(&i.ImageID, &i.Name, &i.StorageKey, &i.ContentType, &i.Width, &i.Height, &i.SizeBytes)
+}
+
+type CreateItemImageParams struct {
+	ItemType string
+	ItemID   int64
+	Caption  string
+	Filename string
+}
+
+const createItemImage = `
+INSERT INTO item_images (item_type, item_id, position, caption, is_primary, filename)
+VALUES (?1, ?2,
+        (SELECT COALESCE(MAX(position) + 1, 0) FROM item_images WHERE item_type = ?1 AND item_id = ?2),
+        ?3,
+        NOT EXISTS (SELECT 1 FROM item_images WHERE item_type = ?1 AND item_id = ?2),
+        ?4)
+RETURNING ` + itemImageColumns + `;
+`
+
+// CreateItemImage appends an image to an item's gallery. The first image of
+// a gallery becomes its primary.
+func (q *Queries) CreateItemImage(ctx context.Context, arg CreateItemImageParams) (ItemImage, error) {
+	var i ItemImage
+	err := scanItemImage(q.db.QueryRowContext(ctx, createItemImage, arg.ItemType, arg.ItemID, arg.Caption, arg.Filename), &i)
+	return i, err
+}
+
+const addImageVariant = `
+INSERT INTO item_image_variants (image_id, name, storage_key, content_type, width, height, size_bytes)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+`
+
+func (q *Queries) AddImageVariant(ctx context.Context, arg ImageVariant) error {
+	_, err := q.db.ExecContext(ctx, addImageVariant, arg.ImageID, arg.Name, arg.StorageKey, arg.ContentType, arg.Width, arg.Height, arg.SizeBytes)
+	return err
+}
+
+const getItemImage = `
+SELECT ` + itemImageColumns + ` FROM item_images WHERE id = ?;
+`
+
+func (q *Queries) GetItemImage(ctx context.Context, id int64) (ItemImage, error) {
+	var i ItemImage
+	err := scanItemImage(q.db.QueryRowContext(ctx, getItemImage, id), &i)
+	return i, err
+}
+
+const listItemImages = `
+SELECT ` + itemImageColumns + ` FROM item_images
+WHERE item_type = ? AND item_id = ?
+ORDER BY position, id;
+`
+
+func (q *Queries) ListItemImages(ctx context.Context, itemType string, itemID int64) ([]ItemImage, error) {
+	rows, err := q.db.QueryContext(ctx, listItemImages, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ItemImage{}
+	for rows.Next() {
+		var i ItemImage
+		if err := scanItemImage(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (q *Queries) listImageVariants(ctx context.Context, query string, args ...any) ([]ImageVariant, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ImageVariant{}
+	for rows.Next() {
+		var i ImageVariant
+		if err := scanImageVariant(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const listItemImageVariants = `
+SELECT v.image_id, v.name, v.storage_key, v.content_type, v.width, v.height, v.size_bytes
+FROM item_image_variants v
+JOIN item_images i ON i.id = v.image_id
+WHERE i.item_type = ? AND i.item_id = ?;
+`
+
+// ListItemImageVariants returns the variants of every image in an item's
+// gallery.
+func (q *Queries) ListItemImageVariants(ctx context.Context, itemType string, itemID int64) ([]ImageVariant, error) {
+	return q.listImageVariants(ctx, listItemImageVariants, itemType, itemID)
+}
+
+const listImageVariants = `
+SELECT ` + imageVariantColumns + ` FROM item_image_variants WHERE image_id = ?;
+`
+
+func (q *Queries) ListImageVariants(ctx context.Context, imageID int64) ([]ImageVariant, error) {
+	return q.listImageVariants(ctx, listImageVariants, imageID)
+}
+
+const getImageVariant = `
+SELECT ` + imageVariantColumns + ` FROM item_image_variants WHERE image_id = ? AND name = ?;
+`
+
+func (q *Queries) GetImageVariant(ctx context.Context, imageID int64, name string) (ImageVariant, error) {
+	var i ImageVariant
+	err := scanImageVariant(q.db.QueryRowContext(ctx, getImageVariant, imageID, name), &i)
+	return i, err
+}
+
+const updateItemImageCaption = `
+UPDATE item_images SET caption = ? WHERE id = ?
+RETURNING ` + itemImageColumns + `;
+`
+
+func (q *Queries) UpdateItemImageCaption(ctx context.Context, id int64, caption string) (ItemImage, error) {
+	var i ItemImage
+	err := scanItemImage(q.db.QueryRowContext(ctx, updateItemImageCaption, caption, id), &i)
+	return i, err
+}
+
+const setPrimaryItemImage = `
+UPDATE item_images SET is_primary = (id = ?3) WHERE item_type = ?1 AND item_id = ?2;
+`
+
+// SetPrimaryItemImage makes imageID the only primary image of its gallery.
+func (q *Queries) SetPrimaryItemImage(ctx context.Context, itemType string, itemID, imageID int64) error {
+	_, err := q.db.ExecContext(ctx, setPrimaryItemImage, itemType, itemID, imageID)
+	return err
+}
+
+const ensurePrimaryItemImage = `
+UPDATE item_images SET is_primary = 1
+WHERE id = (SELECT id FROM item_images WHERE item_type = ?1 AND item_id = ?2 ORDER BY position, id LIMIT 1)
+  AND NOT EXISTS (SELECT 1 FROM item_images WHERE item_type = ?1 AND item_id = ?2 AND is_primary = 1);
+`
+
+// EnsurePrimaryItemImage promotes the first image of a gallery that has lost
+// its primary, for example after the primary was deleted.
+func (q *Queries) EnsurePrimaryItemImage(ctx context.Context, itemType string, itemID int64) error {
+	_, err := q.db.ExecContext(ctx, ensurePrimaryItemImage, itemType, itemID)
+	return err
+}
+
+const setItemImagePosition = `
+UPDATE item_images SET position = ? WHERE id = ?;
+`
+
+func (q *Queries) SetItemImagePosition(ctx context.Context, id, position int64) error {
+	_, err := q.db.ExecContext(ctx, setItemImagePosition, position, id)
+	return err
+}
+
+const deleteItemImage = `
+DELETE FROM item_images WHERE id = ?;
+`
+
+// DeleteItemImage removes an image and its variant rows; the stored files
+// are the caller's to delete.
+func (q *Queries) DeleteItemImage(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteItemImage, id)
+	return err
+}
+
+const setListingImageURL = `
+UPDATE listings SET image_url = ? WHERE id = ?;
+`
+
+func (q *Queries) SetListingImageURL(ctx context.Context, id int64, url string) error {
+	_, err := q.db.ExecContext(ctx, setListingImageURL, url, id)
+	return err
+}
+
+const setAuctionImageURL = `
+UPDATE auctions SET image_url = ? WHERE id = ?;
+`
+
+func (q *Queries) SetAuctionImageURL(ctx context.Context, id int64, url string) error {
+	_, err := q.db.ExecContext(ctx, setAuctionImageURL, url, id)
+	return err
+}
@@ -0,0 +1,73 @@
+package db
+
+import "context"
+
+type AuctionInvite struct {
+	ID           int64  `json:"id" db:"id"`
+	AuctionID    int64  `json:"auction_id" db:"auction_id"`
+	UserID       int64  `json:"user_id" db:"user_id"`
+	InvitedBy    int64  `json:"invited_by" db:"invited_by"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+	Email        string `json:"email" db:"email"`
+	BusinessName string `json:"business_name" db:"business_name"`
+}
+
+const createAuctionInvite = `
+INSERT INTO auction_invites (auction_id, user_id, invited_by)
+VALUES (?, ?, ?)
+RETURNING id, auction_id, user_id, invited_by, created_at;
+`
+
+func (q *Queries) CreateAuctionInvite(ctx context.Context, auctionID, userID, invitedBy int64) (AuctionInvite, error) {
+	row := q.db.QueryRowContext(ctx, createAuctionInvite, auctionID, userID, invitedBy)
+	var i AuctionInvite
+	err := row.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.InvitedBy, &i.CreatedAt)
+	return i, err
+}
+
+const listAuctionInvites = `
+SELECT ai.id, ai.auction_id, ai.user_id, ai.invited_by, ai.created_at, u.email, u.business_name
+FROM auction_invites ai
+JOIN users u ON u.id = ai.user_id
+WHERE ai.auction_id = ?
+ORDER BY ai.id;
+`
+
+func (q *Queries) ListAuctionInvites(ctx context.Context, auctionID int64) ([]AuctionInvite, error) {
+	rows, err := q.db.QueryContext(ctx, listAuctionInvites, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuctionInvite{}
+	for rows.Next() {
+		var i AuctionInvite
+		if err := rows.Scan(&i.ID, &i.AuctionID, &i.UserID, &i.InvitedBy, &i.CreatedAt, &i.Email, &i.BusinessName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const deleteAuctionInvite = `
+DELETE FROM auction_invites
+WHERE auction_id = ? AND user_id = ?
+RETURNING id;
+`
+
+// DeleteAuctionInvite returns sql.ErrNoRows when the user was not invited.
+func (q *Queries) DeleteAuctionInvite(ctx context.Context, auctionID, userID int64) error {
+	var id int64
+	return q.db.QueryRowContext(ctx, deleteAuctionInvite, auctionID, userID).Scan(&id)
+}
+
+const isInvited = `
+SELECT EXISTS (SELECT 1 FROM auction_invites WHERE auction_id = ? AND user_id = ?);
+`
+
+func (q *Queries) IsInvited(ctx context.Context, auctionID, userID int64) (bool, error) {
+	var ok bool
+	err := q.db.QueryRowContext(ctx, isInvited, auctionID, userID).Scan(&ok)
+	return ok, err
+}
@@ -39,10 +39,11 @@ type IsLikedParams struct {
 	ListingID int64
 }
 
-// Insert the like and capture current status/price from listings.
+// Insert the like and capture current status/price from listings, both for
+// the favorites page and for the alert job.
 const likeListing = `
-INSERT OR IGNORE INTO user_likes (user_id, listing_id, seen_status, seen_price)
-SELECT ?, l.id, l.status, l.price FROM listings l WHERE l.id = ?;
+INSERT OR IGNORE INTO user_likes (user_id, listing_id, seen_status, seen_price, alerted_status, alerted_price)
+SELECT ?, l.id, l.status, l.price, l.status, l.price FROM listings l WHERE l.id = ?;
 `
 
 // LikeListing inserts a like. Returns (alreadyLiked bool, err).
@@ -130,3 +131,12 @@ func (q *Queries) MarkLikesSeen(ctx context.Context, userID int64) error {
 	_, err := q.db.ExecContext(ctx, markLikesSeen, userID)
 	return err
 }
+
+const deleteUserLikes = `
+DELETE FROM user_likes WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteUserLikes(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserLikes, userID)
+	return err
+}
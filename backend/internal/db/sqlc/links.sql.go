@@ -0,0 +1,187 @@
+package db
+
+import "context"
+
+type ListingAuctionLink struct {
+	AuctionID      int64  `json:"auction_id" db:"auction_id"`
+	ListingID      int64  `json:"listing_id" db:"listing_id"`
+	ReturnIfUnsold int64  `json:"return_if_unsold" db:"return_if_unsold"`
+	Outcome        string `json:"outcome" db:"outcome"`
+	CreatedAt      string `json:"created_at" db:"created_at"`
+	ResolvedAt     string `json:"resolved_at" db:"resolved_at"`
+}
+
+const listingAuctionLinkColumns = `auction_id, listing_id, return_if_unsold, outcome, created_at, resolved_at`
+
+func scanListingAuctionLink(row interface{ Scan(dest ...any) error }, i *ListingAuctionLink) error {
+	return row.Scan(&i.AuctionID, &i.ListingID, &i.ReturnIfUnsold, &i.Outcome, &i.CreatedAt, &i.ResolvedAt)
+}
+
+type CreateListingAuctionLinkParams struct {
+	AuctionID      int64
+	ListingID      int64
+	ReturnIfUnsold int64
+	Outcome        string
+}
+
+const createListingAuctionLink = `
+INSERT INTO listing_auction_links (auction_id, listing_id, return_if_unsold, outcome, resolved_at)
+VALUES (?1, ?2, ?3, ?4, CASE WHEN ?4 = '' THEN '' ELSE datetime('now') END)
+RETURNING ` + listingAuctionLinkColumns + `;
+`
+
+func (q *Queries) CreateListingAuctionLink(ctx context.Context, arg CreateListingAuctionLinkParams) (ListingAuctionLink, error) {
+	var i ListingAuctionLink
+	err := scanListingAuctionLink(q.db.QueryRowContext(ctx, createListingAuctionLink, arg.AuctionID, arg.ListingID, arg.ReturnIfUnsold, arg.Outcome), &i)
+	return i, err
+}
+
+const getLinkForAuction = `
+SELECT ` + listingAuctionLinkColumns + ` FROM listing_auction_links WHERE auction_id = ?;
+`
+
+func (q *Queries) GetLinkForAuction(ctx context.Context, auctionID int64) (ListingAuctionLink, error) {
+	var i ListingAuctionLink
+	err := scanListingAuctionLink(q.db.QueryRowContext(ctx, getLinkForAuction, auctionID), &i)
+	return i, err
+}
+
+const getLatestLinkForListing = `
+SELECT ` + listingAuctionLinkColumns + ` FROM listing_auction_links
+WHERE listing_id = ?
+ORDER BY created_at DESC, auction_id DESC
+LIMIT 1;
+`
+
+// GetLatestLinkForListing returns the most recent auction a listing was sent
+// to or came back from.
+func (q *Queries) GetLatestLinkForListing(ctx context.Context, listingID int64) (ListingAuctionLink, error) {
+	var i ListingAuctionLink
+	err := scanListingAuctionLink(q.db.QueryRowContext(ctx, getLatestLinkForListing, listingID), &i)
+	return i, err
+}
+
+// EndedLink is a pending link whose auction is over, with whether it sold.
+type EndedLink struct {
+	ListingAuctionLink
+	Sold bool
+}
+
+const listEndedListingAuctionLinks = `
+SELECT l.auction_id, l.listing_id, l.return_if_unsold, l.outcome, l.created_at, l.resolved_at,
+       a.status = 'sold' OR (a.highest_bidder_id != 0 AND (a.reserve_price = 0 OR a.current_bid >= a.reserve_price))
+FROM listing_auction_links l
+JOIN auctions a ON a.id = l.auction_id
+WHERE l.outcome = '' AND a.status IN ('closed', 'sold');
+`
+
+// ListEndedListingAuctionLinks returns unresolved links whose auction has
+// closed. An auction sold when it has a winning bid that meets the reserve,
+// or when an admin marked it sold.
+func (q *Queries) ListEndedListingAuctionLinks(ctx context.Context) ([]EndedLink, error) {
+	rows, err := q.db.QueryContext(ctx, listEndedListingAuctionLinks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EndedLink{}
+	for rows.Next() {
+		var i EndedLink
+		if err := rows.Scan(
+			&i.AuctionID, &i.ListingID, &i.ReturnIfUnsold, &i.Outcome, &i.CreatedAt, &i.ResolvedAt, &i.Sold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const resolveListingAuctionLink = `
+UPDATE listing_auction_links SET outcome = ?, resolved_at = datetime('now') WHERE auction_id = ?;
+`
+
+func (q *Queries) ResolveListingAuctionLink(ctx context.Context, auctionID int64, outcome string) error {
+	_, err := q.db.ExecContext(ctx, resolveListingAuctionLink, outcome, auctionID)
+	return err
+}
+
+const setListingStatus = `
+UPDATE listings SET status = ? WHERE id = ?;
+`
+
+func (q *Queries) SetListingStatus(ctx context.Context, id int64, status string) error {
+	_, err := q.db.ExecContext(ctx, setListingStatus, status, id)
+	return err
+}
+
+const sendListingToAuction = `
+UPDATE listings SET status = 'auction' WHERE id = ? AND status NOT IN ('auction', 'sold');
+`
+
+// SendListingToAuction marks a listing as in auction and reports how many
+// listings it changed; 0 means the listing is already in an auction or sold.
+func (q *Queries) SendListingToAuction(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, sendListingToAuction, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const relistListing = `
+UPDATE listings SET status = 'active', price = CASE WHEN ?2 > 0 THEN ?2 ELSE price END
+WHERE id = ?1
+RETURNING id, title, description, location, price, sale_type, year, status, image_url, created_at;
+`
+
+// RelistListing puts a listing back in the catalog, repricing it when price
+// is positive.
+func (q *Queries) RelistListing(ctx context.Context, id, price int64) (Listing, error) {
+	var i Listing
+	err := q.db.QueryRowContext(ctx, relistListing, id, price).Scan(
+		&i.ID, &i.Title, &i.Description, &i.Location, &i.Price,
+		&i.SaleType, &i.Year, &i.Status, &i.ImageURL, &i.CreatedAt,
+	)
+	return i, err
+}
+
+const copyListingCategoriesToAuction = `
+INSERT OR IGNORE INTO auction_categories (auction_id, category_id)
+SELECT ?2, category_id FROM listing_categories WHERE listing_id = ?1;
+`
+
+func (q *Queries) CopyListingCategoriesToAuction(ctx context.Context, listingID, auctionID int64) error {
+	_, err := q.db.ExecContext(ctx, copyListingCategoriesToAuction, listingID, auctionID)
+	return err
+}
+
+const copyAuctionCategoriesToListing = `
+INSERT OR IGNORE INTO listing_categories (listing_id, category_id)
+SELECT ?2, category_id FROM auction_categories WHERE auction_id = ?1;
+`
+
+func (q *Queries) CopyAuctionCategoriesToListing(ctx context.Context, auctionID, listingID int64) error {
+	_, err := q.db.ExecContext(ctx, copyAuctionCategoriesToListing, auctionID, listingID)
+	return err
+}
+
+const copyListingSpecsToAuction = `
+INSERT OR IGNORE INTO auction_specs (auction_id, attribute_id, value)
+SELECT ?2, attribute_id, value FROM listing_specs WHERE listing_id = ?1;
+`
+
+func (q *Queries) CopyListingSpecsToAuction(ctx context.Context, listingID, auctionID int64) error {
+	_, err := q.db.ExecContext(ctx, copyListingSpecsToAuction, listingID, auctionID)
+	return err
+}
+
+const copyAuctionSpecsToListing = `
+INSERT OR IGNORE INTO listing_specs (listing_id, attribute_id, value)
+SELECT ?2, attribute_id, value FROM auction_specs WHERE auction_id = ?1;
+`
+
+func (q *Queries) CopyAuctionSpecsToListing(ctx context.Context, auctionID, listingID int64) error {
+	_, err := q.db.ExecContext(ctx, copyAuctionSpecsToListing, auctionID, listingID)
+	return err
+}
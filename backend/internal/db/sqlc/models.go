@@ -20,6 +20,7 @@ type Auction struct {
 	AutoExtendMinutes       int64  `json:"auto_extend_minutes" db:"auto_extend_minutes"`
 	AutoExtendWindowMinutes int64  `json:"auto_extend_window_minutes" db:"auto_extend_window_minutes"`
 	PriceVisible            int64  `json:"price_visible" db:"price_visible"`
+	Visibility              string `json:"visibility" db:"visibility"`
 }
 
 type Listing struct {
@@ -52,6 +53,7 @@ type CreateAuctionParams struct {
 	AutoExtendMinutes       int64
 	AutoExtendWindowMinutes int64
 	PriceVisible            int64
+	Visibility              string
 }
 
 type UpdateAuctionParams struct {
@@ -72,6 +74,7 @@ type UpdateAuctionParams struct {
 	AutoExtendMinutes       int64
 	AutoExtendWindowMinutes int64
 	PriceVisible            int64
+	Visibility              string
 }
 
 type CreateListingParams struct {
@@ -119,6 +122,15 @@ type User struct {
 	MustChangePassword     int64  `json:"must_change_password" db:"must_change_password"`
 	IsAdmin                int64  `json:"is_admin" db:"is_admin"`
 	CreatedAt              string `json:"created_at" db:"created_at"`
+	ClosedAt               string `json:"closed_at" db:"closed_at"`
+	InfoRequest            string `json:"info_request" db:"info_request"`
+	AssignedReviewerID     int64  `json:"assigned_reviewer_id" db:"assigned_reviewer_id"`
+	AssignedAt             string `json:"assigned_at" db:"assigned_at"`
+	SubmittedAt            string `json:"submitted_at" db:"submitted_at"`
+	ReviewedAt             string `json:"reviewed_at" db:"reviewed_at"`
+	SuspensionReason       string `json:"suspension_reason" db:"suspension_reason"`
+	SuspendedAt            string `json:"suspended_at" db:"suspended_at"`
+	SuspendedUntil         string `json:"suspended_until" db:"suspended_until"`
 }
 
 type AuctionEnrollment struct {
@@ -0,0 +1,378 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+type Notification struct {
+	ID         int64  `json:"id" db:"id"`
+	UserID     int64  `json:"user_id" db:"user_id"`
+	Kind       string `json:"kind" db:"kind"`
+	ItemType   string `json:"item_type" db:"item_type"`
+	ItemID     int64  `json:"item_id" db:"item_id"`
+	Title      string `json:"title" db:"title"`
+	Body       string `json:"body" db:"body"`
+	OldPrice   int64  `json:"old_price" db:"old_price"`
+	NewPrice   int64  `json:"new_price" db:"new_price"`
+	OldStatus  string `json:"old_status" db:"old_status"`
+	NewStatus  string `json:"new_status" db:"new_status"`
+	EmailState string `json:"email_state" db:"email_state"`
+	ReadAt     string `json:"read_at" db:"read_at"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+}
+
+const notificationColumns = `id, user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state, read_at, created_at`
+
+func scanNotification(row interface{ Scan(dest ...any) error }, i *Notification) error {
+	return row.Scan(
+		&i.ID, &i.UserID, &i.Kind, &i.ItemType, &i.ItemID, &i.Title, &i.Body, &i.OldPrice, &i.NewPrice,
+		&i.OldStatus, &i.NewStatus, &i.EmailState, &i.ReadAt, &i.CreatedAt,
+	)
+}
+
+func (q *Queries) listNotifications(ctx context.Context, query string, args ...any) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Notification{}
+	for rows.Next() {
+		var i Notification
+		if err := scanNotification(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type NotificationPreferences struct {
+	UserID          int64  `json:"user_id" db:"user_id"`
+	PriceDrops      int64  `json:"price_drops" db:"price_drops"`
+	StatusChanges   int64  `json:"status_changes" db:"status_changes"`
+	EmailMode       string `json:"email_mode" db:"email_mode"`
+	WebSocket       int64  `json:"websocket" db:"websocket"`
+	AuctionStarting int64  `json:"auction_starting" db:"auction_starting"`
+	AuctionEnding   int64  `json:"auction_ending" db:"auction_ending"`
+	AuctionBids     int64  `json:"auction_bids" db:"auction_bids"`
+	AuctionResults  int64  `json:"auction_results" db:"auction_results"`
+	LastDigestAt    string `json:"last_digest_at" db:"last_digest_at"`
+}
+
+// preferenceDests lists the scan targets for the preference columns that
+// follow user_id, in the order every query selects them.
+func preferenceDests(i *NotificationPreferences) []any {
+	return []any{
+		&i.PriceDrops, &i.StatusChanges, &i.EmailMode, &i.WebSocket,
+		&i.AuctionStarting, &i.AuctionEnding, &i.AuctionBids, &i.AuctionResults, &i.LastDigestAt,
+	}
+}
+
+const getNotificationPreferences = `
+SELECT u.id, COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM users u
+LEFT JOIN notification_preferences p ON p.user_id = u.id
+WHERE u.id = ?;
+`
+
+// GetNotificationPreferences returns a user's preferences, or the defaults
+// when they never changed them.
+func (q *Queries) GetNotificationPreferences(ctx context.Context, userID int64) (NotificationPreferences, error) {
+	var i NotificationPreferences
+	err := q.db.QueryRowContext(ctx, getNotificationPreferences, userID).Scan(append([]any{&i.UserID}, preferenceDests(&i)...)...)
+	return i, err
+}
+
+type UpsertNotificationPreferencesParams struct {
+	UserID          int64
+	PriceDrops      int64
+	StatusChanges   int64
+	EmailMode       string
+	WebSocket       int64
+	AuctionStarting int64
+	AuctionEnding   int64
+	AuctionBids     int64
+	AuctionResults  int64
+}
+
+const upsertNotificationPreferences = `
+INSERT INTO notification_preferences (user_id, price_drops, status_changes, email_mode, websocket,
+  auction_starting, auction_ending, auction_bids, auction_results)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+  price_drops = excluded.price_drops,
+  status_changes = excluded.status_changes,
+  email_mode = excluded.email_mode,
+  websocket = excluded.websocket,
+  auction_starting = excluded.auction_starting,
+  auction_ending = excluded.auction_ending,
+  auction_bids = excluded.auction_bids,
+  auction_results = excluded.auction_results,
+  updated_at = CURRENT_TIMESTAMP
+RETURNING user_id, price_drops, status_changes, email_mode, websocket,
+  auction_starting, auction_ending, auction_bids, auction_results, last_digest_at;
+`
+
+func (q *Queries) UpsertNotificationPreferences(ctx context.Context, arg UpsertNotificationPreferencesParams) (NotificationPreferences, error) {
+	var i NotificationPreferences
+	err := q.db.QueryRowContext(ctx, upsertNotificationPreferences,
+		arg.UserID, arg.PriceDrops, arg.StatusChanges, arg.EmailMode, arg.WebSocket,
+		arg.AuctionStarting, arg.AuctionEnding, arg.AuctionBids, arg.AuctionResults,
+	).Scan(append([]any{&i.UserID}, preferenceDests(&i)...)...)
+	return i, err
+}
+
+// LikeChange is a like whose listing changed price or status since the alert
+// job last looked, with the owner's preferences.
+type LikeChange struct {
+	LikeID        int64
+	UserID        int64
+	UserClosedAt  string
+	ListingID     int64
+	Title         string
+	AlertedPrice  int64
+	AlertedStatus string
+	Price         int64
+	Status        string
+	Preferences   NotificationPreferences
+}
+
+const listLikeChanges = `
+SELECT ul.id, ul.user_id, u.closed_at, l.id, l.title, ul.alerted_price, ul.alerted_status, l.price, l.status,
+       COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM user_likes ul
+JOIN listings l ON l.id = ul.listing_id
+JOIN users u ON u.id = ul.user_id
+LEFT JOIN notification_preferences p ON p.user_id = ul.user_id
+WHERE l.price != ul.alerted_price OR l.status != ul.alerted_status
+ORDER BY ul.id;
+`
+
+func (q *Queries) ListLikeChanges(ctx context.Context) ([]LikeChange, error) {
+	rows, err := q.db.QueryContext(ctx, listLikeChanges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LikeChange{}
+	for rows.Next() {
+		var i LikeChange
+		dest := []any{&i.LikeID, &i.UserID, &i.UserClosedAt, &i.ListingID, &i.Title, &i.AlertedPrice, &i.AlertedStatus, &i.Price, &i.Status}
+		if err := rows.Scan(append(dest, preferenceDests(&i.Preferences)...)...); err != nil {
+			return nil, err
+		}
+		i.Preferences.UserID = i.UserID
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const updateLikeAlerted = `
+UPDATE user_likes SET alerted_price = ?, alerted_status = ? WHERE id = ?;
+`
+
+func (q *Queries) UpdateLikeAlerted(ctx context.Context, likeID, price int64, status string) error {
+	_, err := q.db.ExecContext(ctx, updateLikeAlerted, price, status, likeID)
+	return err
+}
+
+type CreateNotificationParams struct {
+	UserID     int64
+	Kind       string
+	ItemType   string
+	ItemID     int64
+	Title      string
+	Body       string
+	OldPrice   int64
+	NewPrice   int64
+	OldStatus  string
+	NewStatus  string
+	EmailState string
+}
+
+const createNotification = `
+INSERT INTO notifications (user_id, kind, item_type, item_id, title, body, old_price, new_price, old_status, new_status, email_state)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + notificationColumns + `;
+`
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	var i Notification
+	err := scanNotification(q.db.QueryRowContext(ctx, createNotification,
+		arg.UserID, arg.Kind, arg.ItemType, arg.ItemID, arg.Title, arg.Body,
+		arg.OldPrice, arg.NewPrice, arg.OldStatus, arg.NewStatus, arg.EmailState,
+	), &i)
+	return i, err
+}
+
+type ListNotificationsParams struct {
+	UserID     int64
+	UnreadOnly bool
+	// BeforeID pages backwards from the newest; 0 starts at the newest.
+	BeforeID int64
+	Limit    int64
+}
+
+// ListNotifications returns a user's feed, newest first.
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	conds := []string{"user_id = ?"}
+	args := []any{arg.UserID}
+	if arg.UnreadOnly {
+		conds = append(conds, "read_at = ''")
+	}
+	if arg.BeforeID > 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, arg.BeforeID)
+	}
+	args = append(args, arg.Limit)
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY id DESC LIMIT ?`
+	return q.listNotifications(ctx, query, args...)
+}
+
+const countUnreadNotifications = `
+SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at = '';
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	var n int64
+	err := q.db.QueryRowContext(ctx, countUnreadNotifications, userID).Scan(&n)
+	return n, err
+}
+
+const markNotificationRead = `
+UPDATE notifications SET read_at = datetime('now')
+WHERE id = ? AND user_id = ? AND read_at = '';
+`
+
+// MarkNotificationRead reports how many notifications it marked; 0 means the
+// notification is not the user's or was already read.
+func (q *Queries) MarkNotificationRead(ctx context.Context, userID, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNotificationRead, id, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markAllNotificationsRead = `
+UPDATE notifications SET read_at = datetime('now') WHERE user_id = ? AND read_at = '';
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markAllNotificationsRead, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PendingEmail is a user with notifications waiting to be emailed.
+type PendingEmail struct {
+	UserID       int64
+	Email        string
+	EmailMode    string
+	LastDigestAt string
+}
+
+const listPendingEmails = `
+SELECT u.id, u.email, COALESCE(p.email_mode, 'instant'), COALESCE(p.last_digest_at, '')
+FROM users u
+LEFT JOIN notification_preferences p ON p.user_id = u.id
+WHERE u.closed_at = ''
+  AND EXISTS (SELECT 1 FROM notifications n WHERE n.user_id = u.id AND n.email_state = 'pending')
+ORDER BY u.id;
+`
+
+func (q *Queries) ListPendingEmails(ctx context.Context) ([]PendingEmail, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingEmails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PendingEmail{}
+	for rows.Next() {
+		var i PendingEmail
+		if err := rows.Scan(&i.UserID, &i.Email, &i.EmailMode, &i.LastDigestAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const listPendingEmailNotifications = `
+SELECT ` + notificationColumns + ` FROM notifications
+WHERE user_id = ? AND email_state = 'pending'
+ORDER BY id;
+`
+
+func (q *Queries) ListPendingEmailNotifications(ctx context.Context, userID int64) ([]Notification, error) {
+	return q.listNotifications(ctx, listPendingEmailNotifications, userID)
+}
+
+const markNotificationsEmailed = `
+UPDATE notifications SET email_state = 'sent'
+WHERE user_id = ? AND email_state = 'pending' AND id <= ?;
+`
+
+// MarkNotificationsEmailed marks a user's pending notifications up to and
+// including maxID as sent.
+func (q *Queries) MarkNotificationsEmailed(ctx context.Context, userID, maxID int64) error {
+	_, err := q.db.ExecContext(ctx, markNotificationsEmailed, userID, maxID)
+	return err
+}
+
+const skipPendingNotificationEmails = `
+UPDATE notifications SET email_state = 'none' WHERE user_id = ? AND email_state = 'pending';
+`
+
+// SkipPendingNotificationEmails drops a user's queued emails, for when they
+// turn email off.
+func (q *Queries) SkipPendingNotificationEmails(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, skipPendingNotificationEmails, userID)
+	return err
+}
+
+const setLastDigestAt = `
+INSERT INTO notification_preferences (user_id, email_mode, last_digest_at)
+VALUES (?, 'digest', datetime('now'))
+ON CONFLICT (user_id) DO UPDATE SET last_digest_at = excluded.last_digest_at;
+`
+
+func (q *Queries) SetLastDigestAt(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, setLastDigestAt, userID)
+	return err
+}
+
+const listUserNotifications = `
+SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = ? ORDER BY id DESC;
+`
+
+// ListUserNotifications returns a user's whole feed, newest first.
+func (q *Queries) ListUserNotifications(ctx context.Context, userID int64) ([]Notification, error) {
+	return q.listNotifications(ctx, listUserNotifications, userID)
+}
+
+const deleteUserNotifications = `
+DELETE FROM notifications WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteUserNotifications(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserNotifications, userID)
+	return err
+}
+
+const deleteNotificationPreferences = `
+DELETE FROM notification_preferences WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteNotificationPreferences(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationPreferences, userID)
+	return err
+}
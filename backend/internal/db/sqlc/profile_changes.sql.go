@@ -0,0 +1,165 @@
+package db
+
+import "context"
+
+type ProfileChangeRequest struct {
+	ID                          int64  `json:"id" db:"id"`
+	UserID                      int64  `json:"user_id" db:"user_id"`
+	BusinessName                string `json:"business_name" db:"business_name"`
+	LegalRepresentative         string `json:"legal_representative" db:"legal_representative"`
+	RFC                         string `json:"rfc" db:"rfc"`
+	PreviousBusinessName        string `json:"previous_business_name" db:"previous_business_name"`
+	PreviousLegalRepresentative string `json:"previous_legal_representative" db:"previous_legal_representative"`
+	PreviousRFC                 string `json:"previous_rfc" db:"previous_rfc"`
+	Status                      string `json:"status" db:"status"`
+	ReviewNote                  string `json:"review_note" db:"review_note"`
+	ReviewedBy                  int64  `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt                  string `json:"reviewed_at" db:"reviewed_at"`
+	CreatedAt                   string `json:"created_at" db:"created_at"`
+}
+
+const profileChangeColumns = `id, user_id, business_name, legal_representative, rfc,
+       previous_business_name, previous_legal_representative, previous_rfc,
+       status, review_note, reviewed_by, reviewed_at, created_at`
+
+func scanProfileChange(row interface{ Scan(dest ...any) error }, i *ProfileChangeRequest) error {
+	return row.Scan(
+		&i.ID, &i.UserID, &i.BusinessName, &i.LegalRepresentative, &i.RFC,
+		&i.PreviousBusinessName, &i.PreviousLegalRepresentative, &i.PreviousRFC,
+		&i.Status, &i.ReviewNote, &i.ReviewedBy, &i.ReviewedAt, &i.CreatedAt,
+	)
+}
+
+func (q *Queries) listProfileChanges(ctx context.Context, query string, args ...any) ([]ProfileChangeRequest, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProfileChangeRequest{}
+	for rows.Next() {
+		var i ProfileChangeRequest
+		if err := scanProfileChange(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const supersedeProfileChanges = `
+UPDATE profile_change_requests
+SET status = 'superseded'
+WHERE user_id = ? AND status = 'pending';
+`
+
+func (q *Queries) SupersedeProfileChanges(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, supersedeProfileChanges, userID)
+	return err
+}
+
+type CreateProfileChangeParams struct {
+	UserID                      int64
+	BusinessName                string
+	LegalRepresentative         string
+	RFC                         string
+	PreviousBusinessName        string
+	PreviousLegalRepresentative string
+	PreviousRFC                 string
+}
+
+const createProfileChange = `
+INSERT INTO profile_change_requests (user_id, business_name, legal_representative, rfc,
+                                     previous_business_name, previous_legal_representative, previous_rfc)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + profileChangeColumns + `;
+`
+
+func (q *Queries) CreateProfileChange(ctx context.Context, arg CreateProfileChangeParams) (ProfileChangeRequest, error) {
+	row := q.db.QueryRowContext(ctx, createProfileChange,
+		arg.UserID, arg.BusinessName, arg.LegalRepresentative, arg.RFC,
+		arg.PreviousBusinessName, arg.PreviousLegalRepresentative, arg.PreviousRFC,
+	)
+	var i ProfileChangeRequest
+	err := scanProfileChange(row, &i)
+	return i, err
+}
+
+const getProfileChange = `
+SELECT ` + profileChangeColumns + `
+FROM profile_change_requests
+WHERE id = ?;
+`
+
+func (q *Queries) GetProfileChange(ctx context.Context, id int64) (ProfileChangeRequest, error) {
+	var i ProfileChangeRequest
+	err := scanProfileChange(q.db.QueryRowContext(ctx, getProfileChange, id), &i)
+	return i, err
+}
+
+const getPendingProfileChange = `
+SELECT ` + profileChangeColumns + `
+FROM profile_change_requests
+WHERE user_id = ? AND status = 'pending';
+`
+
+func (q *Queries) GetPendingProfileChange(ctx context.Context, userID int64) (ProfileChangeRequest, error) {
+	var i ProfileChangeRequest
+	err := scanProfileChange(q.db.QueryRowContext(ctx, getPendingProfileChange, userID), &i)
+	return i, err
+}
+
+const listProfileChangesForUser = `
+SELECT ` + profileChangeColumns + `
+FROM profile_change_requests
+WHERE user_id = ?
+ORDER BY id DESC;
+`
+
+func (q *Queries) ListProfileChangesForUser(ctx context.Context, userID int64) ([]ProfileChangeRequest, error) {
+	return q.listProfileChanges(ctx, listProfileChangesForUser, userID)
+}
+
+const listProfileChangesByStatus = `
+SELECT ` + profileChangeColumns + `
+FROM profile_change_requests
+WHERE status = ?
+ORDER BY id
+LIMIT ?;
+`
+
+func (q *Queries) ListProfileChangesByStatus(ctx context.Context, status string, limit int64) ([]ProfileChangeRequest, error) {
+	return q.listProfileChanges(ctx, listProfileChangesByStatus, status, limit)
+}
+
+type ReviewProfileChangeParams struct {
+	ID         int64
+	Status     string
+	ReviewNote string
+	ReviewedBy int64
+}
+
+const reviewProfileChange = `
+UPDATE profile_change_requests
+SET status = ?, review_note = ?, reviewed_by = ?, reviewed_at = datetime('now')
+WHERE id = ? AND status = 'pending'
+RETURNING ` + profileChangeColumns + `;
+`
+
+// ReviewProfileChange only transitions pending requests; it returns
+// sql.ErrNoRows if the request was already reviewed or superseded.
+func (q *Queries) ReviewProfileChange(ctx context.Context, arg ReviewProfileChangeParams) (ProfileChangeRequest, error) {
+	row := q.db.QueryRowContext(ctx, reviewProfileChange, arg.Status, arg.ReviewNote, arg.ReviewedBy, arg.ID)
+	var i ProfileChangeRequest
+	err := scanProfileChange(row, &i)
+	return i, err
+}
+
+const deleteProfileChangesForUser = `
+DELETE FROM profile_change_requests WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteProfileChangesForUser(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteProfileChangesForUser, userID)
+	return err
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+type UserReviewNote struct {
+	ID           int64  `json:"id" db:"id"`
+	UserID       int64  `json:"user_id" db:"user_id"`
+	AuthorUserID int64  `json:"author_user_id" db:"author_user_id"`
+	Body         string `json:"body" db:"body"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+}
+
+// ReviewQueueFilter narrows the registration review queue. An empty Status
+// means every open state (pending and info_requested).
+type ReviewQueueFilter struct {
+	Status     string
+	AssigneeID int64
+	Unassigned bool
+}
+
+func (f ReviewQueueFilter) where() (string, []any) {
+	conds := []string{"closed_at = ''"}
+	var args []any
+	if f.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, f.Status)
+	} else {
+		conds = append(conds, "status IN ('pending', 'info_requested')")
+	}
+	if f.Unassigned {
+		conds = append(conds, "assigned_reviewer_id = 0")
+	} else if f.AssigneeID != 0 {
+		conds = append(conds, "assigned_reviewer_id = ?")
+		args = append(args, f.AssigneeID)
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ListReviewQueue returns open registrations oldest first, so the head of the
+// list is the one closest to breaching the SLA.
+func (q *Queries) ListReviewQueue(ctx context.Context, f ReviewQueueFilter, limit int64) ([]User, error) {
+	where, args := f.where()
+	query := "SELECT " + userColumns + " FROM users " + where + " ORDER BY submitted_at, id LIMIT ?"
+	rows, err := q.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := scanUser(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const claimUserReview = `
+UPDATE users
+SET assigned_reviewer_id = ?1, assigned_at = datetime('now')
+WHERE id = ?2 AND status IN ('pending', 'info_requested') AND assigned_reviewer_id IN (0, ?1)
+RETURNING ` + userColumns + `;
+`
+
+// ClaimUserReview assigns an open registration to reviewerID unless someone
+// else already holds it; it returns sql.ErrNoRows in that case.
+func (q *Queries) ClaimUserReview(ctx context.Context, reviewerID, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, claimUserReview, reviewerID, id)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const assignUserReviewer = `
+UPDATE users
+SET assigned_reviewer_id = ?1, assigned_at = CASE WHEN ?1 = 0 THEN '' ELSE datetime('now') END
+WHERE id = ?2 AND status IN ('pending', 'info_requested')
+RETURNING ` + userColumns + `;
+`
+
+// AssignUserReviewer reassigns an open registration; reviewerID 0 releases it.
+func (q *Queries) AssignUserReviewer(ctx context.Context, reviewerID, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, assignUserReviewer, reviewerID, id)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const requestUserInfo = `
+UPDATE users
+SET status = 'info_requested', info_request = ?, reviewed_at = datetime('now')
+WHERE id = ? AND status = 'pending'
+RETURNING ` + userColumns + `;
+`
+
+func (q *Queries) RequestUserInfo(ctx context.Context, message string, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, requestUserInfo, message, id)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const resubmitUser = `
+UPDATE users
+SET status = 'pending', info_request = '', submitted_at = datetime('now')
+WHERE id = ? AND status = 'info_requested'
+RETURNING ` + userColumns + `;
+`
+
+// ResubmitUser puts a registration back in the queue after the applicant
+// answered an information request. The reviewer assignment is kept.
+func (q *Queries) ResubmitUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, resubmitUser, id)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const createReviewNote = `
+INSERT INTO user_review_notes (user_id, author_user_id, body)
+VALUES (?, ?, ?)
+RETURNING id, user_id, author_user_id, body, created_at;
+`
+
+func (q *Queries) CreateReviewNote(ctx context.Context, userID, authorUserID int64, body string) (UserReviewNote, error) {
+	row := q.db.QueryRowContext(ctx, createReviewNote, userID, authorUserID, body)
+	var i UserReviewNote
+	err := row.Scan(&i.ID, &i.UserID, &i.AuthorUserID, &i.Body, &i.CreatedAt)
+	return i, err
+}
+
+const listReviewNotes = `
+SELECT id, user_id, author_user_id, body, created_at
+FROM user_review_notes
+WHERE user_id = ?
+ORDER BY id;
+`
+
+func (q *Queries) ListReviewNotes(ctx context.Context, userID int64) ([]UserReviewNote, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewNotes, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserReviewNote{}
+	for rows.Next() {
+		var i UserReviewNote
+		if err := rows.Scan(&i.ID, &i.UserID, &i.AuthorUserID, &i.Body, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type ReviewQueueStats struct {
+	Pending            int64   `json:"pending"`
+	InfoRequested      int64   `json:"info_requested"`
+	Unassigned         int64   `json:"unassigned"`
+	OverSLA            int64   `json:"over_sla"`
+	OldestSubmittedAt  string  `json:"oldest_submitted_at"`
+	AvgHoursInQueue    float64 `json:"avg_hours_in_queue"`
+	Decided30d         int64   `json:"decided_30d"`
+	AvgHoursToDecision float64 `json:"avg_hours_to_decision_30d"`
+}
+
+const getReviewQueueStats = `
+SELECT
+  COALESCE(SUM(status = 'pending'), 0),
+  COALESCE(SUM(status = 'info_requested'), 0),
+  COALESCE(SUM(status = 'pending' AND assigned_reviewer_id = 0), 0),
+  COALESCE(SUM(status = 'pending' AND (julianday('now') - julianday(submitted_at)) * 24 > ?), 0),
+  COALESCE(MIN(CASE WHEN status = 'pending' THEN submitted_at END), ''),
+  COALESCE(AVG(CASE WHEN status = 'pending' THEN (julianday('now') - julianday(submitted_at)) * 24 END), 0),
+  COALESCE(SUM(status IN ('approved', 'rejected') AND reviewed_at >= datetime('now', '-30 days')), 0),
+  COALESCE(AVG(CASE WHEN status IN ('approved', 'rejected') AND reviewed_at >= datetime('now', '-30 days')
+                    THEN (julianday(reviewed_at) - julianday(submitted_at)) * 24 END), 0)
+FROM users
+WHERE closed_at = '';
+`
+
+// GetReviewQueueStats summarizes the queue. Time in queue is measured from
+// submitted_at, so a resubmission restarts the clock; slaHours is the age
+// past which a pending registration counts as over SLA.
+func (q *Queries) GetReviewQueueStats(ctx context.Context, slaHours int64) (ReviewQueueStats, error) {
+	var i ReviewQueueStats
+	err := q.db.QueryRowContext(ctx, getReviewQueueStats, slaHours).Scan(
+		&i.Pending, &i.InfoRequested, &i.Unassigned, &i.OverSLA,
+		&i.OldestSubmittedAt, &i.AvgHoursInQueue, &i.Decided30d, &i.AvgHoursToDecision,
+	)
+	return i, err
+}
+
+type ReviewerWorkload struct {
+	ReviewerID int64  `json:"reviewer_id" db:"reviewer_id"`
+	Email      string `json:"email" db:"email"`
+	Assigned   int64  `json:"assigned" db:"assigned"`
+}
+
+const listReviewerWorkload = `
+SELECT u.assigned_reviewer_id, COALESCE(r.email, ''), COUNT(*)
+FROM users u
+LEFT JOIN users r ON r.id = u.assigned_reviewer_id
+WHERE u.assigned_reviewer_id != 0 AND u.status IN ('pending', 'info_requested') AND u.closed_at = ''
+GROUP BY u.assigned_reviewer_id
+ORDER BY COUNT(*) DESC;
+`
+
+func (q *Queries) ListReviewerWorkload(ctx context.Context) ([]ReviewerWorkload, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewerWorkload)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReviewerWorkload{}
+	for rows.Next() {
+		var i ReviewerWorkload
+		if err := rows.Scan(&i.ReviewerID, &i.Email, &i.Assigned); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,52 @@
+package db
+
+import "context"
+
+const listUserRoles = `
+SELECT role
+FROM user_roles
+WHERE user_id = ?
+ORDER BY role;
+`
+
+func (q *Queries) ListUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	return items, rows.Err()
+}
+
+type AddUserRoleParams struct {
+	UserID    int64
+	Role      string
+	GrantedBy int64
+}
+
+const addUserRole = `
+INSERT OR IGNORE INTO user_roles (user_id, role, granted_by)
+VALUES (?, ?, ?);
+`
+
+func (q *Queries) AddUserRole(ctx context.Context, arg AddUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, addUserRole, arg.UserID, arg.Role, arg.GrantedBy)
+	return err
+}
+
+const deleteUserRoles = `
+DELETE FROM user_roles WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteUserRoles(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRoles, userID)
+	return err
+}
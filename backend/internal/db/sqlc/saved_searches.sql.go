@@ -0,0 +1,337 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type SavedSearch struct {
+	ID        int64  `json:"id" db:"id"`
+	UserID    int64  `json:"user_id" db:"user_id"`
+	Name      string `json:"name" db:"name"`
+	ItemTypes string `json:"item_types" db:"item_types"`
+	// Query is the catalog query string as saved; Filter and MatchQuery are
+	// its parsed form.
+	Query       string        `json:"query" db:"query"`
+	Filter      CatalogFilter `json:"-" db:"filter_json"`
+	MatchQuery  string        `json:"-" db:"match_query"`
+	Notify      int64         `json:"notify" db:"notify"`
+	SeenMatchID int64         `json:"-" db:"seen_match_id"`
+	CreatedAt   string        `json:"created_at" db:"created_at"`
+	UpdatedAt   string        `json:"updated_at" db:"updated_at"`
+}
+
+const savedSearchColumns = `id, user_id, name, item_types, query, filter_json, match_query, notify, seen_match_id, created_at, updated_at`
+
+// savedSearchDests returns the scan targets for savedSearchColumns; filter_json
+// lands in raw for decodeFilter.
+func savedSearchDests(i *SavedSearch, raw *string) []any {
+	return []any{&i.ID, &i.UserID, &i.Name, &i.ItemTypes, &i.Query, raw, &i.MatchQuery, &i.Notify, &i.SeenMatchID, &i.CreatedAt, &i.UpdatedAt}
+}
+
+func scanSavedSearch(row interface{ Scan(dest ...any) error }, i *SavedSearch) error {
+	var raw string
+	if err := row.Scan(savedSearchDests(i, &raw)...); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(raw), &i.Filter)
+}
+
+// Types returns the item types the search covers.
+func (s SavedSearch) Types() []string {
+	if s.ItemTypes == "all" {
+		return []string{"listing", "auction"}
+	}
+	return []string{s.ItemTypes}
+}
+
+type CreateSavedSearchParams struct {
+	UserID     int64
+	Name       string
+	ItemTypes  string
+	Query      string
+	Filter     CatalogFilter
+	MatchQuery string
+	Notify     int64
+}
+
+const createSavedSearch = `
+INSERT INTO saved_searches (user_id, name, item_types, query, filter_json, match_query, notify)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + savedSearchColumns + `;
+`
+
+func (q *Queries) CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) (SavedSearch, error) {
+	filter, err := json.Marshal(arg.Filter)
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	var i SavedSearch
+	err = scanSavedSearch(q.db.QueryRowContext(ctx, createSavedSearch,
+		arg.UserID, arg.Name, arg.ItemTypes, arg.Query, string(filter), arg.MatchQuery, arg.Notify,
+	), &i)
+	return i, err
+}
+
+const getSavedSearch = `
+SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = ?;
+`
+
+func (q *Queries) GetSavedSearch(ctx context.Context, id int64) (SavedSearch, error) {
+	var i SavedSearch
+	err := scanSavedSearch(q.db.QueryRowContext(ctx, getSavedSearch, id), &i)
+	return i, err
+}
+
+const countSavedSearches = `
+SELECT COUNT(*) FROM saved_searches WHERE user_id = ?;
+`
+
+func (q *Queries) CountSavedSearches(ctx context.Context, userID int64) (int64, error) {
+	var n int64
+	err := q.db.QueryRowContext(ctx, countSavedSearches, userID).Scan(&n)
+	return n, err
+}
+
+// SavedSearchSummary is a saved search with how many items it has matched
+// since it was saved, and how many of those the user has not seen yet.
+type SavedSearchSummary struct {
+	SavedSearch
+	MatchCount int64 `json:"match_count"`
+	NewCount   int64 `json:"new_count"`
+}
+
+const listSavedSearches = `
+SELECT s.id, s.user_id, s.name, s.item_types, s.query, s.filter_json, s.match_query, s.notify, s.seen_match_id, s.created_at, s.updated_at,
+       (SELECT COUNT(*) FROM saved_search_matches m WHERE m.search_id = s.id AND m.baseline = 0),
+       (SELECT COUNT(*) FROM saved_search_matches m WHERE m.search_id = s.id AND m.baseline = 0 AND m.id > s.seen_match_id)
+FROM saved_searches s
+WHERE s.user_id = ?
+ORDER BY s.id;
+`
+
+func (q *Queries) ListSavedSearches(ctx context.Context, userID int64) ([]SavedSearchSummary, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedSearches, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SavedSearchSummary{}
+	for rows.Next() {
+		var i SavedSearchSummary
+		var raw string
+		if err := rows.Scan(append(savedSearchDests(&i.SavedSearch, &raw), &i.MatchCount, &i.NewCount)...); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &i.Filter); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type UpdateSavedSearchParams struct {
+	ID         int64
+	Name       string
+	ItemTypes  string
+	Query      string
+	Filter     CatalogFilter
+	MatchQuery string
+	Notify     int64
+}
+
+const updateSavedSearch = `
+UPDATE saved_searches
+SET name = ?, item_types = ?, query = ?, filter_json = ?, match_query = ?, notify = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING ` + savedSearchColumns + `;
+`
+
+func (q *Queries) UpdateSavedSearch(ctx context.Context, arg UpdateSavedSearchParams) (SavedSearch, error) {
+	filter, err := json.Marshal(arg.Filter)
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	var i SavedSearch
+	err = scanSavedSearch(q.db.QueryRowContext(ctx, updateSavedSearch,
+		arg.Name, arg.ItemTypes, arg.Query, string(filter), arg.MatchQuery, arg.Notify, arg.ID,
+	), &i)
+	return i, err
+}
+
+const deleteSavedSearch = `
+DELETE FROM saved_searches WHERE id = ?;
+`
+
+func (q *Queries) DeleteSavedSearch(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSavedSearch, id)
+	return err
+}
+
+const deleteUserSavedSearches = `
+DELETE FROM saved_searches WHERE user_id = ?;
+`
+
+// DeleteUserSavedSearches removes all of a user's saved searches; their
+// matches go with them through ON DELETE CASCADE.
+func (q *Queries) DeleteUserSavedSearches(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSavedSearches, userID)
+	return err
+}
+
+const resetSavedSearchMatches = `
+DELETE FROM saved_search_matches WHERE search_id = ?;
+`
+
+// ResetSavedSearchMatches forgets what a search has matched, for when its
+// query changes.
+func (q *Queries) ResetSavedSearchMatches(ctx context.Context, searchID int64) error {
+	_, err := q.db.ExecContext(ctx, resetSavedSearchMatches, searchID)
+	return err
+}
+
+const addSavedSearchMatch = `
+INSERT OR IGNORE INTO saved_search_matches (search_id, item_type, item_id, baseline) VALUES (?, ?, ?, ?);
+`
+
+// AddSavedSearchMatch records a match and reports whether it is new to the
+// search.
+func (q *Queries) AddSavedSearchMatch(ctx context.Context, searchID int64, itemType string, itemID, baseline int64) (bool, error) {
+	result, err := q.db.ExecContext(ctx, addSavedSearchMatch, searchID, itemType, itemID, baseline)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+type SavedSearchMatch struct {
+	ID        int64  `json:"id" db:"id"`
+	SearchID  int64  `json:"search_id" db:"search_id"`
+	ItemType  string `json:"item_type" db:"item_type"`
+	ItemID    int64  `json:"item_id" db:"item_id"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	// New is set for matches the user has not seen yet.
+	New bool `json:"new"`
+}
+
+const listSavedSearchMatches = `
+SELECT m.id, m.search_id, m.item_type, m.item_id, m.created_at, m.id > s.seen_match_id
+FROM saved_search_matches m
+JOIN saved_searches s ON s.id = m.search_id
+WHERE m.search_id = ?1 AND m.baseline = 0 AND (?2 = 0 OR m.id > s.seen_match_id)
+ORDER BY m.id DESC
+LIMIT ?3;
+`
+
+// ListSavedSearchMatches returns the matches found since a search was saved,
+// newest first; newOnly limits them to those not seen yet.
+func (q *Queries) ListSavedSearchMatches(ctx context.Context, searchID int64, newOnly bool, limit int64) ([]SavedSearchMatch, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedSearchMatches, searchID, newOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SavedSearchMatch{}
+	for rows.Next() {
+		var i SavedSearchMatch
+		if err := rows.Scan(&i.ID, &i.SearchID, &i.ItemType, &i.ItemID, &i.CreatedAt, &i.New); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const markSavedSearchSeen = `
+UPDATE saved_searches
+SET seen_match_id = (SELECT COALESCE(MAX(id), 0) FROM saved_search_matches WHERE search_id = saved_searches.id)
+WHERE id = ?;
+`
+
+func (q *Queries) MarkSavedSearchSeen(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markSavedSearchSeen, id)
+	return err
+}
+
+// SavedSearchOwner is a saved search with its owner's account state and
+// notification preferences, for the match job.
+type SavedSearchOwner struct {
+	SavedSearch
+	UserClosedAt string
+	Preferences  NotificationPreferences
+}
+
+const listSavedSearchesForMatching = `
+SELECT s.id, s.user_id, s.name, s.item_types, s.query, s.filter_json, s.match_query, s.notify, s.seen_match_id, s.created_at, s.updated_at,
+       u.closed_at,
+       COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM saved_searches s
+JOIN users u ON u.id = s.user_id
+LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+ORDER BY s.id;
+`
+
+func (q *Queries) ListSavedSearchesForMatching(ctx context.Context) ([]SavedSearchOwner, error) {
+	rows, err := q.db.QueryContext(ctx, listSavedSearchesForMatching)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SavedSearchOwner{}
+	for rows.Next() {
+		var i SavedSearchOwner
+		var raw string
+		dest := append(savedSearchDests(&i.SavedSearch, &raw), &i.UserClosedAt)
+		if err := rows.Scan(append(dest, preferenceDests(&i.Preferences)...)...); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &i.Filter); err != nil {
+			return nil, err
+		}
+		i.Preferences.UserID = i.UserID
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type CatalogChange struct {
+	ID       int64
+	ItemType string
+	ItemID   int64
+}
+
+const listCatalogChanges = `
+SELECT id, item_type, item_id FROM catalog_changes ORDER BY id LIMIT ?;
+`
+
+// ListCatalogChanges returns the oldest queued catalog changes.
+func (q *Queries) ListCatalogChanges(ctx context.Context, limit int64) ([]CatalogChange, error) {
+	rows, err := q.db.QueryContext(ctx, listCatalogChanges, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CatalogChange{}
+	for rows.Next() {
+		var i CatalogChange
+		if err := rows.Scan(&i.ID, &i.ItemType, &i.ItemID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const deleteCatalogChanges = `
+DELETE FROM catalog_changes WHERE id <= ?;
+`
+
+// DeleteCatalogChanges dequeues changes up to and including maxID.
+func (q *Queries) DeleteCatalogChanges(ctx context.Context, maxID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCatalogChanges, maxID)
+	return err
+}
@@ -0,0 +1,83 @@
+package db
+
+import "context"
+
+// SearchHit is one match in search_index. Score is the weighted BM25 score,
+// higher is better; the snippets wrap matched terms in SearchMarkStart and
+// SearchMarkEnd so the caller can escape the text before adding markup.
+type SearchHit struct {
+	RowID              int64
+	Score              float64
+	TitleSnippet       string
+	DescriptionSnippet string
+}
+
+// Snippet markers: control characters that never occur in item text.
+const (
+	SearchMarkStart = "\x02"
+	SearchMarkEnd   = "\x03"
+)
+
+// Kind and ItemID decode the rowid: listing N is 2N, auction N is 2N+1.
+func (h SearchHit) Kind() string {
+	if h.RowID%2 == 0 {
+		return "listing"
+	}
+	return "auction"
+}
+
+func (h SearchHit) ItemID() int64 { return h.RowID / 2 }
+
+const searchIndexWhere = `
+FROM search_index
+WHERE search_index MATCH ?1
+  AND ((rowid % 2 = 0 AND ?2 IN ('', 'listing')
+        AND EXISTS (SELECT 1 FROM listings l WHERE l.id = search_index.rowid / 2 AND l.status = 'active'))
+    OR (rowid % 2 = 1 AND ?2 IN ('', 'auction')
+        AND EXISTS (SELECT 1 FROM auctions a
+                    WHERE a.id = search_index.rowid / 2 AND a.status = 'active'
+                      AND (a.visibility = 'public'
+                           OR (a.visibility = 'invite_only'
+                               AND EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = ?3))))))`
+
+// The bm25 weights follow the column order: title, description, location,
+// categories. bm25 is lower for better matches, so the score negates it.
+const searchIndex = `
+SELECT rowid,
+       -bm25(search_index, 4.0, 1.0, 1.5, 2.0) AS score,
+       snippet(search_index, 0, char(2), char(3), '…', 64),
+       snippet(search_index, 1, char(2), char(3), '…', 24)
+` + searchIndexWhere + `
+ORDER BY score DESC, rowid
+LIMIT ?4;
+`
+
+// SearchIndex returns the best-ranked active listings and auctions matching
+// an FTS query that viewerID may see. kind is "", "listing" or "auction".
+func (q *Queries) SearchIndex(ctx context.Context, match, kind string, viewerID, limit int64) ([]SearchHit, error) {
+	rows, err := q.db.QueryContext(ctx, searchIndex, match, kind, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchHit{}
+	for rows.Next() {
+		var i SearchHit
+		if err := rows.Scan(&i.RowID, &i.Score, &i.TitleSnippet, &i.DescriptionSnippet); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const countSearchIndex = `
+SELECT COUNT(*)` + searchIndexWhere + `;
+`
+
+// CountSearchIndex counts every match SearchIndex would return without a limit.
+func (q *Queries) CountSearchIndex(ctx context.Context, match, kind string, viewerID int64) (int64, error) {
+	var n int64
+	err := q.db.QueryRowContext(ctx, countSearchIndex, match, kind, viewerID).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,308 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+type SpecAttribute struct {
+	ID         int64    `json:"id" db:"id"`
+	CategoryID int64    `json:"category_id" db:"category_id"`
+	Key        string   `json:"key" db:"key"`
+	Label      string   `json:"label" db:"label"`
+	Type       string   `json:"type" db:"type"`
+	Unit       string   `json:"unit" db:"unit"`
+	Options    []string `json:"options" db:"options"`
+	MinValue   float64  `json:"min_value" db:"min_value"`
+	MaxValue   float64  `json:"max_value" db:"max_value"`
+	Required   int64    `json:"required" db:"required"`
+	Position   int64    `json:"position" db:"position"`
+}
+
+const specAttributeColumns = `id, category_id, key, label, type, unit, options, min_value, max_value, required, position`
+
+func scanSpecAttribute(row interface{ Scan(dest ...any) error }, i *SpecAttribute) error {
+	var options string
+	if err := row.Scan(
+		&i.ID, &i.CategoryID, &i.Key, &i.Label, &i.Type, &i.Unit,
+		&options, &i.MinValue, &i.MaxValue, &i.Required, &i.Position,
+	); err != nil {
+		return err
+	}
+	i.Options = []string{}
+	return json.Unmarshal([]byte(options), &i.Options)
+}
+
+func (q *Queries) listSpecAttributes(ctx context.Context, query string, args ...any) ([]SpecAttribute, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SpecAttribute{}
+	for rows.Next() {
+		var i SpecAttribute
+		if err := scanSpecAttribute(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const listSpecAttributes = `
+SELECT ` + specAttributeColumns + ` FROM spec_attributes ORDER BY category_id, position, label;
+`
+
+func (q *Queries) ListSpecAttributes(ctx context.Context) ([]SpecAttribute, error) {
+	return q.listSpecAttributes(ctx, listSpecAttributes)
+}
+
+const getSpecAttribute = `
+SELECT ` + specAttributeColumns + ` FROM spec_attributes WHERE id = ?;
+`
+
+func (q *Queries) GetSpecAttribute(ctx context.Context, id int64) (SpecAttribute, error) {
+	var i SpecAttribute
+	err := scanSpecAttribute(q.db.QueryRowContext(ctx, getSpecAttribute, id), &i)
+	return i, err
+}
+
+const listSpecAttributesForCategory = categorySubtree + `
+SELECT ` + specAttributeColumns + `
+FROM spec_attributes
+WHERE category_id = 0 OR category_id IN (SELECT root_id FROM subtree WHERE id = ?)
+ORDER BY category_id != 0, position, label;
+`
+
+// ListSpecAttributesForCategory returns the attributes that apply to items in
+// a category: global ones plus those of the category and its ancestors.
+func (q *Queries) ListSpecAttributesForCategory(ctx context.Context, categoryID int64) ([]SpecAttribute, error) {
+	return q.listSpecAttributes(ctx, listSpecAttributesForCategory, categoryID)
+}
+
+const listSpecAttributesForListing = categorySubtree + `
+SELECT ` + specAttributeColumns + `
+FROM spec_attributes
+WHERE category_id = 0
+   OR category_id IN (SELECT s.root_id FROM subtree s JOIN listing_categories lc ON lc.category_id = s.id WHERE lc.listing_id = ?)
+ORDER BY category_id != 0, position, label;
+`
+
+// ListSpecAttributesForListing returns the attributes that apply to a listing
+// through its categories.
+func (q *Queries) ListSpecAttributesForListing(ctx context.Context, listingID int64) ([]SpecAttribute, error) {
+	return q.listSpecAttributes(ctx, listSpecAttributesForListing, listingID)
+}
+
+const listSpecAttributesForAuction = categorySubtree + `
+SELECT ` + specAttributeColumns + `
+FROM spec_attributes
+WHERE category_id = 0
+   OR category_id IN (SELECT s.root_id FROM subtree s JOIN auction_categories ac ON ac.category_id = s.id WHERE ac.auction_id = ?)
+ORDER BY category_id != 0, position, label;
+`
+
+// ListSpecAttributesForAuction returns the attributes that apply to an
+// auction through its categories.
+func (q *Queries) ListSpecAttributesForAuction(ctx context.Context, auctionID int64) ([]SpecAttribute, error) {
+	return q.listSpecAttributes(ctx, listSpecAttributesForAuction, auctionID)
+}
+
+const specKeyInUse = categorySubtree + `
+SELECT EXISTS (
+  SELECT 1 FROM spec_attributes
+  WHERE key = ?1
+    AND (?2 = 0 OR category_id = 0
+         OR category_id IN (SELECT root_id FROM subtree WHERE id = ?2)
+         OR category_id IN (SELECT id FROM subtree WHERE root_id = ?2))
+);
+`
+
+// SpecKeyInUse reports whether key is already defined somewhere it would
+// overlap with a new attribute in categoryID: globally, on an ancestor or on
+// a descendant. For a global attribute (categoryID 0) any use overlaps.
+func (q *Queries) SpecKeyInUse(ctx context.Context, categoryID int64, key string) (bool, error) {
+	var ok bool
+	err := q.db.QueryRowContext(ctx, specKeyInUse, key, categoryID).Scan(&ok)
+	return ok, err
+}
+
+type CreateSpecAttributeParams struct {
+	CategoryID int64
+	Key        string
+	Label      string
+	Type       string
+	Unit       string
+	Options    []string
+	MinValue   float64
+	MaxValue   float64
+	Required   int64
+	Position   int64
+}
+
+const createSpecAttribute = `
+INSERT INTO spec_attributes (category_id, key, label, type, unit, options, min_value, max_value, required, position)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING ` + specAttributeColumns + `;
+`
+
+func (q *Queries) CreateSpecAttribute(ctx context.Context, arg CreateSpecAttributeParams) (SpecAttribute, error) {
+	options, err := json.Marshal(nonNilStrings(arg.Options))
+	if err != nil {
+		return SpecAttribute{}, err
+	}
+	var i SpecAttribute
+	err = scanSpecAttribute(q.db.QueryRowContext(ctx, createSpecAttribute,
+		arg.CategoryID, arg.Key, arg.Label, arg.Type, arg.Unit, string(options),
+		arg.MinValue, arg.MaxValue, arg.Required, arg.Position,
+	), &i)
+	return i, err
+}
+
+// UpdateSpecAttributeParams leaves out category, key and type: stored values
+// depend on them, so those are fixed once an attribute exists.
+type UpdateSpecAttributeParams struct {
+	ID       int64
+	Label    string
+	Unit     string
+	Options  []string
+	MinValue float64
+	MaxValue float64
+	Required int64
+	Position int64
+}
+
+const updateSpecAttribute = `
+UPDATE spec_attributes
+SET label = ?, unit = ?, options = ?, min_value = ?, max_value = ?, required = ?, position = ?
+WHERE id = ?
+RETURNING ` + specAttributeColumns + `;
+`
+
+func (q *Queries) UpdateSpecAttribute(ctx context.Context, arg UpdateSpecAttributeParams) (SpecAttribute, error) {
+	options, err := json.Marshal(nonNilStrings(arg.Options))
+	if err != nil {
+		return SpecAttribute{}, err
+	}
+	var i SpecAttribute
+	err = scanSpecAttribute(q.db.QueryRowContext(ctx, updateSpecAttribute,
+		arg.Label, arg.Unit, string(options), arg.MinValue, arg.MaxValue, arg.Required, arg.Position, arg.ID,
+	), &i)
+	return i, err
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+const deleteSpecAttribute = `
+DELETE FROM spec_attributes WHERE id = ?;
+`
+
+// DeleteSpecAttribute removes an attribute and every value stored for it.
+func (q *Queries) DeleteSpecAttribute(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSpecAttribute, id)
+	return err
+}
+
+// SpecValue is one specification of a listing or auction. Value is a float64
+// for number attributes and a string otherwise.
+type SpecValue struct {
+	AttributeID int64  `json:"attribute_id"`
+	Key         string `json:"key"`
+	Label       string `json:"label"`
+	Type        string `json:"type"`
+	Unit        string `json:"unit"`
+	Value       any    `json:"value"`
+}
+
+func (q *Queries) listSpecValues(ctx context.Context, query string, id int64) ([]SpecValue, error) {
+	rows, err := q.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SpecValue{}
+	for rows.Next() {
+		var i SpecValue
+		var value string
+		if err := rows.Scan(&i.AttributeID, &i.Key, &i.Label, &i.Type, &i.Unit, &value); err != nil {
+			return nil, err
+		}
+		i.Value = value
+		if i.Type == "number" {
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				i.Value = n
+			}
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+const listListingSpecs = `
+SELECT a.id, a.key, a.label, a.type, a.unit, v.value
+FROM listing_specs v
+JOIN spec_attributes a ON a.id = v.attribute_id
+WHERE v.listing_id = ?
+ORDER BY a.category_id != 0, a.position, a.label;
+`
+
+func (q *Queries) ListListingSpecs(ctx context.Context, listingID int64) ([]SpecValue, error) {
+	return q.listSpecValues(ctx, listListingSpecs, listingID)
+}
+
+const listAuctionSpecs = `
+SELECT a.id, a.key, a.label, a.type, a.unit, v.value
+FROM auction_specs v
+JOIN spec_attributes a ON a.id = v.attribute_id
+WHERE v.auction_id = ?
+ORDER BY a.category_id != 0, a.position, a.label;
+`
+
+func (q *Queries) ListAuctionSpecs(ctx context.Context, auctionID int64) ([]SpecValue, error) {
+	return q.listSpecValues(ctx, listAuctionSpecs, auctionID)
+}
+
+const clearListingSpecs = `
+DELETE FROM listing_specs WHERE listing_id = ?;
+`
+
+func (q *Queries) ClearListingSpecs(ctx context.Context, listingID int64) error {
+	_, err := q.db.ExecContext(ctx, clearListingSpecs, listingID)
+	return err
+}
+
+const setListingSpec = `
+INSERT INTO listing_specs (listing_id, attribute_id, value) VALUES (?, ?, ?)
+ON CONFLICT (listing_id, attribute_id) DO UPDATE SET value = excluded.value;
+`
+
+func (q *Queries) SetListingSpec(ctx context.Context, listingID, attributeID int64, value string) error {
+	_, err := q.db.ExecContext(ctx, setListingSpec, listingID, attributeID, value)
+	return err
+}
+
+const clearAuctionSpecs = `
+DELETE FROM auction_specs WHERE auction_id = ?;
+`
+
+func (q *Queries) ClearAuctionSpecs(ctx context.Context, auctionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearAuctionSpecs, auctionID)
+	return err
+}
+
+const setAuctionSpec = `
+INSERT INTO auction_specs (auction_id, attribute_id, value) VALUES (?, ?, ?)
+ON CONFLICT (auction_id, attribute_id) DO UPDATE SET value = excluded.value;
+`
+
+func (q *Queries) SetAuctionSpec(ctx context.Context, auctionID, attributeID int64, value string) error {
+	_, err := q.db.ExecContext(ctx, setAuctionSpec, auctionID, attributeID, value)
+	return err
+}
@@ -0,0 +1,117 @@
+package db
+
+import "context"
+
+type UserStatusChange struct {
+	ID            int64  `json:"id" db:"id"`
+	UserID        int64  `json:"user_id" db:"user_id"`
+	FromStatus    string `json:"from_status" db:"from_status"`
+	ToStatus      string `json:"to_status" db:"to_status"`
+	Reason        string `json:"reason" db:"reason"`
+	ActorUserID   int64  `json:"actor_user_id" db:"actor_user_id"`
+	ActorAPIKeyID int64  `json:"actor_api_key_id" db:"actor_api_key_id"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
+}
+
+const suspendUser = `
+UPDATE users
+SET status = 'suspended', suspension_reason = ?, suspended_until = ?,
+    suspended_at = CASE WHEN status = 'suspended' THEN suspended_at ELSE datetime('now') END
+WHERE id = ? AND status IN ('approved', 'suspended')
+RETURNING ` + userColumns + `;
+`
+
+// SuspendUser suspends an approved account, or updates the reason and end of
+// an existing suspension. until is RFC3339, or empty for no end date.
+func (q *Queries) SuspendUser(ctx context.Context, reason, until string, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, reason, until, id)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const reinstateUser = `
+UPDATE users
+SET status = 'approved', suspension_reason = '', suspended_at = '', suspended_until = ''
+WHERE id = ? AND status = 'suspended'
+RETURNING ` + userColumns + `;
+`
+
+func (q *Queries) ReinstateUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, reinstateUser, id)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const reinstateExpiredSuspensions = `
+UPDATE users
+SET status = 'approved', suspension_reason = '', suspended_at = '', suspended_until = ''
+WHERE status = 'suspended' AND suspended_until != '' AND datetime(suspended_until) <= datetime('now')
+RETURNING ` + userColumns + `;
+`
+
+// ReinstateExpiredSuspensions lifts every suspension whose end date has
+// passed and returns the reinstated users.
+func (q *Queries) ReinstateExpiredSuspensions(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, reinstateExpiredSuspensions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := scanUser(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type CreateStatusChangeParams struct {
+	UserID        int64
+	FromStatus    string
+	ToStatus      string
+	Reason        string
+	ActorUserID   int64
+	ActorAPIKeyID int64
+}
+
+const createStatusChange = `
+INSERT INTO user_status_history (user_id, from_status, to_status, reason, actor_user_id, actor_api_key_id)
+VALUES (?, ?, ?, ?, ?, ?);
+`
+
+func (q *Queries) CreateStatusChange(ctx context.Context, arg CreateStatusChangeParams) error {
+	_, err := q.db.ExecContext(ctx, createStatusChange,
+		arg.UserID, arg.FromStatus, arg.ToStatus, arg.Reason, arg.ActorUserID, arg.ActorAPIKeyID,
+	)
+	return err
+}
+
+const listStatusHistory = `
+SELECT id, user_id, from_status, to_status, reason, actor_user_id, actor_api_key_id, created_at
+FROM user_status_history
+WHERE user_id = ?
+ORDER BY id;
+`
+
+func (q *Queries) ListStatusHistory(ctx context.Context, userID int64) ([]UserStatusChange, error) {
+	rows, err := q.db.QueryContext(ctx, listStatusHistory, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserStatusChange{}
+	for rows.Next() {
+		var i UserStatusChange
+		if err := rows.Scan(&i.ID, &i.UserID, &i.FromStatus, &i.ToStatus, &i.Reason,
+			&i.ActorUserID, &i.ActorAPIKeyID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
@@ -2,10 +2,26 @@ package db
 
 import "context"
 
+const userColumns = `id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state,
+       phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at,
+       closed_at, info_request, assigned_reviewer_id, assigned_at, submitted_at, reviewed_at,
+       suspension_reason, suspended_at, suspended_until`
+
+func scanUser(row interface{ Scan(dest ...any) error }, i *User) error {
+	return row.Scan(
+		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
+		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
+		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
+		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
+		&i.ClosedAt, &i.InfoRequest, &i.AssignedReviewerID, &i.AssignedAt, &i.SubmittedAt, &i.ReviewedAt,
+		&i.SuspensionReason, &i.SuspendedAt, &i.SuspendedUntil,
+	)
+}
+
 const createUser = `
 INSERT INTO users (email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile)
 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+RETURNING ` + userColumns + `;
 `
 
 type CreateUserParams struct {
@@ -31,17 +47,12 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.PostalCode, arg.City, arg.State, arg.Phone, arg.Mobile,
 	)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
 const getUserByEmail = `
-SELECT id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at
+SELECT ` + userColumns + `
 FROM users
 WHERE email = ?;
 `
@@ -49,17 +60,12 @@ WHERE email = ?;
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
 const getUserByID = `
-SELECT id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at
+SELECT ` + userColumns + `
 FROM users
 WHERE id = ?;
 `
@@ -67,17 +73,12 @@ WHERE id = ?;
 func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserByID, id)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
 const listUsers = `
-SELECT id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at
+SELECT ` + userColumns + `
 FROM users
 ORDER BY created_at DESC
 LIMIT ?;
@@ -92,12 +93,7 @@ func (q *Queries) ListUsers(ctx context.Context, limit int64) ([]User, error) {
 	var items []User
 	for rows.Next() {
 		var i User
-		if err := rows.Scan(
-			&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-			&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-			&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-			&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-		); err != nil {
+		if err := scanUser(rows, &i); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -106,7 +102,7 @@ func (q *Queries) ListUsers(ctx context.Context, limit int64) ([]User, error) {
 }
 
 const listUsersByStatus = `
-SELECT id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at
+SELECT ` + userColumns + `
 FROM users
 WHERE status = ?
 ORDER BY created_at DESC
@@ -122,12 +118,7 @@ func (q *Queries) ListUsersByStatus(ctx context.Context, status string, limit in
 	var items []User
 	for rows.Next() {
 		var i User
-		if err := rows.Scan(
-			&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-			&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-			&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-			&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-		); err != nil {
+		if err := scanUser(rows, &i); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -137,39 +128,29 @@ func (q *Queries) ListUsersByStatus(ctx context.Context, status string, limit in
 
 const approveUser = `
 UPDATE users
-SET status = 'approved', guarantee_tier = ?, remaining_opportunities = 5
+SET status = 'approved', guarantee_tier = ?, remaining_opportunities = 5, info_request = '', reviewed_at = datetime('now')
 WHERE id = ?
-RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+RETURNING ` + userColumns + `;
 `
 
 func (q *Queries) ApproveUser(ctx context.Context, guaranteeTier string, id int64) (User, error) {
 	row := q.db.QueryRowContext(ctx, approveUser, guaranteeTier, id)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
 const rejectUser = `
 UPDATE users
-SET status = 'rejected', rejection_reason = ?
+SET status = 'rejected', rejection_reason = ?, info_request = '', reviewed_at = datetime('now')
 WHERE id = ?
-RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+RETURNING ` + userColumns + `;
 `
 
 func (q *Queries) RejectUser(ctx context.Context, reason string, id int64) (User, error) {
 	row := q.db.QueryRowContext(ctx, rejectUser, reason, id)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
@@ -177,7 +158,7 @@ const updateUserPassword = `
 UPDATE users
 SET password_hash = ?, must_change_password = ?
 WHERE id = ?
-RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+RETURNING ` + userColumns + `;
 `
 
 type UpdateUserPasswordParams struct {
@@ -189,12 +170,7 @@ type UpdateUserPasswordParams struct {
 func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.PasswordHash, arg.MustChangePassword, arg.ID)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
@@ -202,7 +178,7 @@ const updateUserAdmin = `
 UPDATE users
 SET is_admin = ?
 WHERE id = ?
-RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+RETURNING ` + userColumns + `;
 `
 
 type UpdateUserAdminParams struct {
@@ -213,12 +189,7 @@ type UpdateUserAdminParams struct {
 func (q *Queries) UpdateUserAdmin(ctx context.Context, arg UpdateUserAdminParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUserAdmin, arg.IsAdmin, arg.ID)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
-	)
+	err := scanUser(row, &i)
 	return i, err
 }
 
@@ -237,17 +208,86 @@ const setUserOpportunities = `
 UPDATE users
 SET remaining_opportunities = ?
 WHERE id = ?
-RETURNING id, email, password_hash, business_name, legal_representative, rfc, street_address, colony, municipality, postal_code, city, state, phone, mobile, status, guarantee_tier, remaining_opportunities, rejection_reason, must_change_password, is_admin, created_at;
+RETURNING ` + userColumns + `;
 `
 
 func (q *Queries) SetUserOpportunities(ctx context.Context, arg SetUserOpportunitiesParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, setUserOpportunities, arg.RemainingOpportunities, arg.ID)
 	var i User
-	err := row.Scan(
-		&i.ID, &i.Email, &i.PasswordHash, &i.BusinessName, &i.LegalRepresentative,
-		&i.RFC, &i.StreetAddress, &i.Colony, &i.Municipality,
-		&i.PostalCode, &i.City, &i.State, &i.Phone, &i.Mobile,
-		&i.Status, &i.GuaranteeTier, &i.RemainingOpportunities, &i.RejectionReason, &i.MustChangePassword, &i.IsAdmin, &i.CreatedAt,
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const updateUserProfile = `
+UPDATE users
+SET business_name = ?, legal_representative = ?, rfc = ?, street_address = ?, colony = ?, municipality = ?, postal_code = ?, city = ?, state = ?, phone = ?, mobile = ?
+WHERE id = ?
+RETURNING ` + userColumns + `;
+`
+
+type UpdateUserProfileParams struct {
+	BusinessName        string
+	LegalRepresentative string
+	RFC                 string
+	StreetAddress       string
+	Colony              string
+	Municipality        string
+	PostalCode          string
+	City                string
+	State               string
+	Phone               string
+	Mobile              string
+	ID                  int64
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.BusinessName, arg.LegalRepresentative, arg.RFC, arg.StreetAddress,
+		arg.Colony, arg.Municipality, arg.PostalCode, arg.City, arg.State,
+		arg.Phone, arg.Mobile, arg.ID,
 	)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+const updateUserFiscalIdentity = `
+UPDATE users
+SET business_name = ?, legal_representative = ?, rfc = ?
+WHERE id = ?
+RETURNING ` + userColumns + `;
+`
+
+type UpdateUserFiscalIdentityParams struct {
+	BusinessName        string
+	LegalRepresentative string
+	RFC                 string
+	ID                  int64
+}
+
+func (q *Queries) UpdateUserFiscalIdentity(ctx context.Context, arg UpdateUserFiscalIdentityParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserFiscalIdentity, arg.BusinessName, arg.LegalRepresentative, arg.RFC, arg.ID)
+	var i User
+	err := scanUser(row, &i)
+	return i, err
+}
+
+// anonymizeUser keeps the row, RFC and business name, which back the bid and
+// settlement records we must retain for accounting, and clears everything
+// else that identifies or contacts the person. The password hash is replaced
+// with a value bcrypt can never match.
+const anonymizeUser = `
+UPDATE users
+SET email = ?, password_hash = '!', legal_representative = '', street_address = '', colony = '',
+    municipality = '', postal_code = '', city = '', state = '', phone = '', mobile = '',
+    is_admin = 0, must_change_password = 0, closed_at = datetime('now')
+WHERE id = ?
+RETURNING ` + userColumns + `;
+`
+
+func (q *Queries) AnonymizeUser(ctx context.Context, email string, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, anonymizeUser, email, id)
+	var i User
+	err := scanUser(row, &i)
 	return i, err
 }
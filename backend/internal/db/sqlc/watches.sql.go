@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+)
+
+type AuctionWatch struct {
+	ID           int64  `json:"id" db:"id"`
+	UserID       int64  `json:"user_id" db:"user_id"`
+	AuctionID    int64  `json:"auction_id" db:"auction_id"`
+	LeadMinutes  int64  `json:"lead_minutes" db:"lead_minutes"`
+	AlertedBid   int64  `json:"-" db:"alerted_bid"`
+	StartingSent int64  `json:"-" db:"starting_sent"`
+	EndingSent   int64  `json:"-" db:"ending_sent"`
+	ResultSent   int64  `json:"-" db:"result_sent"`
+	CreatedAt    string `json:"created_at" db:"created_at"`
+}
+
+const auctionWatchColumns = `id, user_id, auction_id, lead_minutes, alerted_bid, starting_sent, ending_sent, result_sent, created_at`
+
+func scanAuctionWatch(row interface{ Scan(dest ...any) error }, i *AuctionWatch) error {
+	return row.Scan(&i.ID, &i.UserID, &i.AuctionID, &i.LeadMinutes, &i.AlertedBid, &i.StartingSent, &i.EndingSent, &i.ResultSent, &i.CreatedAt)
+}
+
+// Watching an auction already watched only changes the lead time. The bid
+// at the time of watching is the baseline for bid alerts.
+const watchAuction = `
+INSERT INTO auction_watches (user_id, auction_id, lead_minutes, alerted_bid)
+SELECT ?1, a.id, ?3, a.current_bid FROM auctions a WHERE a.id = ?2
+ON CONFLICT (user_id, auction_id) DO UPDATE SET lead_minutes = excluded.lead_minutes
+RETURNING ` + auctionWatchColumns + `;
+`
+
+// WatchAuction returns sql.ErrNoRows if the auction does not exist.
+func (q *Queries) WatchAuction(ctx context.Context, userID, auctionID, leadMinutes int64) (AuctionWatch, error) {
+	var i AuctionWatch
+	err := scanAuctionWatch(q.db.QueryRowContext(ctx, watchAuction, userID, auctionID, leadMinutes), &i)
+	return i, err
+}
+
+const getAuctionWatch = `
+SELECT ` + auctionWatchColumns + ` FROM auction_watches WHERE user_id = ? AND auction_id = ?;
+`
+
+func (q *Queries) GetAuctionWatch(ctx context.Context, userID, auctionID int64) (AuctionWatch, error) {
+	var i AuctionWatch
+	err := scanAuctionWatch(q.db.QueryRowContext(ctx, getAuctionWatch, userID, auctionID), &i)
+	return i, err
+}
+
+const unwatchAuction = `
+DELETE FROM auction_watches WHERE user_id = ? AND auction_id = ?;
+`
+
+func (q *Queries) UnwatchAuction(ctx context.Context, userID, auctionID int64) error {
+	_, err := q.db.ExecContext(ctx, unwatchAuction, userID, auctionID)
+	return err
+}
+
+// WatchedAuction is an entry of a user's watchlist. SecondsToStart and
+// SecondsRemaining count down to start_time and end_time, and stop at 0 (also
+// for times that do not parse).
+type WatchedAuction struct {
+	AuctionWatch
+	Title            string `json:"title"`
+	ImageURL         string `json:"image_url"`
+	Status           string `json:"status"`
+	CurrentBid       int64  `json:"current_bid"`
+	BidCount         int64  `json:"bid_count"`
+	Leading          bool   `json:"leading"`
+	StartTime        string `json:"start_time"`
+	EndTime          string `json:"end_time"`
+	SecondsToStart   int64  `json:"seconds_to_start"`
+	SecondsRemaining int64  `json:"seconds_remaining"`
+}
+
+const listUserWatches = `
+SELECT w.id, w.user_id, w.auction_id, w.lead_minutes, w.alerted_bid, w.starting_sent, w.ending_sent, w.result_sent, w.created_at,
+       a.title, a.image_url, a.status, a.current_bid,
+       (SELECT COUNT(*) FROM bids b WHERE b.auction_id = a.id),
+       a.highest_bidder_id = w.user_id,
+       a.start_time, a.end_time,
+       COALESCE(MAX(0, CAST(ROUND((julianday(a.start_time) - julianday('now')) * 86400) AS INTEGER)), 0),
+       COALESCE(MAX(0, CAST(ROUND((julianday(a.end_time) - julianday('now')) * 86400) AS INTEGER)), 0)
+FROM auction_watches w
+JOIN auctions a ON a.id = w.auction_id
+WHERE w.user_id = ?
+  AND (a.visibility != 'invite_only'
+       OR EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = w.user_id))
+ORDER BY CASE a.status WHEN 'active' THEN 0 WHEN 'scheduled' THEN 1 ELSE 2 END, a.end_time, w.id;
+`
+
+// ListUserWatches returns live auctions first, soonest to end first, then
+// scheduled ones and finally those that ended. Invite-only auctions the user
+// is no longer invited to are left out.
+func (q *Queries) ListUserWatches(ctx context.Context, userID int64) ([]WatchedAuction, error) {
+	rows, err := q.db.QueryContext(ctx, listUserWatches, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WatchedAuction{}
+	for rows.Next() {
+		var i WatchedAuction
+		if err := rows.Scan(
+			&i.ID, &i.UserID, &i.AuctionID, &i.LeadMinutes, &i.AlertedBid, &i.StartingSent, &i.EndingSent, &i.ResultSent, &i.CreatedAt,
+			&i.Title, &i.ImageURL, &i.Status, &i.CurrentBid, &i.BidCount, &i.Leading,
+			&i.StartTime, &i.EndTime, &i.SecondsToStart, &i.SecondsRemaining,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// WatchChange is a watch with an alert due: the auction is about to start or
+// end, has new bids, or ended. StartingDue and EndingDue say whether the
+// auction is within the watch's lead time of its start or end; a time that
+// does not parse is never due. Watches on invite-only auctions whose invite
+// was revoked never have alerts due.
+type WatchChange struct {
+	AuctionWatch
+	UserClosedAt    string
+	Title           string
+	Status          string
+	CurrentBid      int64
+	HighestBidderID int64
+	Sold            bool
+	StartingDue     bool
+	EndingDue       bool
+	MinutesLeft     int64
+	Preferences     NotificationPreferences
+}
+
+const listWatchChanges = `
+SELECT w.id, w.user_id, w.auction_id, w.lead_minutes, w.alerted_bid, w.starting_sent, w.ending_sent, w.result_sent, w.created_at,
+       u.closed_at, a.title, a.status, a.current_bid, a.highest_bidder_id,
+       a.status = 'sold' OR (a.highest_bidder_id != 0 AND (a.reserve_price = 0 OR a.current_bid >= a.reserve_price)),
+       COALESCE(a.status = 'scheduled'
+         AND datetime(a.start_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'), 0),
+       COALESCE(a.status = 'active'
+         AND datetime(a.end_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'), 0),
+       COALESCE(MAX(0, CAST(ROUND((julianday(CASE a.status WHEN 'scheduled' THEN a.start_time ELSE a.end_time END)
+         - julianday('now')) * 1440) AS INTEGER)), 0),
+       COALESCE(p.price_drops, 1), COALESCE(p.status_changes, 1), COALESCE(p.email_mode, 'instant'),
+       COALESCE(p.websocket, 1), COALESCE(p.auction_starting, 1), COALESCE(p.auction_ending, 1),
+       COALESCE(p.auction_bids, 1), COALESCE(p.auction_results, 1), COALESCE(p.last_digest_at, '')
+FROM auction_watches w
+JOIN auctions a ON a.id = w.auction_id
+JOIN users u ON u.id = w.user_id
+LEFT JOIN notification_preferences p ON p.user_id = w.user_id
+WHERE (a.visibility != 'invite_only'
+       OR EXISTS (SELECT 1 FROM auction_invites ai WHERE ai.auction_id = a.id AND ai.user_id = w.user_id))
+  AND ((w.starting_sent = 0 AND a.status != 'scheduled')
+    OR (w.starting_sent = 0 AND a.start_time != '' AND datetime(a.start_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'))
+    OR (w.ending_sent = 0 AND a.status IN ('closed', 'sold'))
+    OR (w.ending_sent = 0 AND a.status = 'active' AND a.end_time != '' AND datetime(a.end_time) <= datetime('now', '+' || w.lead_minutes || ' minutes'))
+    OR (w.result_sent = 0 AND a.status IN ('closed', 'sold'))
+    OR a.current_bid != w.alerted_bid)
+ORDER BY w.id;
+`
+
+func (q *Queries) ListWatchChanges(ctx context.Context) ([]WatchChange, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchChanges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WatchChange{}
+	for rows.Next() {
+		var i WatchChange
+		dest := []any{
+			&i.ID, &i.UserID, &i.AuctionID, &i.LeadMinutes, &i.AlertedBid, &i.StartingSent, &i.EndingSent, &i.ResultSent, &i.CreatedAt,
+			&i.UserClosedAt, &i.Title, &i.Status, &i.CurrentBid, &i.HighestBidderID,
+			&i.Sold, &i.StartingDue, &i.EndingDue, &i.MinutesLeft,
+		}
+		if err := rows.Scan(append(dest, preferenceDests(&i.Preferences)...)...); err != nil {
+			return nil, err
+		}
+		i.Preferences.UserID = i.UserID
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+type UpdateWatchAlertedParams struct {
+	ID           int64
+	AlertedBid   int64
+	StartingSent int64
+	EndingSent   int64
+	ResultSent   int64
+}
+
+const updateWatchAlerted = `
+UPDATE auction_watches SET alerted_bid = ?, starting_sent = ?, ending_sent = ?, result_sent = ? WHERE id = ?;
+`
+
+func (q *Queries) UpdateWatchAlerted(ctx context.Context, arg UpdateWatchAlertedParams) error {
+	_, err := q.db.ExecContext(ctx, updateWatchAlerted, arg.AlertedBid, arg.StartingSent, arg.EndingSent, arg.ResultSent, arg.ID)
+	return err
+}
+
+const deleteUserWatches = `
+DELETE FROM auction_watches WHERE user_id = ?;
+`
+
+func (q *Queries) DeleteUserWatches(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserWatches, userID)
+	return err
+}
@@ -0,0 +1,219 @@
+package httpapi
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// exportBundle gathers everything we hold about a user for an ARCO access
+// request.
+func (s *Server) exportBundle(r *http.Request, userID int64) (map[string]any, []sqlc.UserDocument, error) {
+	ctx := r.Context()
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	roles, err := s.queries.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	enrollments, err := s.queries.ListEnrollmentsForUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	bids, err := s.queries.ListBidsForUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	likes, err := s.queries.GetUserLikes(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	watches, err := s.queries.ListUserWatches(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	searches, err := s.queries.ListSavedSearches(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifications, err := s.queries.ListUserNotifications(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	preferences, err := s.queries.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs, err := s.queries.ListUserDocuments(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := s.queries.ListProfileChangesForUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if likes == nil {
+		likes = []sqlc.UserLikeWithListing{}
+	}
+	if docs == nil {
+		docs = []sqlc.UserDocument{}
+	}
+	return map[string]any{
+		"exported_at":              time.Now().UTC().Format(time.RFC3339),
+		"profile":                  userResponse(user),
+		"roles":                    roles,
+		"enrollments":              enrollments,
+		"bids":                     bids,
+		"likes":                    likes,
+		"watches":                  watches,
+		"saved_searches":           searches,
+		"notifications":            notifications,
+		"notification_preferences": preferences,
+		"documents":                docs,
+		"profile_changes":          changes,
+	}, docs, nil
+}
+
+// handleExportMyData returns the caller's data as JSON, or with ?format=zip as
+// an archive holding data.json plus the uploaded document files.
+func (s *Server) handleExportMyData(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		respondError(w, http.StatusBadRequest, "format must be json or zip")
+		return
+	}
+	bundle, docs, err := s.exportBundle(r, claims.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("user_id", claims.UserID).Msg("failed to export user data")
+		respondError(w, http.StatusInternalServerError, "failed to export data")
+		return
+	}
+	if format != "zip" {
+		w.Header().Set("Content-Disposition", `attachment; filename="maqzone-data.json"`)
+		respondJSON(w, http.StatusOK, bundle)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="maqzone-data.zip"`)
+	zw := zip.NewWriter(w)
+	if f, err := zw.Create("data.json"); err == nil {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(bundle)
+	}
+	for _, d := range docs {
+		if err := s.addDocumentToZip(r, zw, d); err != nil {
+			// Headers are already sent; skip the file and keep the rest.
+			s.logger.Warn().Err(err).Int64("document_id", d.ID).Msg("failed to add document to export")
+		}
+	}
+	_ = zw.Close()
+}
+
+func (s *Server) addDocumentToZip(r *http.Request, zw *zip.Writer, d sqlc.UserDocument) error {
+	src, err := s.storage.Open(r.Context(), d.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := zw.Create(fmt.Sprintf("documents/%s%s", d.DocType, path.Ext(d.StorageKey)))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+type closureRequest struct {
+	Reason string `json:"reason"`
+}
+
+// handleRequestClosure opens an account closure request. Closure is only
+// carried out once staff confirm no open obligations remain.
+func (s *Server) handleRequestClosure(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	var req closureRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	user, err := s.queries.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load user")
+		return
+	}
+	if user.ClosedAt != "" {
+		respondError(w, http.StatusConflict, "account is already closed")
+		return
+	}
+	closure, err := s.queries.CreateClosureRequest(r.Context(), claims.UserID, strings.TrimSpace(req.Reason))
+	if err != nil {
+		// The partial unique index allows a single pending request per user.
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			respondError(w, http.StatusConflict, "a closure request is already pending")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to create closure request")
+		return
+	}
+	respondJSON(w, http.StatusCreated, closure)
+}
+
+func (s *Server) handleGetMyClosure(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	closure, err := s.queries.GetLatestClosureRequest(r.Context(), claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "no closure request")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load closure request")
+		return
+	}
+	respondJSON(w, http.StatusOK, closure)
+}
+
+func (s *Server) handleCancelMyClosure(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	closure, err := s.queries.CancelClosureRequest(r.Context(), claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "no pending closure request")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to cancel closure request")
+		return
+	}
+	respondJSON(w, http.StatusOK, closure)
+}
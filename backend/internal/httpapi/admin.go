@@ -1,57 +1,119 @@
 package httpapi
 
 import (
-  "crypto/subtle"
+  "context"
+  "database/sql"
   "encoding/json"
+  "errors"
   "net/http"
   "strings"
+  "time"
 
   "maqzone/backend/internal/auth"
   sqlc "maqzone/backend/internal/db/sqlc"
 )
 
+const adminPrincipalKey ctxKey = "adminPrincipal"
+
+// adminPrincipal identifies the caller of an /api/admin endpoint: either a
+// staff user authenticated with a JWT or an API key, plus the scopes it holds.
+type adminPrincipal struct {
+  UserID   int64
+  APIKeyID int64
+  Scopes   map[string]bool
+}
+
+func (p *adminPrincipal) can(scope string) bool {
+  return p != nil && p.Scopes[scope]
+}
+
+func getAdminPrincipal(ctx context.Context) *adminPrincipal {
+  if v, ok := ctx.Value(adminPrincipalKey).(*adminPrincipal); ok {
+    return v
+  }
+  return nil
+}
+
 func (s *Server) adminAuth(next http.Handler) http.Handler {
   return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-    token := r.Header.Get("X-Admin-Token")
-    if token != "" && s.cfg.AdminToken != "" && subtleCompare(token, s.cfg.AdminToken) {
-      next.ServeHTTP(w, r)
+    if key := r.Header.Get("X-API-Key"); key != "" {
+      principal, err := s.authenticateAPIKey(r.Context(), key)
+      if err != nil {
+        respondError(w, http.StatusUnauthorized, "invalid api key")
+        return
+      }
+      ctx := context.WithValue(r.Context(), adminPrincipalKey, principal)
+      next.ServeHTTP(w, r.WithContext(ctx))
       return
     }
 
     header := r.Header.Get("Authorization")
     if strings.HasPrefix(header, "Bearer ") {
       tokenStr := strings.TrimPrefix(header, "Bearer ")
-      claims, err := auth.ValidateToken(s.cfg.JWTSecret, tokenStr)
+      claims, err := s.keys.ValidateToken(tokenStr)
       if err != nil {
         respondError(w, http.StatusUnauthorized, "invalid or expired token")
         return
       }
+      if claims.Impersonating() {
+        respondError(w, http.StatusForbidden, "impersonation tokens cannot access admin endpoints")
+        return
+      }
       user, err := s.queries.GetUserByID(r.Context(), claims.UserID)
       if err != nil {
         respondError(w, http.StatusUnauthorized, "invalid admin user")
         return
       }
-      if user.IsAdmin == 1 {
-        next.ServeHTTP(w, r)
+      // Closing or suspending an account takes effect before its roles are
+      // revoked and before its tokens expire.
+      switch {
+      case user.ClosedAt != "":
+        respondError(w, http.StatusUnauthorized, "account closed")
+        return
+      case user.Status == "suspended":
+        respondError(w, http.StatusForbidden, "account suspended")
         return
       }
-      respondError(w, http.StatusForbidden, "admin access required")
+      roles, err := s.queries.ListUserRoles(r.Context(), user.ID)
+      if err != nil {
+        respondError(w, http.StatusInternalServerError, "failed to load roles")
+        return
+      }
+      if len(roles) == 0 {
+        respondError(w, http.StatusForbidden, "admin access required")
+        return
+      }
+      principal := &adminPrincipal{UserID: user.ID, Scopes: scopeSet(auth.ScopesForRoles(roles))}
+      ctx := context.WithValue(r.Context(), userClaimsKey, claims)
+      ctx = context.WithValue(ctx, adminPrincipalKey, principal)
+      next.ServeHTTP(w, r.WithContext(ctx))
       return
     }
 
-    if s.cfg.AdminToken == "" {
-      respondError(w, http.StatusForbidden, "admin token not configured")
-      return
-    }
-    respondError(w, http.StatusUnauthorized, "invalid admin token")
+    respondError(w, http.StatusUnauthorized, "missing admin credentials")
   })
 }
 
-func subtleCompare(a, b string) bool {
-  if len(a) != len(b) {
-    return false
+// requireScope rejects admin requests whose principal lacks the given scope.
+// It must be mounted after adminAuth.
+func (s *Server) requireScope(scope string) func(http.Handler) http.Handler {
+  return func(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+      if !getAdminPrincipal(r.Context()).can(scope) {
+        respondError(w, http.StatusForbidden, "missing scope: "+scope)
+        return
+      }
+      next.ServeHTTP(w, r)
+    })
+  }
+}
+
+func scopeSet(scopes []string) map[string]bool {
+  set := make(map[string]bool, len(scopes))
+  for _, sc := range scopes {
+    set[sc] = true
   }
-  return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+  return set
 }
 
 type updateAuctionRequest struct {
@@ -71,6 +133,9 @@ type updateAuctionRequest struct {
   AutoExtendMinutes       int64  `json:"auto_extend_minutes"`
   AutoExtendWindowMinutes int64  `json:"auto_extend_window_minutes"`
   PriceVisible            int64  `json:"price_visible"`
+  // Visibility keeps its current value when omitted, so an update cannot
+  // accidentally publish a private auction.
+  Visibility string `json:"visibility"`
 }
 
 func (s *Server) handleUpdateAuction(w http.ResponseWriter, r *http.Request) {
@@ -84,6 +149,10 @@ func (s *Server) handleUpdateAuction(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusBadRequest, "invalid json")
     return
   }
+  if msg := auctionTimesError(req.StartTime, req.EndTime); msg != "" {
+    respondError(w, http.StatusBadRequest, msg)
+    return
+  }
   // Default sale_mode to pass CHECK constraint
   if req.SaleMode == "" {
     req.SaleMode = "auction"
@@ -100,6 +169,22 @@ func (s *Server) handleUpdateAuction(w http.ResponseWriter, r *http.Request) {
   if req.AutoExtendWindowMinutes == 0 {
     req.AutoExtendWindowMinutes = 2
   }
+  before, err := s.queries.GetAuction(r.Context(), id)
+  if err != nil {
+    if errors.Is(err, sql.ErrNoRows) {
+      respondError(w, http.StatusNotFound, "auction not found")
+      return
+    }
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
+  if req.Visibility == "" {
+    req.Visibility = before.Visibility
+  }
+  if !validVisibility(req.Visibility) {
+    respondError(w, http.StatusBadRequest, "visibility must be public, unlisted or invite_only")
+    return
+  }
   item, err := s.queries.UpdateAuction(r.Context(), sqlc.UpdateAuctionParams{
     ID:                      id,
     Title:                   req.Title,
@@ -118,12 +203,14 @@ func (s *Server) handleUpdateAuction(w http.ResponseWriter, r *http.Request) {
     AutoExtendMinutes:       req.AutoExtendMinutes,
     AutoExtendWindowMinutes: req.AutoExtendWindowMinutes,
     PriceVisible:            req.PriceVisible,
+    Visibility:              req.Visibility,
   })
   if err != nil {
     s.logger.Error().Err(err).Msg("failed to update auction")
     respondError(w, http.StatusInternalServerError, "failed to update auction")
     return
   }
+  s.audit(r, auditEntry{Action: "auction.update", TargetType: "auction", TargetID: id, Before: before, After: item})
   respondJSON(w, http.StatusOK, item)
 }
 
@@ -133,10 +220,22 @@ func (s *Server) handleDeleteAuction(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusBadRequest, "invalid id")
     return
   }
+  before, err := s.queries.GetAuction(r.Context(), id)
+  if err != nil {
+    if errors.Is(err, sql.ErrNoRows) {
+      respondError(w, http.StatusNotFound, "auction not found")
+      return
+    }
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
   if err := s.queries.DeleteAuction(r.Context(), id); err != nil {
     respondError(w, http.StatusInternalServerError, "failed to delete auction")
     return
   }
+  s.deleteGallery(r.Context(), "auction", id)
+  s.deleteAttachments(r.Context(), "auction", id)
+  s.audit(r, auditEntry{Action: "auction.delete", TargetType: "auction", TargetID: id, Before: before})
   respondJSON(w, http.StatusOK, map[string]any{"deleted": id})
 }
 
@@ -162,6 +261,15 @@ func (s *Server) handleUpdateListing(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusBadRequest, "invalid json")
     return
   }
+  before, err := s.queries.GetListing(r.Context(), id)
+  if err != nil {
+    if errors.Is(err, sql.ErrNoRows) {
+      respondError(w, http.StatusNotFound, "listing not found")
+      return
+    }
+    respondError(w, http.StatusInternalServerError, "failed to load listing")
+    return
+  }
   item, err := s.queries.UpdateListing(r.Context(), sqlc.UpdateListingParams{
     ID:          id,
     Title:       req.Title,
@@ -177,6 +285,7 @@ func (s *Server) handleUpdateListing(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusInternalServerError, "failed to update listing")
     return
   }
+  s.audit(r, auditEntry{Action: "listing.update", TargetType: "listing", TargetID: id, Before: before, After: item})
   respondJSON(w, http.StatusOK, item)
 }
 
@@ -186,10 +295,22 @@ func (s *Server) handleDeleteListing(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusBadRequest, "invalid id")
     return
   }
+  before, err := s.queries.GetListing(r.Context(), id)
+  if err != nil {
+    if errors.Is(err, sql.ErrNoRows) {
+      respondError(w, http.StatusNotFound, "listing not found")
+      return
+    }
+    respondError(w, http.StatusInternalServerError, "failed to load listing")
+    return
+  }
   if err := s.queries.DeleteListing(r.Context(), id); err != nil {
     respondError(w, http.StatusInternalServerError, "failed to delete listing")
     return
   }
+  s.deleteGallery(r.Context(), "listing", id)
+  s.deleteAttachments(r.Context(), "listing", id)
+  s.audit(r, auditEntry{Action: "listing.delete", TargetType: "listing", TargetID: id, Before: before})
   respondJSON(w, http.StatusOK, map[string]any{"deleted": id})
 }
 
@@ -228,13 +349,67 @@ func (s *Server) handleRequestEnrollment(w http.ResponseWriter, r *http.Request)
     respondError(w, http.StatusUnauthorized, "not authenticated")
     return
   }
-  enrollment, err := s.queries.RequestEnrollment(r.Context(), auctionID, claims.UserID)
+  // Invite-only auctions do not exist for anyone who was not invited. This
+  // runs before the transaction because canViewAuction reads through s.queries.
+  auction, err := s.queries.GetAuction(r.Context(), auctionID)
   if err != nil {
-    if strings.Contains(err.Error(), "UNIQUE") {
-      respondError(w, http.StatusConflict, "already enrolled")
+    if errors.Is(err, sql.ErrNoRows) {
+      respondError(w, http.StatusNotFound, "auction not found")
       return
     }
-    respondError(w, http.StatusInternalServerError, "failed to request enrollment")
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
+  if ok, err := s.canViewAuction(r.Context(), auction); err != nil || !ok {
+    respondError(w, http.StatusNotFound, "auction not found")
+    return
+  }
+  // The policy is evaluated and the seat taken in one transaction so
+  // concurrent requests cannot overshoot max_enrollees.
+  var enrollment sqlc.AuctionEnrollment
+  err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+    auction, err := q.GetAuction(r.Context(), auctionID)
+    if err != nil {
+      return err
+    }
+    policy, _, err := loadEnrollmentPolicy(r, q, auctionID)
+    if err != nil {
+      return err
+    }
+    user, err := q.GetUserByID(r.Context(), claims.UserID)
+    if err != nil {
+      return err
+    }
+    status, err := enrollmentDecision(policy, auction, user, time.Now())
+    if err != nil {
+      return err
+    }
+    if policy.MaxEnrollees > 0 {
+      count, err := q.CountActiveEnrollments(r.Context(), auctionID)
+      if err != nil {
+        return err
+      }
+      if count >= policy.MaxEnrollees {
+        if policy.Waitlist == 0 {
+          return errEnrollmentFull
+        }
+        status = "waitlisted"
+      }
+    }
+    enrollment, err = q.RequestEnrollment(r.Context(), auctionID, claims.UserID, status)
+    return err
+  })
+  if err != nil {
+    switch {
+    case errors.Is(err, sql.ErrNoRows):
+      respondError(w, http.StatusNotFound, "auction not found")
+    case errors.Is(err, errEnrollmentClosed), errors.Is(err, errEnrollmentFull):
+      respondError(w, http.StatusConflict, err.Error())
+    case strings.Contains(err.Error(), "UNIQUE"):
+      respondError(w, http.StatusConflict, "already enrolled")
+    default:
+      respondError(w, http.StatusInternalServerError, "failed to request enrollment")
+    }
     return
   }
   respondJSON(w, http.StatusCreated, enrollment)
@@ -284,11 +459,13 @@ func (s *Server) handleApproveEnrollment(w http.ResponseWriter, r *http.Request)
     respondError(w, http.StatusBadRequest, "invalid user id")
     return
   }
+  before, _ := s.queries.GetEnrollment(r.Context(), auctionID, userID)
   enrollment, err := s.queries.ApproveEnrollment(r.Context(), auctionID, userID)
   if err != nil {
     respondError(w, http.StatusInternalServerError, "failed to approve enrollment")
     return
   }
+  s.audit(r, auditEntry{Action: "enrollment.approve", TargetType: "enrollment", TargetID: enrollment.ID, Before: before, After: enrollment})
   respondJSON(w, http.StatusOK, enrollment)
 }
 
@@ -303,11 +480,16 @@ func (s *Server) handleRejectEnrollment(w http.ResponseWriter, r *http.Request)
     respondError(w, http.StatusBadRequest, "invalid user id")
     return
   }
+  before, _ := s.queries.GetEnrollment(r.Context(), auctionID, userID)
   enrollment, err := s.queries.RejectEnrollment(r.Context(), auctionID, userID)
   if err != nil {
     respondError(w, http.StatusInternalServerError, "failed to reject enrollment")
     return
   }
+  s.audit(r, auditEntry{Action: "enrollment.reject", TargetType: "enrollment", TargetID: enrollment.ID, Before: before, After: enrollment})
+  if before.Status == "pending" || before.Status == "approved" {
+    s.promoteWaitlist(r, auctionID)
+  }
   respondJSON(w, http.StatusOK, enrollment)
 }
 
@@ -326,6 +508,11 @@ func (s *Server) handleSetUserOpportunities(w http.ResponseWriter, r *http.Reque
     respondError(w, http.StatusBadRequest, "invalid json")
     return
   }
+  before, err := s.queries.GetUserByID(r.Context(), id)
+  if err != nil {
+    respondError(w, http.StatusNotFound, "user not found")
+    return
+  }
   user, err := s.queries.SetUserOpportunities(r.Context(), sqlc.SetUserOpportunitiesParams{
     ID:                     id,
     RemainingOpportunities: req.Opportunities,
@@ -334,5 +521,6 @@ func (s *Server) handleSetUserOpportunities(w http.ResponseWriter, r *http.Reque
     respondError(w, http.StatusInternalServerError, "failed to set opportunities")
     return
   }
+  s.audit(r, auditEntry{Action: "user.set_opportunities", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
   respondJSON(w, http.StatusOK, user)
 }
@@ -0,0 +1,188 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+func (s *Server) handleListClosures(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	items, err := s.queries.ListClosureRequestsByStatus(r.Context(), status, int64(parseLimit(r, 50)))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list closure requests")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+type reviewClosureRequest struct {
+	Note string `json:"note"`
+}
+
+// closureClearedFields names what approving a closure erases. The audit entry
+// lists these names, never the values.
+var closureClearedFields = []string{
+	"email", "password", "legal_representative", "street_address", "colony",
+	"municipality", "postal_code", "city", "state", "phone", "mobile",
+	"documents", "likes", "roles", "profile_changes", "notifications",
+	"notification_preferences", "watches", "saved_searches",
+}
+
+var (
+	errClosureNotPending = errors.New("closure request is not pending")
+	errLeadingAuctions   = errors.New("user holds the highest bid on open auctions")
+)
+
+// handleApproveClosure anonymizes the account. Bids, enrollments, the RFC and
+// the business name stay so settlements remain traceable for accounting;
+// contact details, documents, likes, roles, profile history, notifications,
+// watches and saved searches are removed.
+func (s *Server) handleApproveClosure(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req reviewClosureRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+
+	var closure sqlc.AccountClosureRequest
+	var after sqlc.User
+	var docs []sqlc.UserDocument
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		closure, err = q.ReviewClosureRequest(r.Context(), sqlc.ReviewClosureRequestParams{
+			ID:         id,
+			Status:     "approved",
+			ReviewNote: strings.TrimSpace(req.Note),
+			ReviewedBy: reviewerID(r),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errClosureNotPending
+		}
+		if err != nil {
+			return err
+		}
+		leading, err := q.CountLeadingAuctions(r.Context(), closure.UserID)
+		if err != nil {
+			return err
+		}
+		if leading > 0 {
+			return errLeadingAuctions
+		}
+		if docs, err = q.ListUserDocuments(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		email := fmt.Sprintf("deleted-%d@closed.invalid", closure.UserID)
+		if after, err = q.AnonymizeUser(r.Context(), email, closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserLikes(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserRoles(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserDocuments(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteProfileChangesForUser(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserNotifications(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteNotificationPreferences(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserWatches(r.Context(), closure.UserID); err != nil {
+			return err
+		}
+		return q.DeleteUserSavedSearches(r.Context(), closure.UserID)
+	})
+	if err != nil {
+		s.respondClosureError(w, r, id, err)
+		return
+	}
+	// Files go only after the rows are gone; a failure leaves an orphan, never
+	// a dangling reference.
+	ctx := context.WithoutCancel(r.Context())
+	for _, d := range docs {
+		if err := s.storage.Delete(ctx, d.StorageKey); err != nil {
+			s.logger.Warn().Err(err).Str("key", d.StorageKey).Msg("failed to delete closed account document")
+		}
+	}
+	s.audit(r, auditEntry{Action: "user.closure.approve", TargetType: "user", TargetID: closure.UserID, After: map[string]any{
+		"closure_id": closure.ID,
+		"user_id":    closure.UserID,
+		"cleared":    closureClearedFields,
+	}})
+	respondJSON(w, http.StatusOK, map[string]any{
+		"closure": closure,
+		"user":    userResponse(after),
+	})
+}
+
+func (s *Server) handleRejectClosure(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req reviewClosureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Note == "" {
+		respondError(w, http.StatusBadRequest, "note is required")
+		return
+	}
+	closure, err := s.queries.ReviewClosureRequest(r.Context(), sqlc.ReviewClosureRequestParams{
+		ID:         id,
+		Status:     "rejected",
+		ReviewNote: req.Note,
+		ReviewedBy: reviewerID(r),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errClosureNotPending
+	}
+	if err != nil {
+		s.respondClosureError(w, r, id, err)
+		return
+	}
+	s.audit(r, auditEntry{Action: "user.closure.reject", TargetType: "account_closure", TargetID: closure.ID, After: closure})
+	respondJSON(w, http.StatusOK, map[string]any{"closure": closure})
+}
+
+func (s *Server) respondClosureError(w http.ResponseWriter, r *http.Request, id int64, err error) {
+	switch {
+	case errors.Is(err, errClosureNotPending):
+		if _, getErr := s.queries.GetClosureRequest(r.Context(), id); errors.Is(getErr, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "closure request not found")
+			return
+		}
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errLeadingAuctions):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		s.logger.Error().Err(err).Int64("closure_id", id).Msg("failed to review closure request")
+		respondError(w, http.StatusInternalServerError, "failed to review closure request")
+	}
+}
@@ -0,0 +1,171 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"maqzone/backend/internal/auth"
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+var errInvalidAPIKey = errors.New("invalid api key")
+
+// authenticateAPIKey resolves a plaintext key to an admin principal, rejecting
+// unknown, revoked and expired keys, and records when the key was last used.
+func (s *Server) authenticateAPIKey(ctx context.Context, key string) (*adminPrincipal, error) {
+	id, secret, ok := auth.ParseAPIKey(key)
+	if !ok {
+		return nil, errInvalidAPIKey
+	}
+	apiKey, err := s.queries.GetAPIKeyByPrefix(ctx, id)
+	if err != nil {
+		return nil, errInvalidAPIKey
+	}
+	if !auth.CheckAPIKeySecret(apiKey.SecretHash, secret) || !apiKeyActive(apiKey, time.Now()) {
+		return nil, errInvalidAPIKey
+	}
+	if err := s.queries.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		s.logger.Error().Err(err).Int64("api_key_id", apiKey.ID).Msg("failed to record api key use")
+	}
+	return &adminPrincipal{
+		APIKeyID: apiKey.ID,
+		Scopes:   scopeSet(auth.SplitScopes(apiKey.Scopes)),
+	}, nil
+}
+
+func apiKeyActive(k sqlc.APIKey, now time.Time) bool {
+	if k.RevokedAt != "" {
+		return false
+	}
+	if k.ExpiresAt != "" {
+		expires, err := time.Parse(time.RFC3339, k.ExpiresAt)
+		if err != nil || !now.Before(expires) {
+			return false
+		}
+	}
+	return true
+}
+
+type createAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		respondError(w, http.StatusBadRequest, "at least one scope is required")
+		return
+	}
+	principal := getAdminPrincipal(r.Context())
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			respondError(w, http.StatusBadRequest, "unknown scope: "+scope)
+			return
+		}
+		// A key can never be used to mint a more powerful key.
+		if !principal.can(scope) {
+			respondError(w, http.StatusForbidden, "cannot grant scope: "+scope)
+			return
+		}
+	}
+	expiresAt := ""
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "expires_at must be RFC3339")
+			return
+		}
+		if !t.After(time.Now()) {
+			respondError(w, http.StatusBadRequest, "expires_at must be in the future")
+			return
+		}
+		expiresAt = t.UTC().Format(time.RFC3339)
+	}
+
+	plaintext, id, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to generate api key")
+		return
+	}
+	key, err := s.queries.CreateAPIKey(r.Context(), sqlc.CreateAPIKeyParams{
+		Name:       req.Name,
+		Prefix:     id,
+		SecretHash: hash,
+		Scopes:     auth.JoinScopes(req.Scopes),
+		CreatedBy:  principal.UserID,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to create api key")
+		respondError(w, http.StatusInternalServerError, "failed to create api key")
+		return
+	}
+	s.audit(r, auditEntry{Action: "api_key.create", TargetType: "api_key", TargetID: key.ID, After: apiKeyResponse(key)})
+	respondJSON(w, http.StatusCreated, map[string]any{
+		"key":     plaintext,
+		"api_key": apiKeyResponse(key),
+	})
+}
+
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.queries.ListAPIKeys(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list api keys")
+		return
+	}
+	out := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, apiKeyResponse(k))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	key, err := s.queries.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "api key not found or already revoked")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to revoke api key")
+		return
+	}
+	s.audit(r, auditEntry{Action: "api_key.revoke", TargetType: "api_key", TargetID: key.ID, After: apiKeyResponse(key)})
+	respondJSON(w, http.StatusOK, apiKeyResponse(key))
+}
+
+func apiKeyResponse(k sqlc.APIKey) map[string]any {
+	return map[string]any{
+		"id":           k.ID,
+		"name":         k.Name,
+		"prefix":       auth.APIKeyPrefix + k.Prefix,
+		"scopes":       auth.SplitScopes(k.Scopes),
+		"created_by":   k.CreatedBy,
+		"expires_at":   k.ExpiresAt,
+		"last_used_at": k.LastUsedAt,
+		"revoked_at":   k.RevokedAt,
+		"active":       apiKeyActive(k, time.Now()),
+		"created_at":   k.CreatedAt,
+	}
+}
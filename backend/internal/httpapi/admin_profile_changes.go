@@ -0,0 +1,167 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+func (s *Server) handleListProfileChanges(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	items, err := s.queries.ListProfileChangesByStatus(r.Context(), status, int64(parseLimit(r, 50)))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list profile changes")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleListUserProfileChanges(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	items, err := s.queries.ListProfileChangesForUser(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list profile changes")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+type reviewProfileChangeRequest struct {
+	Note string `json:"note"`
+}
+
+var errChangeNotPending = errors.New("change request is not pending")
+
+// handleApproveProfileChange applies the requested fiscal identity to the
+// user. The request row keeps the replaced values.
+func (s *Server) handleApproveProfileChange(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req reviewProfileChangeRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+
+	var change sqlc.ProfileChangeRequest
+	var before, after sqlc.User
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		change, err = q.ReviewProfileChange(r.Context(), sqlc.ReviewProfileChangeParams{
+			ID:         id,
+			Status:     "approved",
+			ReviewNote: strings.TrimSpace(req.Note),
+			ReviewedBy: reviewerID(r),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errChangeNotPending
+		}
+		if err != nil {
+			return err
+		}
+		if before, err = q.GetUserByID(r.Context(), change.UserID); err != nil {
+			return err
+		}
+		after, err = q.UpdateUserFiscalIdentity(r.Context(), sqlc.UpdateUserFiscalIdentityParams{
+			BusinessName:        change.BusinessName,
+			LegalRepresentative: change.LegalRepresentative,
+			RFC:                 change.RFC,
+			ID:                  change.UserID,
+		})
+		return err
+	})
+	if err != nil {
+		s.respondProfileChangeError(w, r, id, err)
+		return
+	}
+	// Only the names of the changed fields are logged; see auditUser.
+	var fields []string
+	if before.BusinessName != after.BusinessName {
+		fields = append(fields, "business_name")
+	}
+	if before.LegalRepresentative != after.LegalRepresentative {
+		fields = append(fields, "legal_representative")
+	}
+	if before.RFC != after.RFC {
+		fields = append(fields, "rfc")
+	}
+	s.audit(r, auditEntry{Action: "user.profile_change.approve", TargetType: "user", TargetID: change.UserID, After: map[string]any{"change_id": change.ID, "fields": fields}})
+	respondJSON(w, http.StatusOK, map[string]any{
+		"change": change,
+		"user":   userResponse(after),
+	})
+}
+
+func (s *Server) handleRejectProfileChange(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req reviewProfileChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Note == "" {
+		respondError(w, http.StatusBadRequest, "note is required")
+		return
+	}
+	change, err := s.queries.ReviewProfileChange(r.Context(), sqlc.ReviewProfileChangeParams{
+		ID:         id,
+		Status:     "rejected",
+		ReviewNote: req.Note,
+		ReviewedBy: reviewerID(r),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errChangeNotPending
+	}
+	if err != nil {
+		s.respondProfileChangeError(w, r, id, err)
+		return
+	}
+	s.audit(r, auditEntry{Action: "user.profile_change.reject", TargetType: "profile_change", TargetID: change.ID, After: map[string]any{
+		"user_id":     change.UserID,
+		"status":      change.Status,
+		"review_note": change.ReviewNote,
+	}})
+	respondJSON(w, http.StatusOK, map[string]any{"change": change})
+}
+
+func (s *Server) respondProfileChangeError(w http.ResponseWriter, r *http.Request, id int64, err error) {
+	if errors.Is(err, errChangeNotPending) {
+		if _, getErr := s.queries.GetProfileChange(r.Context(), id); errors.Is(getErr, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "change request not found")
+			return
+		}
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.logger.Error().Err(err).Int64("change_id", id).Msg("failed to review profile change")
+	respondError(w, http.StatusInternalServerError, "failed to review profile change")
+}
+
+// reviewerID is the staff user behind an admin request, or 0 for API keys.
+func reviewerID(r *http.Request) int64 {
+	if p := getAdminPrincipal(r.Context()); p != nil {
+		return p.UserID
+	}
+	return 0
+}
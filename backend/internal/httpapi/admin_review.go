@@ -0,0 +1,330 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"maqzone/backend/internal/auth"
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/mailer"
+)
+
+// sqliteTimeLayout is the format datetime('now') produces.
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+func (s *Server) slaHours() int {
+	if s.cfg.ReviewSLAHours > 0 {
+		return s.cfg.ReviewSLAHours
+	}
+	return 48
+}
+
+// reviewQueueItem adds the internal review fields to a user response.
+func (s *Server) reviewQueueItem(u sqlc.User) map[string]any {
+	resp := userResponse(u)
+	resp["assigned_reviewer_id"] = u.AssignedReviewerID
+	resp["assigned_at"] = u.AssignedAt
+	resp["reviewed_at"] = u.ReviewedAt
+	if submitted, err := time.Parse(sqliteTimeLayout, u.SubmittedAt); err == nil && (u.Status == "pending" || u.Status == "info_requested") {
+		hours := time.Since(submitted).Hours()
+		resp["hours_in_queue"] = hours
+		resp["over_sla"] = u.Status == "pending" && hours > float64(s.slaHours())
+	}
+	return resp
+}
+
+// handleReviewQueue lists open registrations, oldest first. ?assignee takes
+// "me", "unassigned" or a reviewer id; ?status narrows to pending or
+// info_requested.
+func (s *Server) handleReviewQueue(w http.ResponseWriter, r *http.Request) {
+	filter := sqlc.ReviewQueueFilter{Status: r.URL.Query().Get("status")}
+	if filter.Status != "" && filter.Status != "pending" && filter.Status != "info_requested" {
+		respondError(w, http.StatusBadRequest, "status must be pending or info_requested")
+		return
+	}
+	switch assignee := r.URL.Query().Get("assignee"); assignee {
+	case "":
+	case "unassigned":
+		filter.Unassigned = true
+	case "me":
+		filter.AssigneeID = reviewerID(r)
+		if filter.AssigneeID == 0 {
+			respondError(w, http.StatusBadRequest, "assignee=me requires a staff user session")
+			return
+		}
+	default:
+		id, err := strconv.ParseInt(assignee, 10, 64)
+		if err != nil || id <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid assignee")
+			return
+		}
+		filter.AssigneeID = id
+	}
+	users, err := s.queries.ListReviewQueue(r.Context(), filter, int64(parseLimit(r, 50)))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list review queue")
+		return
+	}
+	out := make([]map[string]any, 0, len(users))
+	for _, u := range users {
+		out = append(out, s.reviewQueueItem(u))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleReviewMetrics(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.queries.GetReviewQueueStats(r.Context(), int64(s.slaHours()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load review metrics")
+		return
+	}
+	workload, err := s.queries.ListReviewerWorkload(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load review metrics")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"sla_hours": s.slaHours(),
+		"queue":     stats,
+		"reviewers": workload,
+	})
+}
+
+var errNotOpenForReview = errors.New("registration is not awaiting review")
+
+// handleClaimUser assigns an open registration to the calling reviewer. A
+// registration someone else holds has to be reassigned explicitly.
+func (s *Server) handleClaimUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	reviewer := reviewerID(r)
+	if reviewer == 0 {
+		respondError(w, http.StatusForbidden, "claiming requires a staff user session")
+		return
+	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	user, err := s.queries.ClaimUserReview(r.Context(), reviewer, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		if before.Status != "pending" && before.Status != "info_requested" {
+			respondError(w, http.StatusConflict, errNotOpenForReview.Error())
+			return
+		}
+		respondJSON(w, http.StatusConflict, map[string]any{
+			"error":                "registration is assigned to another reviewer",
+			"assigned_reviewer_id": before.AssignedReviewerID,
+		})
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to claim registration")
+		return
+	}
+	s.audit(r, auditEntry{Action: "user.review.claim", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
+	respondJSON(w, http.StatusOK, s.reviewQueueItem(user))
+}
+
+type assignReviewerRequest struct {
+	ReviewerID int64 `json:"reviewer_id"`
+}
+
+// handleAssignReviewer hands a registration to another reviewer, or releases
+// it back to the queue with reviewer_id 0.
+func (s *Server) handleAssignReviewer(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req assignReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.ReviewerID != 0 {
+		roles, err := s.queries.ListUserRoles(r.Context(), req.ReviewerID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to load roles")
+			return
+		}
+		if !scopeSet(auth.ScopesForRoles(roles))[auth.ScopeUsersReview] {
+			respondError(w, http.StatusBadRequest, "reviewer must hold a role with users:review")
+			return
+		}
+	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	user, err := s.queries.AssignUserReviewer(r.Context(), req.ReviewerID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, errNotOpenForReview.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to assign reviewer")
+		return
+	}
+	s.audit(r, auditEntry{Action: "user.review.assign", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
+	respondJSON(w, http.StatusOK, s.reviewQueueItem(user))
+}
+
+func (s *Server) handleListReviewNotes(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	notes, err := s.queries.ListReviewNotes(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list notes")
+		return
+	}
+	respondJSON(w, http.StatusOK, notes)
+}
+
+type reviewNoteRequest struct {
+	Body string `json:"body"`
+}
+
+// handleAddReviewNote appends to the internal notes thread on a registration.
+// Notes are never shown to the applicant.
+func (s *Server) handleAddReviewNote(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req reviewNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		respondError(w, http.StatusBadRequest, "body is required")
+		return
+	}
+	if _, err := s.queries.GetUserByID(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	note, err := s.queries.CreateReviewNote(r.Context(), id, reviewerID(r), req.Body)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to add note")
+		return
+	}
+	s.audit(r, auditEntry{Action: "user.review.note", TargetType: "user", TargetID: id, After: note})
+	respondJSON(w, http.StatusCreated, note)
+}
+
+type requestInfoRequest struct {
+	Message string `json:"message"`
+}
+
+// handleRequestUserInfo sends a pending registration back to the applicant
+// with a message explaining what is missing. The applicant is emailed and
+// can resubmit once they have updated their profile or documents.
+func (s *Server) handleRequestUserInfo(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req requestInfoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		respondError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	var user sqlc.User
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		user, err = q.RequestUserInfo(r.Context(), req.Message, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errNotOpenForReview
+		}
+		if err != nil {
+			return err
+		}
+		// Keep the request in the thread so the history reads end to end.
+		_, err = q.CreateReviewNote(r.Context(), id, reviewerID(r), "Requested more information: "+req.Message)
+		return err
+	})
+	if errors.Is(err, errNotOpenForReview) {
+		respondError(w, http.StatusConflict, "only pending registrations can be sent back for information")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to request information")
+		return
+	}
+	s.recordStatusChange(r, id, before.Status, user.Status, req.Message)
+	s.audit(r, auditEntry{Action: "user.review.request_info", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
+	s.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "MAQZONE: necesitamos más información sobre tu registro",
+		Body: fmt.Sprintf("Hola,\n\nPara continuar con la revisión de tu registro necesitamos lo siguiente:\n\n%s\n\n"+
+			"Actualiza tu perfil o tus documentos y vuelve a enviar tu registro desde tu cuenta.\n", req.Message),
+	})
+	respondJSON(w, http.StatusOK, s.reviewQueueItem(user))
+}
+
+// handleResubmitRegistration returns a registration to the review queue after
+// the applicant has answered an information request.
+func (s *Server) handleResubmitRegistration(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	user, err := s.queries.ResubmitUser(r.Context(), claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, "no information was requested for this registration")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to resubmit registration")
+		return
+	}
+	s.recordStatusChange(r, user.ID, "info_requested", user.Status, "resubmitted")
+	if _, err := s.queries.CreateReviewNote(r.Context(), user.ID, 0, "Applicant resubmitted the registration."); err != nil {
+		s.logger.Warn().Err(err).Int64("user_id", user.ID).Msg("failed to record resubmission note")
+	}
+	respondJSON(w, http.StatusOK, userResponse(user))
+}
+
+// sendMail delivers in the background so a slow relay never holds up the
+// request; failures are only logged.
+func (s *Server) sendMail(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.logger.Error().Err(err).Str("subject", msg.Subject).Msg("failed to send email")
+		}
+	}()
+}
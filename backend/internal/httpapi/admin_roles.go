@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"maqzone/backend/internal/auth"
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+var errSelfRoleChange = errors.New("cannot change your own roles")
+
+func (s *Server) handleListRoles(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(auth.RoleScopes))
+	for name := range auth.RoleScopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		out = append(out, map[string]any{
+			"role":   name,
+			"scopes": auth.RoleScopes[name],
+		})
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleGetUserRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.GetUserByID(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	roles, err := s.queries.ListUserRoles(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load roles")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user_id": id,
+		"roles":   roles,
+		"scopes":  auth.ScopesForRoles(roles),
+	})
+}
+
+type setRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+func (s *Server) handleSetUserRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req setRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	for _, role := range req.Roles {
+		if !auth.ValidRole(role) {
+			respondError(w, http.StatusBadRequest, "unknown role: "+role)
+			return
+		}
+	}
+	before, _ := s.queries.ListUserRoles(r.Context(), id)
+	if _, err := s.replaceUserRoles(r, id, req.Roles); err != nil {
+		s.respondRoleError(w, err)
+		return
+	}
+	roles, err := s.queries.ListUserRoles(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load roles")
+		return
+	}
+	s.audit(r, auditEntry{Action: "user.set_roles", TargetType: "user", TargetID: id, Before: before, After: roles})
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user_id": id,
+		"roles":   roles,
+		"scopes":  auth.ScopesForRoles(roles),
+	})
+}
+
+// replaceUserRoles swaps a user's roles for the given set and keeps the legacy
+// is_admin flag in sync (set while the user holds any staff role).
+func (s *Server) replaceUserRoles(r *http.Request, userID int64, roles []string) (sqlc.User, error) {
+	grantedBy := int64(0)
+	if principal := getAdminPrincipal(r.Context()); principal != nil {
+		// Prevents staff from locking themselves out or escalating their own access.
+		if principal.UserID == userID {
+			return sqlc.User{}, errSelfRoleChange
+		}
+		grantedBy = principal.UserID
+	}
+	var user sqlc.User
+	err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		if err := q.DeleteUserRoles(r.Context(), userID); err != nil {
+			return err
+		}
+		for _, role := range roles {
+			if err := q.AddUserRole(r.Context(), sqlc.AddUserRoleParams{
+				UserID:    userID,
+				Role:      role,
+				GrantedBy: grantedBy,
+			}); err != nil {
+				return err
+			}
+		}
+		isAdmin := int64(0)
+		if len(roles) > 0 {
+			isAdmin = 1
+		}
+		var err error
+		user, err = q.UpdateUserAdmin(r.Context(), sqlc.UpdateUserAdminParams{
+			IsAdmin: isAdmin,
+			ID:      userID,
+		})
+		return err
+	})
+	return user, err
+}
+
+func (s *Server) respondRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errSelfRoleChange):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		respondError(w, http.StatusNotFound, "user not found")
+	default:
+		s.logger.Error().Err(err).Msg("failed to update roles")
+		respondError(w, http.StatusInternalServerError, "failed to update roles")
+	}
+}
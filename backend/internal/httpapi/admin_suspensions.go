@@ -0,0 +1,172 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/mailer"
+)
+
+type suspendRequest struct {
+	Reason string `json:"reason"`
+	// Until is an optional RFC3339 end date; the scheduler reinstates the
+	// account once it passes.
+	Until string `json:"until"`
+}
+
+// handleSuspendUser blocks an approved buyer from bidding and enrolling while
+// keeping their registration intact. Suspending an already suspended user
+// updates the reason and end date.
+func (s *Server) handleSuspendUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req suspendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		respondError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	until := ""
+	if req.Until != "" {
+		t, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "until must be an RFC3339 timestamp")
+			return
+		}
+		if !t.After(time.Now()) {
+			respondError(w, http.StatusBadRequest, "until must be in the future")
+			return
+		}
+		until = t.UTC().Format(time.RFC3339)
+	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	user, err := s.queries.SuspendUser(r.Context(), req.Reason, until, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, "only approved users can be suspended")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to suspend user")
+		return
+	}
+	s.recordStatusChange(r, id, before.Status, user.Status, req.Reason)
+	s.audit(r, auditEntry{Action: "user.suspend", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
+
+	body := fmt.Sprintf("Hola,\n\nTu cuenta de MAQZONE ha sido suspendida. Motivo: %s\n", req.Reason)
+	if until != "" {
+		body += fmt.Sprintf("\nLa suspensión termina el %s.\n", until)
+	}
+	s.sendMail(mailer.Message{To: user.Email, Subject: "MAQZONE: tu cuenta ha sido suspendida", Body: body})
+	respondJSON(w, http.StatusOK, userResponse(user))
+}
+
+type reinstateRequest struct {
+	Note string `json:"note"`
+}
+
+func (s *Server) handleReinstateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req reinstateRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	user, err := s.queries.ReinstateUser(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, "user is not suspended")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to reinstate user")
+		return
+	}
+	s.recordStatusChange(r, id, before.Status, user.Status, strings.TrimSpace(req.Note))
+	s.audit(r, auditEntry{Action: "user.reinstate", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
+	respondJSON(w, http.StatusOK, userResponse(user))
+}
+
+func (s *Server) handleUserStatusHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	items, err := s.queries.ListStatusHistory(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list status history")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+// recordStatusChange appends to the user's status history. Requests without
+// an admin principal or token are self-service (registration), so the user is
+// their own actor. Like audit, failures are logged, not surfaced.
+func (s *Server) recordStatusChange(r *http.Request, userID int64, from, to, reason string) {
+	if from == to {
+		return
+	}
+	actorUserID, actorAPIKeyID := requestActor(r)
+	if actorUserID == 0 && actorAPIKeyID == 0 {
+		actorUserID = userID
+	}
+	err := s.queries.CreateStatusChange(r.Context(), sqlc.CreateStatusChangeParams{
+		UserID:        userID,
+		FromStatus:    from,
+		ToStatus:      to,
+		Reason:        reason,
+		ActorUserID:   actorUserID,
+		ActorAPIKeyID: actorAPIKeyID,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Int64("user_id", userID).Msg("failed to record status change")
+	}
+}
+
+// respondInactiveAccount rejects users who may not take part in auctions and
+// reports whether it did. Suspended users are told why and until when.
+func respondInactiveAccount(w http.ResponseWriter, u sqlc.User) bool {
+	switch {
+	case u.ClosedAt != "":
+		respondError(w, http.StatusForbidden, "account closed")
+	case u.Status == "suspended":
+		respondJSON(w, http.StatusForbidden, map[string]any{
+			"error":           "account suspended",
+			"reason":          u.SuspensionReason,
+			"suspended_until": u.SuspendedUntil,
+		})
+	case u.Status != "approved":
+		respondError(w, http.StatusForbidden, "account not approved")
+	default:
+		return false
+	}
+	return true
+}
@@ -50,7 +50,14 @@ func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "user not found")
 		return
 	}
-	respondJSON(w, http.StatusOK, userResponse(user))
+	docs, err := s.queries.ListUserDocuments(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list documents")
+		return
+	}
+	resp := userResponse(user)
+	resp["documents"] = documentChecklist(docs)
+	respondJSON(w, http.StatusOK, resp)
 }
 
 type approveRequest struct {
@@ -72,11 +79,30 @@ func (s *Server) handleApproveUser(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "guarantee_tier must be 50k or 100k")
 		return
 	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	pending, err := s.pendingDocuments(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to check documents")
+		return
+	}
+	if len(pending) > 0 {
+		respondJSON(w, http.StatusConflict, map[string]any{
+			"error":             "required documents are not approved",
+			"pending_documents": pending,
+		})
+		return
+	}
 	user, err := s.queries.ApproveUser(r.Context(), req.GuaranteeTier, id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to approve user")
 		return
 	}
+	s.recordStatusChange(r, id, before.Status, user.Status, "")
+	s.audit(r, auditEntry{Action: "user.approve", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
 	respondJSON(w, http.StatusOK, userResponse(user))
 }
 
@@ -99,11 +125,18 @@ func (s *Server) handleRejectUser(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "reason is required")
 		return
 	}
+	before, err := s.queries.GetUserByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
 	user, err := s.queries.RejectUser(r.Context(), req.Reason, id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to reject user")
 		return
 	}
+	s.recordStatusChange(r, id, before.Status, user.Status, req.Reason)
+	s.audit(r, auditEntry{Action: "user.reject", TargetType: "user", TargetID: id, Before: auditUser(before), After: auditUser(user)})
 	respondJSON(w, http.StatusOK, userResponse(user))
 }
 
@@ -141,6 +174,8 @@ func (s *Server) handleSetUserPassword(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to update password")
 		return
 	}
+	// The hash itself never goes into the log.
+	s.audit(r, auditEntry{Action: "user.reset_password", TargetType: "user", TargetID: id})
 	respondJSON(w, http.StatusOK, userResponse(user))
 }
 
@@ -148,6 +183,8 @@ type adminRoleRequest struct {
 	IsAdmin bool `json:"is_admin"`
 }
 
+// handleSetUserAdmin is kept for the admin panel's on/off switch: granting
+// admin assigns the superadmin role and revoking it removes every role.
 func (s *Server) handleSetUserAdmin(w http.ResponseWriter, r *http.Request) {
 	id, err := parseID(r, "id")
 	if err != nil {
@@ -159,17 +196,16 @@ func (s *Server) handleSetUserAdmin(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid json")
 		return
 	}
-	value := int64(0)
+	roles := []string{}
 	if req.IsAdmin {
-		value = 1
+		roles = append(roles, auth.RoleSuperadmin)
 	}
-	user, err := s.queries.UpdateUserAdmin(r.Context(), sqlc.UpdateUserAdminParams{
-		IsAdmin: value,
-		ID:      id,
-	})
+	before, _ := s.queries.ListUserRoles(r.Context(), id)
+	user, err := s.replaceUserRoles(r, id, roles)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to update admin role")
+		s.respondRoleError(w, err)
 		return
 	}
+	s.audit(r, auditEntry{Action: "user.set_admin", TargetType: "user", TargetID: id, Before: before, After: roles})
 	respondJSON(w, http.StatusOK, userResponse(user))
 }
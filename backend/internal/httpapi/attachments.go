@@ -0,0 +1,408 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/storage"
+)
+
+// maxAttachmentSize bounds a single attachment; walkaround videos are the
+// largest files admins upload.
+const maxAttachmentSize = 100 << 20
+
+// attachmentTypes maps accepted content types to the extension files are
+// stored with. As with KYC documents the type is sniffed from the content.
+var attachmentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+}
+
+func validAttachmentVisibility(v string) bool {
+	return v == "public" || v == "enrolled"
+}
+
+type attachmentResponse struct {
+	sqlc.ItemAttachment
+	URL string `json:"url"`
+	// Available tells the caller whether they may download the file; titles
+	// of enrolled-only attachments are shown to everyone.
+	Available bool `json:"available"`
+}
+
+func attachmentURL(id int64) string {
+	return fmt.Sprintf("/api/attachments/%d/file", id)
+}
+
+// canDownloadAttachment reports whether the caller may download a. Enrolled
+// attachments need an approved, open account and, on auctions, an approved
+// enrollment in the auction. Listings have no enrollment, so the account is
+// enough there.
+func (s *Server) canDownloadAttachment(ctx context.Context, a sqlc.ItemAttachment) (bool, error) {
+	if a.Visibility == "public" {
+		return true, nil
+	}
+	claims := GetClaims(ctx)
+	if claims == nil {
+		return false, nil
+	}
+	user, err := s.queries.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if user.Status != "approved" || user.ClosedAt != "" {
+		return false, nil
+	}
+	if a.ItemType != "auction" {
+		return true, nil
+	}
+	enrollment, err := s.queries.GetEnrollment(ctx, a.ItemID, claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return enrollment.Status == "approved", nil
+}
+
+// itemAttachments lists an item's attachments as seen by the caller.
+func (s *Server) itemAttachments(ctx context.Context, itemType string, itemID int64) ([]attachmentResponse, error) {
+	items, err := s.queries.ListItemAttachments(ctx, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]attachmentResponse, 0, len(items))
+	for _, a := range items {
+		ok, err := s.canDownloadAttachment(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, attachmentResponse{ItemAttachment: a, URL: attachmentURL(a.ID), Available: ok})
+	}
+	return out, nil
+}
+
+// handleListAttachments serves GET /api/{listings,auctions}/{id}/attachments.
+func (s *Server) handleListAttachments(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		if !s.canViewGalleryItem(w, r, itemType, id) {
+			return
+		}
+		items, err := s.itemAttachments(r.Context(), itemType, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list attachments")
+			return
+		}
+		respondJSON(w, http.StatusOK, items)
+	}
+}
+
+// handleDownloadAttachment serves an attachment to a caller allowed to see
+// it. Enrolled-only files answer 401 to anonymous callers and 403 to
+// signed-in ones who are not enrolled.
+func (s *Server) handleDownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	a, err := s.queries.GetItemAttachment(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	if a.ItemType == "auction" {
+		auction, err := s.queries.GetAuction(r.Context(), a.ItemID)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		if ok, err := s.canViewAuction(r.Context(), auction); err != nil || !ok {
+			respondError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+	}
+	ok, err := s.canDownloadAttachment(r.Context(), a)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to check access")
+		return
+	}
+	if !ok {
+		switch {
+		case GetClaims(r.Context()) == nil:
+			respondError(w, http.StatusUnauthorized, "sign in to download this attachment")
+		case a.ItemType == "auction":
+			respondError(w, http.StatusForbidden, "approved enrollment required")
+		default:
+			respondError(w, http.StatusForbidden, "account not approved")
+		}
+		return
+	}
+	s.serveAttachment(w, r, a)
+}
+
+// handleAdminListAttachments lists every attachment of an item regardless of
+// visibility.
+func (s *Server) handleAdminListAttachments(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		items, err := s.queries.ListItemAttachments(r.Context(), itemType, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list attachments")
+			return
+		}
+		out := make([]attachmentResponse, 0, len(items))
+		for _, a := range items {
+			out = append(out, attachmentResponse{ItemAttachment: a, URL: attachmentURL(a.ID), Available: true})
+		}
+		respondJSON(w, http.StatusOK, out)
+	}
+}
+
+func (s *Server) handleAdminDownloadAttachment(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := s.loadItemAttachment(w, r, itemType)
+		if !ok {
+			return
+		}
+		s.serveAttachment(w, r, a)
+	}
+}
+
+// serveAttachment streams the file inline. Local storage hands back a
+// seekable file, so videos get range requests for scrubbing.
+func (s *Server) serveAttachment(w http.ResponseWriter, r *http.Request, a sqlc.ItemAttachment) {
+	f, err := s.storage.Open(r.Context(), a.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "attachment file missing")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to open attachment")
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", a.Filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if a.Visibility != "public" {
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", time.Time{}, rs)
+		return
+	}
+	_, _ = io.Copy(w, f)
+}
+
+// handleUploadAttachment attaches a file to a listing or auction. The
+// multipart form carries file, title and visibility (default public).
+func (s *Server) handleUploadAttachment(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		if _, err := s.galleryItem(r.Context(), itemType, id); err != nil {
+			respondError(w, http.StatusNotFound, itemType+" not found")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			respondError(w, http.StatusBadRequest, "expected multipart form with a file field")
+			return
+		}
+		title := strings.TrimSpace(r.FormValue("title"))
+		if title == "" {
+			respondError(w, http.StatusBadRequest, "title is required")
+			return
+		}
+		visibility := r.FormValue("visibility")
+		if visibility == "" {
+			visibility = "public"
+		}
+		if !validAttachmentVisibility(visibility) {
+			respondError(w, http.StatusBadRequest, "visibility must be public or enrolled")
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		if header.Size > maxAttachmentSize {
+			respondError(w, http.StatusRequestEntityTooLarge, "file exceeds 100 MB")
+			return
+		}
+
+		sniff := make([]byte, 512)
+		n, err := io.ReadFull(file, sniff)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			respondError(w, http.StatusBadRequest, "failed to read file")
+			return
+		}
+		sniff = sniff[:n]
+		contentType := http.DetectContentType(sniff)
+		ext, ok := attachmentTypes[contentType]
+		if !ok {
+			respondError(w, http.StatusUnsupportedMediaType, "attachments must be PDF, JPEG, PNG, MP4 or WebM")
+			return
+		}
+
+		suffix := make([]byte, 8)
+		if _, err := rand.Read(suffix); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to store attachment")
+			return
+		}
+		key := fmt.Sprintf("attachments/%s/%d/%s%s", itemType, id, hex.EncodeToString(suffix), ext)
+		size, err := s.storage.Put(r.Context(), key, io.MultiReader(bytes.NewReader(sniff), file))
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to store attachment")
+			respondError(w, http.StatusInternalServerError, "failed to store attachment")
+			return
+		}
+		a, err := s.queries.CreateItemAttachment(r.Context(), sqlc.CreateItemAttachmentParams{
+			ItemType:    itemType,
+			ItemID:      id,
+			Title:       title,
+			Visibility:  visibility,
+			Filename:    filepath.Base(header.Filename),
+			ContentType: contentType,
+			SizeBytes:   size,
+			StorageKey:  key,
+		})
+		if err != nil {
+			_ = s.storage.Delete(context.WithoutCancel(r.Context()), key)
+			respondError(w, http.StatusInternalServerError, "failed to save attachment")
+			return
+		}
+		s.audit(r, auditEntry{Action: itemType + ".attachment.add", TargetType: itemType, TargetID: id, After: a})
+		respondJSON(w, http.StatusCreated, attachmentResponse{ItemAttachment: a, URL: attachmentURL(a.ID), Available: true})
+	}
+}
+
+// loadItemAttachment reads {attachmentId} and checks it belongs to the {id}
+// item.
+func (s *Server) loadItemAttachment(w http.ResponseWriter, r *http.Request, itemType string) (sqlc.ItemAttachment, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return sqlc.ItemAttachment{}, false
+	}
+	attachmentID, err := parseID(r, "attachmentId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid attachment id")
+		return sqlc.ItemAttachment{}, false
+	}
+	a, err := s.queries.GetItemAttachment(r.Context(), attachmentID)
+	if err != nil || a.ItemType != itemType || a.ItemID != id {
+		respondError(w, http.StatusNotFound, "attachment not found")
+		return sqlc.ItemAttachment{}, false
+	}
+	return a, true
+}
+
+type updateAttachmentRequest struct {
+	Title      string `json:"title"`
+	Visibility string `json:"visibility"`
+	Position   int64  `json:"position"`
+}
+
+func (s *Server) handleUpdateAttachment(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		before, ok := s.loadItemAttachment(w, r, itemType)
+		if !ok {
+			return
+		}
+		var req updateAttachmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		req.Title = strings.TrimSpace(req.Title)
+		if req.Title == "" {
+			respondError(w, http.StatusBadRequest, "title is required")
+			return
+		}
+		if !validAttachmentVisibility(req.Visibility) {
+			respondError(w, http.StatusBadRequest, "visibility must be public or enrolled")
+			return
+		}
+		a, err := s.queries.UpdateItemAttachment(r.Context(), sqlc.UpdateItemAttachmentParams{
+			ID:         before.ID,
+			Title:      req.Title,
+			Visibility: req.Visibility,
+			Position:   req.Position,
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to update attachment")
+			return
+		}
+		s.audit(r, auditEntry{Action: itemType + ".attachment.update", TargetType: itemType, TargetID: a.ItemID, Before: before, After: a})
+		respondJSON(w, http.StatusOK, attachmentResponse{ItemAttachment: a, URL: attachmentURL(a.ID), Available: true})
+	}
+}
+
+func (s *Server) handleDeleteAttachment(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := s.loadItemAttachment(w, r, itemType)
+		if !ok {
+			return
+		}
+		if err := s.queries.DeleteItemAttachment(r.Context(), a.ID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to delete attachment")
+			return
+		}
+		if err := s.storage.Delete(context.WithoutCancel(r.Context()), a.StorageKey); err != nil {
+			s.logger.Warn().Err(err).Str("key", a.StorageKey).Msg("failed to delete attachment file")
+		}
+		s.audit(r, auditEntry{Action: itemType + ".attachment.delete", TargetType: itemType, TargetID: a.ItemID, Before: a})
+		respondJSON(w, http.StatusOK, map[string]any{"deleted": a.ID})
+	}
+}
+
+// deleteAttachments drops every attachment of a deleted listing or auction.
+func (s *Server) deleteAttachments(ctx context.Context, itemType string, itemID int64) {
+	items, err := s.queries.ListItemAttachments(ctx, itemType, itemID)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to list attachments for deletion")
+		return
+	}
+	for _, a := range items {
+		if err := s.queries.DeleteItemAttachment(ctx, a.ID); err != nil {
+			s.logger.Warn().Err(err).Int64("attachment_id", a.ID).Msg("failed to delete attachment")
+			continue
+		}
+		if err := s.storage.Delete(context.WithoutCancel(ctx), a.StorageKey); err != nil {
+			s.logger.Warn().Err(err).Str("key", a.StorageKey).Msg("failed to delete attachment file")
+		}
+	}
+}
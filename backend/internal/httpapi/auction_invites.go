@@ -0,0 +1,169 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/mailer"
+)
+
+func validVisibility(v string) bool {
+	return v == "public" || v == "unlisted" || v == "invite_only"
+}
+
+// canViewAuction reports whether the caller may see an auction. Public and
+// unlisted auctions are open to anyone with the link; invite-only ones only
+// to invited users.
+func (s *Server) canViewAuction(ctx context.Context, a sqlc.Auction) (bool, error) {
+	if a.Visibility != "invite_only" {
+		return true, nil
+	}
+	claims := GetClaims(ctx)
+	if claims == nil {
+		return false, nil
+	}
+	return s.queries.IsInvited(ctx, a.ID, claims.UserID)
+}
+
+func (s *Server) handleListAuctionInvites(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid auction id")
+		return
+	}
+	items, err := s.queries.ListAuctionInvites(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list invites")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+type inviteRequest struct {
+	UserIDs []int64  `json:"user_ids"`
+	Emails  []string `json:"emails"`
+}
+
+type inviteResult struct {
+	UserID int64  `json:"user_id,omitempty"`
+	Email  string `json:"email"`
+	// Result is invited, already_invited, not_found or failed.
+	Result string `json:"result"`
+}
+
+// handleInviteUsers adds users to an auction's invite list by ID or email.
+// Each invitee is enrolled as approved straight away, so an invite is all a
+// buyer needs to bid.
+func (s *Server) handleInviteUsers(w http.ResponseWriter, r *http.Request) {
+	auctionID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid auction id")
+		return
+	}
+	var req inviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if len(req.UserIDs) == 0 && len(req.Emails) == 0 {
+		respondError(w, http.StatusBadRequest, "user_ids or emails is required")
+		return
+	}
+	auction, err := s.queries.GetAuction(r.Context(), auctionID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+
+	var users []sqlc.User
+	var results []inviteResult
+	for _, id := range req.UserIDs {
+		u, err := s.queries.GetUserByID(r.Context(), id)
+		if err != nil {
+			results = append(results, inviteResult{UserID: id, Result: "not_found"})
+			continue
+		}
+		users = append(users, u)
+	}
+	for _, email := range req.Emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		u, err := s.queries.GetUserByEmail(r.Context(), email)
+		if err != nil {
+			results = append(results, inviteResult{Email: email, Result: "not_found"})
+			continue
+		}
+		users = append(users, u)
+	}
+
+	invitedBy, _ := requestActor(r)
+	for _, u := range users {
+		res := inviteResult{UserID: u.ID, Email: u.Email}
+		var invite sqlc.AuctionInvite
+		var enrollment sqlc.AuctionEnrollment
+		err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+			var err error
+			if invite, err = q.CreateAuctionInvite(r.Context(), auctionID, u.ID, invitedBy); err != nil {
+				return err
+			}
+			enrollment, err = q.UpsertApprovedEnrollment(r.Context(), auctionID, u.ID)
+			return err
+		})
+		switch {
+		case err != nil && strings.Contains(err.Error(), "UNIQUE constraint"):
+			res.Result = "already_invited"
+		case err != nil:
+			res.Result = "failed"
+		default:
+			res.Result = "invited"
+			s.audit(r, auditEntry{Action: "auction.invite", TargetType: "auction", TargetID: auctionID, After: map[string]any{"invite": invite, "enrollment": enrollment}})
+			s.sendMail(mailer.Message{
+				To:      u.Email,
+				Subject: "MAQZONE: invitación a una subasta privada",
+				Body:    fmt.Sprintf("Hola,\n\nHas sido invitado a la subasta privada \"%s\". Ya estás inscrito y puedes pujar cuando abra.\n", auction.Title),
+			})
+		}
+		results = append(results, res)
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+// handleRevokeInvite removes a user from the invite list and rejects their
+// enrollment, so they lose access to an invite-only auction immediately.
+func (s *Server) handleRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	auctionID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid auction id")
+		return
+	}
+	userID, err := parseID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		if err := q.DeleteAuctionInvite(r.Context(), auctionID, userID); err != nil {
+			return err
+		}
+		_, err := q.RejectEnrollment(r.Context(), auctionID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "invite not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to revoke invite")
+		return
+	}
+	s.audit(r, auditEntry{Action: "auction.invite.revoke", TargetType: "auction", TargetID: auctionID, Before: map[string]any{"user_id": userID}})
+	respondJSON(w, http.StatusOK, map[string]any{"deleted": userID})
+}
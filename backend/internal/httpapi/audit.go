@@ -0,0 +1,284 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+const auditRecorderKey ctxKey = "auditRecorder"
+
+// auditEntry describes one privileged change. Before and After are snapshots
+// of the target (any JSON-serializable value); either may be nil.
+type auditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   int64
+	Before     any
+	After      any
+}
+
+type auditRecorder struct {
+	recorded bool
+}
+
+// audit records a privileged action performed by the current admin principal.
+// Failures are logged rather than surfaced: the change itself already happened.
+func (s *Server) audit(r *http.Request, e auditEntry) {
+	if rec, ok := r.Context().Value(auditRecorderKey).(*auditRecorder); ok {
+		rec.recorded = true
+	}
+	params := sqlc.CreateAuditEntryParams{
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		BeforeJSON: auditJSON(e.Before),
+		AfterJSON:  auditJSON(e.After),
+		RequestID:  middleware.GetReqID(r.Context()),
+		IP:         clientIP(r),
+	}
+	if e.Before != nil || e.After != nil {
+		params.ChangesJSON = auditJSON(auditChanges(e.Before, e.After))
+	}
+	params.ActorUserID, params.ActorAPIKeyID = requestActor(r)
+	if _, err := s.queries.CreateAuditEntry(r.Context(), params); err != nil {
+		s.logger.Error().Err(err).Str("action", e.Action).Msg("failed to write audit entry")
+	}
+}
+
+// auditMutations makes sure every successful admin mutation leaves a trace:
+// handlers record detailed entries through s.audit, and anything they miss is
+// logged here with the route pattern as the action.
+func (s *Server) auditMutations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &auditRecorder{}
+		r = r.WithContext(context.WithValue(r.Context(), auditRecorderKey, rec))
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if rec.recorded || ww.Status() >= 400 {
+			return
+		}
+		action := r.Method
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			action += " " + rctx.RoutePattern()
+		}
+		id, _ := parseID(r, "id")
+		s.audit(r, auditEntry{Action: action, TargetID: id})
+	})
+}
+
+// requestActor identifies who is behind a request: the admin principal, the
+// staff member behind an impersonation token, or the signed-in user.
+func requestActor(r *http.Request) (userID, apiKeyID int64) {
+	if p := getAdminPrincipal(r.Context()); p != nil {
+		return p.UserID, p.APIKeyID
+	}
+	if c := GetClaims(r.Context()); c != nil {
+		if c.Impersonating() {
+			return c.ImpersonatorID, 0
+		}
+		return c.UserID, 0
+	}
+	return 0, 0
+}
+
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// auditUser is the part of a user that may go into the audit log: account
+// state only. The log is append-only, so contact and fiscal details never go
+// in; a closed account must be able to erase them.
+func auditUser(u sqlc.User) map[string]any {
+	return map[string]any{
+		"id":                      u.ID,
+		"status":                  u.Status,
+		"guarantee_tier":          u.GuaranteeTier,
+		"remaining_opportunities": u.RemainingOpportunities,
+		"rejection_reason":        u.RejectionReason,
+		"info_request":            u.InfoRequest,
+		"suspension_reason":       u.SuspensionReason,
+		"suspended_until":         u.SuspendedUntil,
+		"submitted_at":            u.SubmittedAt,
+		"assigned_reviewer_id":    u.AssignedReviewerID,
+		"must_change_password":    u.MustChangePassword == 1,
+		"is_admin":                u.IsAdmin == 1,
+		"closed_at":               u.ClosedAt,
+	}
+}
+
+func auditJSON(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// auditChanges returns the top-level fields that differ between two snapshots
+// as {"field": {"from": old, "to": new}}.
+func auditChanges(before, after any) map[string]any {
+	b, a := auditFields(before), auditFields(after)
+	changes := map[string]any{}
+	for k, av := range a {
+		if bv, ok := b[k]; !ok || !reflect.DeepEqual(av, bv) {
+			changes[k] = map[string]any{"from": b[k], "to": av}
+		}
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			changes[k] = map[string]any{"from": bv, "to": nil}
+		}
+	}
+	return changes
+}
+
+func auditFields(v any) map[string]any {
+	if v == nil {
+		return map[string]any{}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return map[string]any{}
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// Not an object (e.g. a list of roles): diff it as a single value.
+		var value any
+		_ = json.Unmarshal(data, &value)
+		return map[string]any{"value": value}
+	}
+	return fields
+}
+
+func parseAuditFilter(r *http.Request) sqlc.AuditFilter {
+	q := r.URL.Query()
+	f := sqlc.AuditFilter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		From:       q.Get("from"),
+		To:         q.Get("to"),
+	}
+	f.ActorUserID, _ = strconv.ParseInt(q.Get("actor_user_id"), 10, 64)
+	f.ActorAPIKeyID, _ = strconv.ParseInt(q.Get("actor_api_key_id"), 10, 64)
+	f.TargetID, _ = strconv.ParseInt(q.Get("target_id"), 10, 64)
+	return f
+}
+
+func parseOffset(r *http.Request) int {
+	if n, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+func (s *Server) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	filter := parseAuditFilter(r)
+	limit := parseLimit(r, 50)
+	offset := parseOffset(r)
+
+	items, err := s.queries.ListAuditEntries(r.Context(), filter, int64(limit), int64(offset))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list audit entries")
+		return
+	}
+	total, err := s.queries.CountAuditEntries(r.Context(), filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to count audit entries")
+		return
+	}
+	out := make([]map[string]any, 0, len(items))
+	for _, e := range items {
+		out = append(out, auditEntryResponse(e))
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"items":  out,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// auditExportBatch bounds how many rows are read per query while streaming.
+const auditExportBatch = 500
+
+func (s *Server) handleExportAudit(w http.ResponseWriter, r *http.Request) {
+	filter := parseAuditFilter(r)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="maqzone-audit.csv"`)
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"id", "created_at", "actor_user_id", "actor_api_key_id", "action",
+		"target_type", "target_id", "changes", "request_id", "ip",
+	})
+	for offset := int64(0); ; offset += auditExportBatch {
+		items, err := s.queries.ListAuditEntries(r.Context(), filter, auditExportBatch, offset)
+		if err != nil {
+			// Headers are already sent; log and end the file early.
+			s.logger.Error().Err(err).Msg("failed to export audit entries")
+			break
+		}
+		for _, e := range items {
+			_ = cw.Write([]string{
+				strconv.FormatInt(e.ID, 10),
+				e.CreatedAt,
+				strconv.FormatInt(e.ActorUserID, 10),
+				strconv.FormatInt(e.ActorAPIKeyID, 10),
+				e.Action,
+				e.TargetType,
+				strconv.FormatInt(e.TargetID, 10),
+				e.ChangesJSON,
+				e.RequestID,
+				e.IP,
+			})
+		}
+		if len(items) < auditExportBatch {
+			break
+		}
+	}
+	cw.Flush()
+}
+
+func auditEntryResponse(e sqlc.AuditEntry) map[string]any {
+	return map[string]any{
+		"id":               e.ID,
+		"actor_user_id":    e.ActorUserID,
+		"actor_api_key_id": e.ActorAPIKeyID,
+		"action":           e.Action,
+		"target_type":      e.TargetType,
+		"target_id":        e.TargetID,
+		"before":           rawJSON(e.BeforeJSON),
+		"after":            rawJSON(e.AfterJSON),
+		"changes":          rawJSON(e.ChangesJSON),
+		"request_id":       e.RequestID,
+		"ip":               e.IP,
+		"created_at":       e.CreatedAt,
+	}
+}
+
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
@@ -7,6 +7,7 @@ import (
 
 	"maqzone/backend/internal/auth"
 	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/validation"
 )
 
 type registerRequest struct {
@@ -42,15 +43,7 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hash, err := auth.HashPassword(req.Password)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to process password")
-		return
-	}
-
-	user, err := s.queries.CreateUser(r.Context(), sqlc.CreateUserParams{
-		Email:               req.Email,
-		PasswordHash:        hash,
+	profile := validation.Profile{
 		BusinessName:        req.BusinessName,
 		LegalRepresentative: req.LegalRepresentative,
 		RFC:                 req.RFC,
@@ -62,6 +55,32 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		State:               req.State,
 		Phone:               req.Phone,
 		Mobile:              req.Mobile,
+	}
+	if errs := profile.Normalize(); len(errs) > 0 {
+		respondValidation(w, errs)
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to process password")
+		return
+	}
+
+	user, err := s.queries.CreateUser(r.Context(), sqlc.CreateUserParams{
+		Email:               req.Email,
+		PasswordHash:        hash,
+		BusinessName:        profile.BusinessName,
+		LegalRepresentative: profile.LegalRepresentative,
+		RFC:                 profile.RFC,
+		StreetAddress:       profile.StreetAddress,
+		Colony:              profile.Colony,
+		Municipality:        profile.Municipality,
+		PostalCode:          profile.PostalCode,
+		City:                profile.City,
+		State:               profile.State,
+		Phone:               profile.Phone,
+		Mobile:              profile.Mobile,
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint") {
@@ -71,8 +90,9 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to create account")
 		return
 	}
+	s.recordStatusChange(r, user.ID, "", user.Status, "registered")
 
-	token, err := auth.GenerateToken(s.cfg.JWTSecret, user.ID, user.Email)
+	token, err := s.keys.GenerateToken(user.ID, user.Email)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to generate token")
 		return
@@ -113,7 +133,7 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := auth.GenerateToken(s.cfg.JWTSecret, user.ID, user.Email)
+	token, err := s.keys.GenerateToken(user.ID, user.Email)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to generate token")
 		return
@@ -137,8 +157,19 @@ func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to load user")
 		return
 	}
+	roles, err := s.queries.ListUserRoles(r.Context(), user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load roles")
+		return
+	}
 
-	respondJSON(w, http.StatusOK, userResponse(user))
+	resp := userResponse(user)
+	resp["roles"] = roles
+	resp["scopes"] = auth.ScopesForRoles(roles)
+	if claims.Impersonating() {
+		resp["impersonated_by"] = claims.ImpersonatorID
+	}
+	respondJSON(w, http.StatusOK, resp)
 }
 
 type changePasswordRequest struct {
@@ -197,11 +228,22 @@ func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	uploads, err := s.queries.ListUserDocuments(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list documents")
+		return
+	}
 	respondJSON(w, http.StatusOK, map[string]any{
 		"documents": []map[string]string{
 			{"name": "Hoja de Registro MAQZONE", "url": "/docs/hoja-de-registro-maqzone.html"},
 			{"name": "Terminos y Condiciones de Venta", "url": "/docs/terminos-de-venta-maqzone.html"},
 		},
+		"uploads": documentChecklist(uploads),
 	})
 }
 
@@ -224,8 +266,13 @@ func userResponse(u sqlc.User) map[string]any {
 		"guarantee_tier":       u.GuaranteeTier,
 		"remaining_opportunities": u.RemainingOpportunities,
 		"rejection_reason":     u.RejectionReason,
+		"info_request":         u.InfoRequest,
+		"suspension_reason":    u.SuspensionReason,
+		"suspended_until":      u.SuspendedUntil,
+		"submitted_at":         u.SubmittedAt,
 		"must_change_password": u.MustChangePassword == 1,
 		"is_admin":             u.IsAdmin == 1,
+		"closed_at":            u.ClosedAt,
 		"created_at":           u.CreatedAt,
 	}
 }
@@ -2,6 +2,8 @@ package httpapi
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strings"
 	"sync"
@@ -27,7 +29,7 @@ func (s *Server) optionalUserAuth(next http.Handler) http.Handler {
 		header := r.Header.Get("Authorization")
 		if strings.HasPrefix(header, "Bearer ") {
 			tokenStr := strings.TrimPrefix(header, "Bearer ")
-			if claims, err := auth.ValidateToken(s.cfg.JWTSecret, tokenStr); err == nil {
+			if claims, err := s.keys.ValidateToken(tokenStr); err == nil {
 				ctx := context.WithValue(r.Context(), userClaimsKey, claims)
 				r = r.WithContext(ctx)
 			}
@@ -44,16 +46,39 @@ func (s *Server) userAuth(next http.Handler) http.Handler {
 			return
 		}
 		tokenStr := strings.TrimPrefix(header, "Bearer ")
-		claims, err := auth.ValidateToken(s.cfg.JWTSecret, tokenStr)
+		claims, err := s.keys.ValidateToken(tokenStr)
 		if err != nil {
 			respondError(w, http.StatusUnauthorized, "invalid or expired token")
 			return
 		}
+		if s.respondClosedAccount(w, r, claims.UserID) {
+			return
+		}
 		ctx := context.WithValue(r.Context(), userClaimsKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		s.auditImpersonation(next).ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// respondClosedAccount rejects tokens whose account is gone or closed and
+// reports whether it did. Tokens outlive account closure, so the account is
+// checked on every request rather than trusting the token until it expires.
+func (s *Server) respondClosedAccount(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	user, err := s.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusUnauthorized, "invalid or expired token")
+			return true
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load user")
+		return true
+	}
+	if user.ClosedAt != "" {
+		respondError(w, http.StatusUnauthorized, "account closed")
+		return true
+	}
+	return false
+}
+
 func (s *Server) requireApproved(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := GetClaims(r.Context())
@@ -66,8 +91,7 @@ func (s *Server) requireApproved(next http.Handler) http.Handler {
 			respondError(w, http.StatusInternalServerError, "failed to load user")
 			return
 		}
-		if user.Status != "approved" {
-			respondError(w, http.StatusForbidden, "account not approved")
+		if respondInactiveAccount(w, user) {
 			return
 		}
 		next.ServeHTTP(w, r)
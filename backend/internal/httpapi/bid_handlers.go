@@ -46,6 +46,10 @@ func (s *Server) handlePlaceBid(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to load auction")
 		return
 	}
+	if ok, err := s.canViewAuction(r.Context(), auction); err != nil || !ok {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
 
 	if auction.Status != "active" {
 		respondError(w, http.StatusBadRequest, "auction is not active")
@@ -53,11 +57,14 @@ func (s *Server) handlePlaceBid(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check enrollment
-	_, enrollErr := s.queries.GetEnrollment(r.Context(), auctionID, claims.UserID)
+	enrollment, enrollErr := s.queries.GetEnrollment(r.Context(), auctionID, claims.UserID)
 	if enrollErr != nil {
 		respondError(w, http.StatusForbidden, "not enrolled in this auction")
 		return
 	}
+	if respondEnrollmentNotApproved(w, enrollment) {
+		return
+	}
 
 	// Use configurable min_bid_increment (default 1000)
 	increment := auction.MinBidIncrement
@@ -163,6 +170,19 @@ func (s *Server) handleListBids(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid auction id")
 		return
 	}
+	auction, err := s.queries.GetAuction(r.Context(), auctionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "auction not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load auction")
+		return
+	}
+	if ok, err := s.canViewAuction(r.Context(), auction); err != nil || !ok {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
 
 	limit := parseLimit(r, 50)
 	bids, err := s.queries.ListBidsForAuction(r.Context(), auctionID, int64(limit))
@@ -220,3 +240,22 @@ func itoa(n int64) string {
 	}
 	return s
 }
+
+// respondEnrollmentNotApproved rejects bids on enrollments an admin has not
+// approved and reports whether it did. Pending and rejected are told apart so
+// the client can tell the user whether to wait or give up.
+func respondEnrollmentNotApproved(w http.ResponseWriter, e sqlc.AuctionEnrollment) bool {
+	switch e.Status {
+	case "approved":
+		return false
+	case "pending":
+		respondError(w, http.StatusForbidden, "enrollment pending approval")
+	case "rejected":
+		respondError(w, http.StatusForbidden, "enrollment rejected")
+	case "waitlisted":
+		respondError(w, http.StatusForbidden, "enrollment waitlisted")
+	default:
+		respondError(w, http.StatusForbidden, "not enrolled in this auction")
+	}
+	return true
+}
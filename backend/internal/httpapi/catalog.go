@@ -0,0 +1,126 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// catalogCursor is the decoded form of the opaque next_cursor token. The sort
+// is carried along so a cursor cannot be replayed against another order.
+type catalogCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   int64  `json:"i"`
+}
+
+func encodeCatalogCursor(sort string, c *sqlc.CatalogCursor) string {
+	if c == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(catalogCursor{Sort: sort, Key: c.Key, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCatalogCursor(token, sort string) (*sqlc.CatalogCursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false
+	}
+	var c catalogCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort || c.ID == 0 {
+		return nil, false
+	}
+	return &sqlc.CatalogCursor{Key: c.Key, ID: c.ID}, true
+}
+
+// catalogStatuses are the statuses the public catalog may filter on. Listings
+// use active, auction and sold; auctions scheduled, active, closed and sold.
+// Withdrawn listings and any other status stay out of public view.
+var catalogStatuses = []string{"active", "scheduled", "auction", "closed", "sold"}
+
+// catalogQuery is a parsed catalog request: filters, sort order and the page
+// to start after.
+type catalogQuery struct {
+	filter sqlc.CatalogFilter
+	sort   string
+	after  *sqlc.CatalogCursor
+	limit  int64
+}
+
+// parseCatalogQuery reads the catalog query parameters shared by
+// /api/listings and /api/auctions. sorts lists the accepted sort orders,
+// default first. On a bad parameter it returns a message for a 400.
+func (s *Server) parseCatalogQuery(r *http.Request, sorts []string) (catalogQuery, string, error) {
+	q := r.URL.Query()
+	c := catalogQuery{
+		filter: sqlc.CatalogFilter{
+			Status:   q.Get("status"),
+			Location: strings.TrimSpace(q.Get("location")),
+			SaleType: q.Get("sale_type"),
+		},
+		sort:  q.Get("sort"),
+		limit: int64(parseLimit(r, 20)),
+	}
+	if c.filter.Status == "" {
+		c.filter.Status = "active"
+	}
+	if !slices.Contains(catalogStatuses, c.filter.Status) {
+		return c, "status must be one of " + strings.Join(catalogStatuses, ", "), nil
+	}
+	for name, dst := range map[string]*int64{
+		"price_min": &c.filter.PriceMin,
+		"price_max": &c.filter.PriceMax,
+		"year_min":  &c.filter.YearMin,
+		"year_max":  &c.filter.YearMax,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return c, "invalid " + name, nil
+		}
+		*dst = n
+	}
+	specs, msg := parseSpecFilters(r)
+	if msg != "" {
+		return c, msg, nil
+	}
+	c.filter.Specs = specs
+	if c.sort == "" {
+		c.sort = sorts[0]
+	}
+	if !slices.Contains(sorts, c.sort) {
+		return c, "sort must be one of " + strings.Join(sorts, ", "), nil
+	}
+	if token := q.Get("cursor"); token != "" {
+		after, ok := decodeCatalogCursor(token, c.sort)
+		if !ok {
+			return c, "invalid cursor", nil
+		}
+		c.after = after
+	}
+
+	categoryID, filtered, found, err := s.categoryFilter(r)
+	if err != nil {
+		return c, "", err
+	}
+	switch {
+	case filtered && !found:
+		// No category has id -1, so an unknown slug matches nothing.
+		c.filter.CategoryID = -1
+	case filtered:
+		c.filter.CategoryID = categoryID
+	}
+	if claims := GetClaims(r.Context()); claims != nil {
+		c.filter.ViewerID = claims.UserID
+	}
+	return c, "", nil
+}
@@ -0,0 +1,321 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+var accentFolder = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"Á", "a", "É", "e", "Í", "i", "Ó", "o", "Ú", "u", "Ü", "u", "Ñ", "n",
+)
+
+// slugify turns a category name into its URL slug:
+// "Maquinaria de construcción" becomes "maquinaria-de-construccion".
+func slugify(name string) string {
+	s := strings.ToLower(accentFolder.Replace(name))
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// handleListCategories returns the whole category tree as a flat list ordered
+// by parent and position; counts include descendants.
+func (s *Server) handleListCategories(w http.ResponseWriter, r *http.Request) {
+	items, err := s.queries.ListCategoriesWithCounts(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list categories")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleGetCategory(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	all, err := s.queries.ListCategoriesWithCounts(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load category")
+		return
+	}
+	var category *sqlc.CategoryWithCounts
+	for i := range all {
+		if all[i].Slug == slug {
+			category = &all[i]
+		}
+	}
+	if category == nil {
+		respondError(w, http.StatusNotFound, "category not found")
+		return
+	}
+	children := []sqlc.CategoryWithCounts{}
+	for _, c := range all {
+		if c.ParentID == category.ID {
+			children = append(children, c)
+		}
+	}
+	path, err := s.queries.ListCategoryAncestors(r.Context(), category.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load category")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"category": category,
+		"path":     path,
+		"children": children,
+	})
+}
+
+// categoryFilter resolves the ?category=<slug> catalog filter. ok is false
+// when no filter was given; an unknown slug yields found false so callers can
+// return an empty page rather than an error.
+func (s *Server) categoryFilter(r *http.Request) (id int64, ok, found bool, err error) {
+	slug := r.URL.Query().Get("category")
+	if slug == "" {
+		return 0, false, false, nil
+	}
+	c, err := s.queries.GetCategoryBySlug(r.Context(), slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, true, false, nil
+	}
+	if err != nil {
+		return 0, true, false, err
+	}
+	return c.ID, true, true, nil
+}
+
+type categoryRequest struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	ParentID int64  `json:"parent_id"`
+	Position int64  `json:"position"`
+}
+
+// validateCategory normalizes req and checks its parent. id is the category
+// being updated (0 on create) and may not become its own ancestor.
+func (s *Server) validateCategory(ctx context.Context, req *categoryRequest, id int64) (int, string) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return http.StatusBadRequest, "name is required"
+	}
+	if req.Slug == "" {
+		req.Slug = req.Name
+	}
+	req.Slug = slugify(req.Slug)
+	if req.Slug == "" {
+		return http.StatusBadRequest, "slug must contain letters or digits"
+	}
+	if req.ParentID == 0 {
+		return 0, ""
+	}
+	if _, err := s.queries.GetCategory(ctx, req.ParentID); err != nil {
+		return http.StatusBadRequest, "parent category not found"
+	}
+	if id != 0 {
+		cycle, err := s.queries.IsCategoryInSubtree(ctx, id, req.ParentID)
+		if err != nil {
+			return http.StatusInternalServerError, "failed to check category tree"
+		}
+		if cycle {
+			return http.StatusBadRequest, "a category cannot be moved under itself or its descendants"
+		}
+	}
+	return 0, ""
+}
+
+func (s *Server) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if status, msg := s.validateCategory(r.Context(), &req, 0); status != 0 {
+		respondError(w, status, msg)
+		return
+	}
+	item, err := s.queries.CreateCategory(r.Context(), sqlc.CreateCategoryParams{
+		Name:     req.Name,
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+		Position: req.Position,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			respondError(w, http.StatusConflict, "a category with that name or slug already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to create category")
+		return
+	}
+	s.audit(r, auditEntry{Action: "category.create", TargetType: "category", TargetID: item.ID, After: item})
+	respondJSON(w, http.StatusCreated, item)
+}
+
+func (s *Server) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req categoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	before, err := s.queries.GetCategory(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "category not found")
+		return
+	}
+	if status, msg := s.validateCategory(r.Context(), &req, id); status != 0 {
+		respondError(w, status, msg)
+		return
+	}
+	item, err := s.queries.UpdateCategory(r.Context(), sqlc.UpdateCategoryParams{
+		ID:       id,
+		Name:     req.Name,
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+		Position: req.Position,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			respondError(w, http.StatusConflict, "a category with that name or slug already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to update category")
+		return
+	}
+	s.audit(r, auditEntry{Action: "category.update", TargetType: "category", TargetID: id, Before: before, After: item})
+	respondJSON(w, http.StatusOK, item)
+}
+
+// handleDeleteCategory removes an empty-of-children category; its listing and
+// auction assignments go with it.
+func (s *Server) handleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	before, err := s.queries.GetCategory(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "category not found")
+		return
+	}
+	children, err := s.queries.ListChildCategories(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to delete category")
+		return
+	}
+	if len(children) > 0 {
+		respondError(w, http.StatusConflict, "category has subcategories")
+		return
+	}
+	if err := s.queries.DeleteCategory(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to delete category")
+		return
+	}
+	s.audit(r, auditEntry{Action: "category.delete", TargetType: "category", TargetID: id, Before: before})
+	respondJSON(w, http.StatusOK, map[string]any{"deleted": id})
+}
+
+type setCategoriesRequest struct {
+	CategoryIDs []int64 `json:"category_ids"`
+}
+
+// handleSetListingCategories replaces a listing's categories.
+func (s *Server) handleSetListingCategories(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.GetListing(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	s.setCategories(w, r, "listing", id, s.queries.ListCategoriesForListing, func(q *sqlc.Queries, categoryIDs []int64) error {
+		if err := q.ClearListingCategories(r.Context(), id); err != nil {
+			return err
+		}
+		for _, c := range categoryIDs {
+			if err := q.AddListingCategory(r.Context(), id, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// handleSetAuctionCategories replaces an auction's categories.
+func (s *Server) handleSetAuctionCategories(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.GetAuction(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+	s.setCategories(w, r, "auction", id, s.queries.ListCategoriesForAuction, func(q *sqlc.Queries, categoryIDs []int64) error {
+		if err := q.ClearAuctionCategories(r.Context(), id); err != nil {
+			return err
+		}
+		for _, c := range categoryIDs {
+			if err := q.AddAuctionCategory(r.Context(), id, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Server) setCategories(
+	w http.ResponseWriter, r *http.Request, targetType string, id int64,
+	list func(context.Context, int64) ([]sqlc.Category, error),
+	replace func(q *sqlc.Queries, categoryIDs []int64) error,
+) {
+	var req setCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	for _, c := range req.CategoryIDs {
+		if _, err := s.queries.GetCategory(r.Context(), c); err != nil {
+			respondError(w, http.StatusBadRequest, "unknown category id")
+			return
+		}
+	}
+	before, _ := list(r.Context(), id)
+	if err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		return replace(q, req.CategoryIDs)
+	}); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to set categories")
+		return
+	}
+	after, err := list(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list categories")
+		return
+	}
+	s.audit(r, auditEntry{Action: targetType + ".categories", TargetType: targetType, TargetID: id, Before: before, After: after})
+	respondJSON(w, http.StatusOK, after)
+}
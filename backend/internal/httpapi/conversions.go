@@ -0,0 +1,429 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// Listing statuses set by conversions. A listing sent to auction leaves the
+// catalog as "auction" (shown as "en subasta") until the auction ends.
+const (
+	listingStatusInAuction = "auction"
+	listingStatusSold      = "sold"
+	listingStatusWithdrawn = "inactive"
+)
+
+var errListingUnavailable = errors.New("listing is already in an auction or sold")
+
+type convertListingRequest struct {
+	createAuctionRequest
+	// ReturnIfUnsold puts the listing back in the catalog automatically if
+	// the auction ends without a sale; otherwise it is withdrawn.
+	ReturnIfUnsold int64 `json:"return_if_unsold"`
+}
+
+// handleConvertListingToAuction creates an auction from a listing. Title,
+// description, location and image default to the listing's; categories,
+// specifications, photos and attachments are copied. The body takes the
+// same auction settings as POST /api/admin/auctions, with end_time required.
+func (s *Server) handleConvertListingToAuction(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req convertListingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.ReturnIfUnsold != 0 && req.ReturnIfUnsold != 1 {
+		respondError(w, http.StatusBadRequest, "return_if_unsold must be 0 or 1")
+		return
+	}
+	listing, err := s.queries.GetListing(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "listing not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load listing")
+		return
+	}
+	switch listing.Status {
+	case listingStatusInAuction:
+		respondError(w, http.StatusConflict, "listing is already in an auction")
+		return
+	case listingStatusSold:
+		respondError(w, http.StatusConflict, "listing is sold")
+		return
+	}
+	if req.EndTime == "" {
+		respondError(w, http.StatusBadRequest, "end_time is required")
+		return
+	}
+	if msg := auctionTimesError(req.StartTime, req.EndTime); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if req.Title == "" {
+		req.Title = listing.Title
+	}
+	if req.Description == "" {
+		req.Description = listing.Description
+	}
+	if req.Location == "" {
+		req.Location = listing.Location
+	}
+	if req.ImageURL == "" {
+		req.ImageURL = listing.ImageURL
+	}
+	params, msg := req.params()
+	if msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	media, err := s.copyItemMedia(r.Context(), "listing", listing.ID, "auction")
+	if err != nil {
+		s.logger.Error().Err(err).Int64("listing_id", id).Msg("failed to copy listing media")
+		respondError(w, http.StatusInternalServerError, "failed to create auction")
+		return
+	}
+	var auction sqlc.Auction
+	var link sqlc.ListingAuctionLink
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		// The status check above is only a fast path; claiming the listing
+		// here keeps two concurrent conversions from both going through.
+		sent, err := q.SendListingToAuction(r.Context(), listing.ID)
+		if err != nil {
+			return err
+		}
+		if sent == 0 {
+			return errListingUnavailable
+		}
+		auction, err = q.CreateAuction(r.Context(), params)
+		if err != nil {
+			return err
+		}
+		link, err = q.CreateListingAuctionLink(r.Context(), sqlc.CreateListingAuctionLinkParams{
+			AuctionID:      auction.ID,
+			ListingID:      listing.ID,
+			ReturnIfUnsold: req.ReturnIfUnsold,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.CopyListingCategoriesToAuction(r.Context(), listing.ID, auction.ID); err != nil {
+			return err
+		}
+		if err := q.CopyListingSpecsToAuction(r.Context(), listing.ID, auction.ID); err != nil {
+			return err
+		}
+		return media.insert(r.Context(), q, "auction", auction.ID)
+	})
+	if err != nil {
+		s.deleteStoredKeys(r.Context(), media.keys)
+		if errors.Is(err, errListingUnavailable) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		s.logger.Error().Err(err).Int64("listing_id", id).Msg("failed to convert listing")
+		respondError(w, http.StatusInternalServerError, "failed to create auction")
+		return
+	}
+	if err := s.syncGalleryCover(r.Context(), "auction", auction.ID); err != nil {
+		s.logger.Warn().Err(err).Msg("failed to update cover image")
+	}
+	if updated, err := s.queries.GetAuction(r.Context(), auction.ID); err == nil {
+		auction = updated
+	}
+	s.audit(r, auditEntry{Action: "listing.convert_to_auction", TargetType: "listing", TargetID: listing.ID, Before: listing, After: link})
+	respondJSON(w, http.StatusCreated, map[string]any{"auction": auction, "link": link})
+}
+
+type returnToListingRequest struct {
+	// Price reprices the listing; 0 keeps the listing's price, or for an
+	// auction without a listing uses the reserve price.
+	Price int64 `json:"price"`
+}
+
+// handleReturnAuctionToListing puts an auction that ended without a sale back
+// in the listing catalog. An auction created from a listing reactivates that
+// listing; any other auction gets a new direct-sale listing with its data,
+// categories, specifications and media.
+func (s *Server) handleReturnAuctionToListing(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req returnToListingRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	if req.Price < 0 {
+		respondError(w, http.StatusBadRequest, "price must not be negative")
+		return
+	}
+	auction, err := s.queries.GetAuction(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "auction not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load auction")
+		return
+	}
+	if auction.Status != "closed" {
+		respondError(w, http.StatusConflict, "only closed auctions can return to the catalog")
+		return
+	}
+	if auction.HighestBidderID != 0 && (auction.ReservePrice == 0 || auction.CurrentBid >= auction.ReservePrice) {
+		respondError(w, http.StatusConflict, "auction sold")
+		return
+	}
+	link, err := s.queries.GetLinkForAuction(r.Context(), id)
+	switch {
+	case err == nil && link.Outcome == "returned":
+		respondError(w, http.StatusConflict, "auction already returned to the catalog")
+		return
+	case err == nil && link.Outcome == "sold":
+		respondError(w, http.StatusConflict, "auction sold")
+		return
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		respondError(w, http.StatusInternalServerError, "failed to load auction")
+		return
+	}
+	linked := err == nil
+
+	// Media is copied only into a new listing; a linked listing still has its own.
+	media := &itemMedia{}
+	if !linked {
+		media, err = s.copyItemMedia(r.Context(), "auction", id, "listing")
+		if err != nil {
+			s.logger.Error().Err(err).Int64("auction_id", id).Msg("failed to copy auction media")
+			respondError(w, http.StatusInternalServerError, "failed to return auction to catalog")
+			return
+		}
+	}
+	var listing sqlc.Listing
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		if linked {
+			listing, err = q.RelistListing(r.Context(), link.ListingID, req.Price)
+			if err != nil {
+				return err
+			}
+			return q.ResolveListingAuctionLink(r.Context(), id, "returned")
+		}
+		price := req.Price
+		if price == 0 {
+			price = auction.ReservePrice
+		}
+		listing, err = q.CreateListing(r.Context(), sqlc.CreateListingParams{
+			Title:       auction.Title,
+			Description: auction.Description,
+			Location:    auction.Location,
+			Price:       price,
+			SaleType:    "direct",
+			Status:      "active",
+			ImageURL:    auction.ImageURL,
+		})
+		if err != nil {
+			return err
+		}
+		link, err = q.CreateListingAuctionLink(r.Context(), sqlc.CreateListingAuctionLinkParams{
+			AuctionID: id,
+			ListingID: listing.ID,
+			Outcome:   "returned",
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.CopyAuctionCategoriesToListing(r.Context(), id, listing.ID); err != nil {
+			return err
+		}
+		if err := q.CopyAuctionSpecsToListing(r.Context(), id, listing.ID); err != nil {
+			return err
+		}
+		return media.insert(r.Context(), q, "listing", listing.ID)
+	})
+	if err != nil {
+		s.deleteStoredKeys(r.Context(), media.keys)
+		s.logger.Error().Err(err).Int64("auction_id", id).Msg("failed to return auction to catalog")
+		respondError(w, http.StatusInternalServerError, "failed to return auction to catalog")
+		return
+	}
+	if !linked {
+		if err := s.syncGalleryCover(r.Context(), "listing", listing.ID); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to update cover image")
+		}
+		if updated, err := s.queries.GetListing(r.Context(), listing.ID); err == nil {
+			listing = updated
+		}
+	}
+	s.audit(r, auditEntry{Action: "auction.return_to_listing", TargetType: "auction", TargetID: id, After: listing})
+	respondJSON(w, http.StatusOK, map[string]any{"listing": listing, "link": link})
+}
+
+// itemMedia is a copy of an item's photos and attachments whose files have
+// already been duplicated in storage, so either item can later be deleted on
+// its own. Copying happens before the conversion's transaction, which only
+// inserts the rows (see insert); keys lists the new files for deletion if
+// the transaction fails.
+type itemMedia struct {
+	images      []copiedImage
+	attachments []sqlc.ItemAttachment
+	keys        []string
+}
+
+type copiedImage struct {
+	image    sqlc.ItemImage
+	variants []sqlc.ImageVariant
+}
+
+// copyItemMedia duplicates the stored files of an item's photos and
+// attachments. The target item does not exist yet, so the new keys are
+// grouped by item type only. On error the files copied so far are deleted.
+func (s *Server) copyItemMedia(ctx context.Context, fromType string, fromID int64, toType string) (*itemMedia, error) {
+	m := &itemMedia{}
+	if err := s.copyItemFiles(ctx, fromType, fromID, toType, m); err != nil {
+		s.deleteStoredKeys(ctx, m.keys)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *Server) copyItemFiles(ctx context.Context, fromType string, fromID int64, toType string, m *itemMedia) error {
+	images, err := s.queries.ListItemImages(ctx, fromType, fromID)
+	if err != nil {
+		return err
+	}
+	variants, err := s.queries.ListItemImageVariants(ctx, fromType, fromID)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		token, err := storageToken()
+		if err != nil {
+			return err
+		}
+		c := copiedImage{image: img}
+		for _, v := range variants {
+			if v.ImageID != img.ID {
+				continue
+			}
+			key := fmt.Sprintf("images/%s/%s/%s%s", toType, token, v.Name, path.Ext(v.StorageKey))
+			size, err := s.copyStoredFile(ctx, v.StorageKey, key)
+			if err != nil {
+				return err
+			}
+			m.keys = append(m.keys, key)
+			v.StorageKey = key
+			v.SizeBytes = size
+			c.variants = append(c.variants, v)
+		}
+		m.images = append(m.images, c)
+	}
+
+	attachments, err := s.queries.ListItemAttachments(ctx, fromType, fromID)
+	if err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		token, err := storageToken()
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("attachments/%s/%s%s", toType, token, path.Ext(a.StorageKey))
+		size, err := s.copyStoredFile(ctx, a.StorageKey, key)
+		if err != nil {
+			return err
+		}
+		m.keys = append(m.keys, key)
+		a.StorageKey = key
+		a.SizeBytes = size
+		m.attachments = append(m.attachments, a)
+	}
+	return nil
+}
+
+// insert adds the copied photos and attachments to an item.
+func (m *itemMedia) insert(ctx context.Context, q *sqlc.Queries, itemType string, itemID int64) error {
+	for _, c := range m.images {
+		created, err := q.CreateItemImage(ctx, sqlc.CreateItemImageParams{
+			ItemType: itemType,
+			ItemID:   itemID,
+			Caption:  c.image.Caption,
+			Filename: c.image.Filename,
+		})
+		if err != nil {
+			return err
+		}
+		for _, v := range c.variants {
+			v.ImageID = created.ID
+			if err := q.AddImageVariant(ctx, v); err != nil {
+				return err
+			}
+		}
+		if c.image.IsPrimary == 1 {
+			if err := q.SetPrimaryItemImage(ctx, itemType, itemID, created.ID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, a := range m.attachments {
+		if _, err := q.CreateItemAttachment(ctx, sqlc.CreateItemAttachmentParams{
+			ItemType:    itemType,
+			ItemID:      itemID,
+			Title:       a.Title,
+			Visibility:  a.Visibility,
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			SizeBytes:   a.SizeBytes,
+			StorageKey:  a.StorageKey,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) copyStoredFile(ctx context.Context, from, to string) (int64, error) {
+	f, err := s.storage.Open(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return s.storage.Put(ctx, to, f)
+}
+
+func (s *Server) deleteStoredKeys(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(context.WithoutCancel(ctx), key); err != nil {
+			s.logger.Warn().Err(err).Str("key", key).Msg("failed to delete copied file")
+		}
+	}
+}
+
+func storageToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,283 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/storage"
+)
+
+// requiredDocuments are the KYC documents every registration must have
+// approved before the account itself can be approved.
+var requiredDocuments = []string{
+	"registration_sheet",
+	"tax_certificate",
+	"representative_id",
+	"proof_of_address",
+}
+
+// maxDocumentSize bounds a single upload.
+const maxDocumentSize = 10 << 20
+
+// documentTypes maps accepted content types to the extension files are
+// stored with. The type is sniffed from the content, not the client header.
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+func validDocumentType(docType string) bool {
+	for _, t := range requiredDocuments {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleUploadDocument(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	docType := chi.URLParam(r, "type")
+	if !validDocumentType(docType) {
+		respondError(w, http.StatusBadRequest, "unknown document type")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDocumentSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		respondError(w, http.StatusBadRequest, "expected multipart form with a file field")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+	if header.Size > maxDocumentSize {
+		respondError(w, http.StatusRequestEntityTooLarge, "file exceeds 10 MB")
+		return
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		respondError(w, http.StatusBadRequest, "failed to read file")
+		return
+	}
+	sniff = sniff[:n]
+	contentType := http.DetectContentType(sniff)
+	ext, ok := documentTypes[contentType]
+	if !ok {
+		respondError(w, http.StatusUnsupportedMediaType, "documents must be PDF, JPEG or PNG")
+		return
+	}
+
+	key, err := documentKey(claims.UserID, docType, ext)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to store document")
+		return
+	}
+	size, err := s.storage.Put(r.Context(), key, io.MultiReader(bytes.NewReader(sniff), file))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to store document")
+		respondError(w, http.StatusInternalServerError, "failed to store document")
+		return
+	}
+
+	previous, prevErr := s.queries.GetUserDocumentByType(r.Context(), claims.UserID, docType)
+	doc, err := s.queries.UpsertUserDocument(r.Context(), sqlc.UpsertUserDocumentParams{
+		UserID:      claims.UserID,
+		DocType:     docType,
+		Filename:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		SizeBytes:   size,
+		StorageKey:  key,
+	})
+	if err != nil {
+		_ = s.storage.Delete(context.WithoutCancel(r.Context()), key)
+		respondError(w, http.StatusInternalServerError, "failed to save document")
+		return
+	}
+	if prevErr == nil && previous.StorageKey != key {
+		if err := s.storage.Delete(r.Context(), previous.StorageKey); err != nil {
+			s.logger.Warn().Err(err).Str("key", previous.StorageKey).Msg("failed to delete replaced document")
+		}
+	}
+	respondJSON(w, http.StatusCreated, doc)
+}
+
+func documentKey(userID int64, docType, ext string) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("documents/%d/%s-%s%s", userID, docType, hex.EncodeToString(suffix), ext), nil
+}
+
+func (s *Server) handleDownloadMyDocument(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	doc, err := s.queries.GetUserDocumentByType(r.Context(), claims.UserID, chi.URLParam(r, "type"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	s.serveDocument(w, r, doc)
+}
+
+func (s *Server) handleAdminListDocuments(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	docs, err := s.queries.ListUserDocuments(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list documents")
+		return
+	}
+	respondJSON(w, http.StatusOK, documentChecklist(docs))
+}
+
+func (s *Server) handleAdminDownloadDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.loadUserDocument(w, r)
+	if !ok {
+		return
+	}
+	s.serveDocument(w, r, doc)
+}
+
+type reviewDocumentRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+func (s *Server) handleReviewDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.loadUserDocument(w, r)
+	if !ok {
+		return
+	}
+	var req reviewDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.Status != "approved" && req.Status != "rejected" {
+		respondError(w, http.StatusBadRequest, "status must be approved or rejected")
+		return
+	}
+	if req.Status == "rejected" && strings.TrimSpace(req.Note) == "" {
+		respondError(w, http.StatusBadRequest, "note is required when rejecting")
+		return
+	}
+	updated, err := s.queries.ReviewUserDocument(r.Context(), sqlc.ReviewUserDocumentParams{
+		ID:         doc.ID,
+		Status:     req.Status,
+		ReviewNote: strings.TrimSpace(req.Note),
+		ReviewedBy: reviewerID(r),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to review document")
+		return
+	}
+	s.audit(r, auditEntry{Action: "document.review", TargetType: "user_document", TargetID: doc.ID, Before: doc, After: updated})
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// loadUserDocument resolves the {docId} URL parameter, making sure it belongs
+// to the user in {id}.
+func (s *Server) loadUserDocument(w http.ResponseWriter, r *http.Request) (sqlc.UserDocument, bool) {
+	userID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return sqlc.UserDocument{}, false
+	}
+	docID, err := parseID(r, "docId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid document id")
+		return sqlc.UserDocument{}, false
+	}
+	doc, err := s.queries.GetUserDocument(r.Context(), docID)
+	if err != nil || doc.UserID != userID {
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "document not found")
+			return sqlc.UserDocument{}, false
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load document")
+		return sqlc.UserDocument{}, false
+	}
+	return doc, true
+}
+
+func (s *Server) serveDocument(w http.ResponseWriter, r *http.Request, doc sqlc.UserDocument) {
+	f, err := s.storage.Open(r.Context(), doc.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "document file missing")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to open document")
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", doc.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = io.Copy(w, f)
+}
+
+// documentChecklist lists every required document with its upload, if any.
+func documentChecklist(docs []sqlc.UserDocument) []map[string]any {
+	byType := make(map[string]sqlc.UserDocument, len(docs))
+	for _, d := range docs {
+		byType[d.DocType] = d
+	}
+	out := make([]map[string]any, 0, len(requiredDocuments))
+	for _, t := range requiredDocuments {
+		item := map[string]any{"doc_type": t, "status": "missing", "document": nil}
+		if d, ok := byType[t]; ok {
+			item["status"] = d.Status
+			item["document"] = d
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// pendingDocuments returns the required document types that are missing or
+// not yet approved for a user.
+func (s *Server) pendingDocuments(ctx context.Context, userID int64) ([]string, error) {
+	docs, err := s.queries.ListUserDocuments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, item := range documentChecklist(docs) {
+		if item["status"] != "approved" {
+			pending = append(pending, item["doc_type"].(string))
+		}
+	}
+	return pending, nil
+}
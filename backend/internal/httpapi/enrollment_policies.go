@@ -0,0 +1,254 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/mailer"
+)
+
+var (
+	errEnrollmentClosed = errors.New("enrollment for this auction has closed")
+	errEnrollmentFull   = errors.New("this auction has reached its enrollment limit")
+)
+
+// enrollmentDecision evaluates an auction's policy for a user who wants to
+// enroll. It returns the status the enrollment starts in, or an error when
+// enrollment is not possible at all. The enrollee cap is checked separately,
+// inside the insert transaction.
+func enrollmentDecision(policy sqlc.EnrollmentPolicy, auction sqlc.Auction, user sqlc.User, now time.Time) (string, error) {
+	if policy.DeadlineMinutesBeforeStart > 0 && auction.StartTime != "" {
+		if start, err := time.Parse(time.RFC3339, auction.StartTime); err == nil {
+			deadline := start.Add(-time.Duration(policy.DeadlineMinutesBeforeStart) * time.Minute)
+			if !now.Before(deadline) {
+				return "", errEnrollmentClosed
+			}
+		}
+	}
+	if policy.ManualReviewAbove > 0 && auctionValue(auction) >= policy.ManualReviewAbove {
+		return "pending", nil
+	}
+	for _, tier := range splitTiers(policy.AutoApproveTiers) {
+		if tier == user.GuaranteeTier {
+			return "approved", nil
+		}
+	}
+	return "pending", nil
+}
+
+// auctionValue is what a lot is judged by for manual review: the fixed price
+// for direct sales, otherwise the higher of reserve and current bid.
+func auctionValue(a sqlc.Auction) int64 {
+	if a.SaleMode == "fixed" {
+		return a.FixedPrice
+	}
+	if a.CurrentBid > a.ReservePrice {
+		return a.CurrentBid
+	}
+	return a.ReservePrice
+}
+
+func splitTiers(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+func enrollmentPolicyResponse(p sqlc.EnrollmentPolicy, configured bool) map[string]any {
+	tiers := splitTiers(p.AutoApproveTiers)
+	if tiers == nil {
+		tiers = []string{}
+	}
+	return map[string]any{
+		"auction_id":                    p.AuctionID,
+		"configured":                    configured,
+		"auto_approve_tiers":            tiers,
+		"manual_review_above":           p.ManualReviewAbove,
+		"max_enrollees":                 p.MaxEnrollees,
+		"deadline_minutes_before_start": p.DeadlineMinutesBeforeStart,
+		"waitlist":                      p.Waitlist == 1,
+		"updated_at":                    p.UpdatedAt,
+	}
+}
+
+// loadEnrollmentPolicy returns the auction's policy, or the permissive default
+// (manual review, no cap, no deadline) when none is configured.
+func loadEnrollmentPolicy(r *http.Request, q *sqlc.Queries, auctionID int64) (sqlc.EnrollmentPolicy, bool, error) {
+	policy, err := q.GetEnrollmentPolicy(r.Context(), auctionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sqlc.EnrollmentPolicy{AuctionID: auctionID}, false, nil
+	}
+	return policy, err == nil, err
+}
+
+func (s *Server) handleGetEnrollmentPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.GetAuction(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+	policy, configured, err := loadEnrollmentPolicy(r, s.queries, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load enrollment policy")
+		return
+	}
+	respondJSON(w, http.StatusOK, enrollmentPolicyResponse(policy, configured))
+}
+
+type enrollmentPolicyRequest struct {
+	AutoApproveTiers           []string `json:"auto_approve_tiers"`
+	ManualReviewAbove          int64    `json:"manual_review_above"`
+	MaxEnrollees               int64    `json:"max_enrollees"`
+	DeadlineMinutesBeforeStart int64    `json:"deadline_minutes_before_start"`
+	Waitlist                   bool     `json:"waitlist"`
+}
+
+func (s *Server) handlePutEnrollmentPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req enrollmentPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	seen := map[string]bool{}
+	var tiers []string
+	for _, t := range req.AutoApproveTiers {
+		if t != "50k" && t != "100k" {
+			respondError(w, http.StatusBadRequest, "auto_approve_tiers may only contain 50k and 100k")
+			return
+		}
+		if !seen[t] {
+			seen[t] = true
+			tiers = append(tiers, t)
+		}
+	}
+	if req.ManualReviewAbove < 0 || req.MaxEnrollees < 0 || req.DeadlineMinutesBeforeStart < 0 {
+		respondError(w, http.StatusBadRequest, "limits must not be negative")
+		return
+	}
+	if _, err := s.queries.GetAuction(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+	waitlist := int64(0)
+	if req.Waitlist {
+		waitlist = 1
+	}
+	before, configured, _ := loadEnrollmentPolicy(r, s.queries, id)
+	policy, err := s.queries.UpsertEnrollmentPolicy(r.Context(), sqlc.UpsertEnrollmentPolicyParams{
+		AuctionID:                  id,
+		AutoApproveTiers:           strings.Join(tiers, ","),
+		ManualReviewAbove:          req.ManualReviewAbove,
+		MaxEnrollees:               req.MaxEnrollees,
+		DeadlineMinutesBeforeStart: req.DeadlineMinutesBeforeStart,
+		Waitlist:                   waitlist,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to save enrollment policy")
+		return
+	}
+	s.audit(r, auditEntry{
+		Action:     "auction.enrollment_policy.update",
+		TargetType: "auction",
+		TargetID:   id,
+		Before:     enrollmentPolicyResponse(before, configured),
+		After:      enrollmentPolicyResponse(policy, true),
+	})
+	respondJSON(w, http.StatusOK, enrollmentPolicyResponse(policy, true))
+}
+
+func (s *Server) handleDeleteEnrollmentPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	before, configured, err := loadEnrollmentPolicy(r, s.queries, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load enrollment policy")
+		return
+	}
+	if !configured {
+		respondError(w, http.StatusNotFound, "no enrollment policy configured")
+		return
+	}
+	if err := s.queries.DeleteEnrollmentPolicy(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to delete enrollment policy")
+		return
+	}
+	s.audit(r, auditEntry{Action: "auction.enrollment_policy.delete", TargetType: "auction", TargetID: id, Before: enrollmentPolicyResponse(before, true)})
+	respondJSON(w, http.StatusOK, map[string]any{"deleted": id})
+}
+
+// promoteWaitlist gives a seat freed by a rejection to the oldest waitlisted
+// enrollment. The tier rules apply as if they had just enrolled; the deadline
+// does not, since they asked in time. Failures are logged, not surfaced: the
+// rejection that triggered this already succeeded.
+func (s *Server) promoteWaitlist(r *http.Request, auctionID int64) {
+	var promoted sqlc.AuctionEnrollment
+	var user sqlc.User
+	err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		policy, _, err := loadEnrollmentPolicy(r, q, auctionID)
+		if err != nil {
+			return err
+		}
+		if policy.MaxEnrollees > 0 {
+			count, err := q.CountActiveEnrollments(r.Context(), auctionID)
+			if err != nil {
+				return err
+			}
+			if count >= policy.MaxEnrollees {
+				return nil
+			}
+		}
+		next, err := q.NextWaitlistedEnrollment(r.Context(), auctionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		auction, err := q.GetAuction(r.Context(), auctionID)
+		if err != nil {
+			return err
+		}
+		if user, err = q.GetUserByID(r.Context(), next.UserID); err != nil {
+			return err
+		}
+		policy.DeadlineMinutesBeforeStart = 0
+		status, err := enrollmentDecision(policy, auction, user, time.Now())
+		if err != nil {
+			return err
+		}
+		promoted, err = q.SetEnrollmentStatus(r.Context(), status, next.ID)
+		return err
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Int64("auction_id", auctionID).Msg("failed to promote waitlisted enrollment")
+		return
+	}
+	if promoted.ID == 0 {
+		return
+	}
+	body := "Hola,\n\nSe liberó un lugar en una subasta en la que estabas en lista de espera. "
+	if promoted.Status == "approved" {
+		body += "Tu inscripción ya está aprobada.\n"
+	} else {
+		body += "Tu inscripción está pendiente de aprobación.\n"
+	}
+	s.sendMail(mailer.Message{To: user.Email, Subject: "MAQZONE: saliste de la lista de espera", Body: body})
+}
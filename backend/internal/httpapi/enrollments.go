@@ -0,0 +1,208 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// maxBulkEnrollments bounds one bulk request so a loose filter cannot touch
+// every enrollment in the system by accident.
+const maxBulkEnrollments = 1000
+
+type bulkEnrollmentRequest struct {
+	Action     string  `json:"action"`
+	AuctionIDs []int64 `json:"auction_ids"`
+	// UserIDs optionally restricts the batch to specific users.
+	UserIDs        []int64 `json:"user_ids"`
+	Status         string  `json:"status"`
+	GuaranteeTier  string  `json:"guarantee_tier"`
+	RegisteredFrom string  `json:"registered_from"`
+	RegisteredTo   string  `json:"registered_to"`
+	DryRun         bool    `json:"dry_run"`
+}
+
+type bulkEnrollmentResult struct {
+	EnrollmentID int64  `json:"enrollment_id"`
+	AuctionID    int64  `json:"auction_id"`
+	UserID       int64  `json:"user_id"`
+	Email        string `json:"email"`
+	From         string `json:"from"`
+	To           string `json:"to,omitempty"`
+	// Result is approved, rejected, would_approve, would_reject, skipped or
+	// failed; Reason explains the last two.
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// handleBulkEnrollments approves or rejects every enrollment matching a
+// filter across one or more auctions. With dry_run nothing changes and the
+// results say what would happen. Items are applied one by one, so a failure
+// on one enrollment does not undo the rest.
+func (s *Server) handleBulkEnrollments(w http.ResponseWriter, r *http.Request) {
+	var req bulkEnrollmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	s.runBulkEnrollments(w, r, req)
+}
+
+// handleBulkAuctionEnrollments is handleBulkEnrollments scoped to the auction
+// in the path.
+func (s *Server) handleBulkAuctionEnrollments(w http.ResponseWriter, r *http.Request) {
+	auctionID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid auction id")
+		return
+	}
+	var req bulkEnrollmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.AuctionIDs = []int64{auctionID}
+	s.runBulkEnrollments(w, r, req)
+}
+
+func (s *Server) runBulkEnrollments(w http.ResponseWriter, r *http.Request, req bulkEnrollmentRequest) {
+	target := ""
+	switch req.Action {
+	case "approve":
+		target = "approved"
+	case "reject":
+		target = "rejected"
+	default:
+		respondError(w, http.StatusBadRequest, "action must be approve or reject")
+		return
+	}
+	if len(req.AuctionIDs) == 0 {
+		respondError(w, http.StatusBadRequest, "auction_ids is required")
+		return
+	}
+	switch req.Status {
+	case "", "pending", "approved", "rejected", "waitlisted":
+	default:
+		respondError(w, http.StatusBadRequest, "status must be pending, approved, rejected or waitlisted")
+		return
+	}
+	switch req.GuaranteeTier {
+	case "", "50k", "100k":
+	default:
+		respondError(w, http.StatusBadRequest, "guarantee_tier must be 50k or 100k")
+		return
+	}
+	if !validFilterDate(req.RegisteredFrom) || !validFilterDate(req.RegisteredTo) {
+		respondError(w, http.StatusBadRequest, "registered_from and registered_to must be RFC3339 timestamps or YYYY-MM-DD dates")
+		return
+	}
+
+	candidates, err := s.queries.ListEnrollmentsByFilter(r.Context(), sqlc.EnrollmentFilter{
+		AuctionIDs:     req.AuctionIDs,
+		Status:         req.Status,
+		GuaranteeTier:  req.GuaranteeTier,
+		RegisteredFrom: req.RegisteredFrom,
+		RegisteredTo:   req.RegisteredTo,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list enrollments")
+		return
+	}
+	if len(req.UserIDs) > 0 {
+		wanted := make(map[int64]bool, len(req.UserIDs))
+		for _, id := range req.UserIDs {
+			wanted[id] = true
+		}
+		filtered := candidates[:0]
+		for _, c := range candidates {
+			if wanted[c.UserID] {
+				filtered = append(filtered, c)
+			}
+		}
+		candidates = filtered
+	}
+	if len(candidates) > maxBulkEnrollments {
+		respondError(w, http.StatusBadRequest, "filter matches "+strconv.Itoa(len(candidates))+" enrollments; narrow it to at most "+strconv.Itoa(maxBulkEnrollments))
+		return
+	}
+
+	results := make([]bulkEnrollmentResult, 0, len(candidates))
+	counts := map[string]int{}
+	for _, c := range candidates {
+		res := bulkEnrollmentResult{
+			EnrollmentID: c.ID,
+			AuctionID:    c.AuctionID,
+			UserID:       c.UserID,
+			Email:        c.Email,
+			From:         c.Status,
+		}
+		switch {
+		case c.Status == target:
+			res.Result, res.Reason = "skipped", "already "+target
+		case target == "approved" && c.UserClosedAt != "":
+			res.Result, res.Reason = "skipped", "account closed"
+		case target == "approved" && c.UserStatus != "approved":
+			res.Result, res.Reason = "skipped", "account "+c.UserStatus
+		case req.DryRun:
+			res.To, res.Result = target, "would_"+req.Action
+		default:
+			res.To = target
+			before := sqlc.AuctionEnrollment{ID: c.ID, AuctionID: c.AuctionID, UserID: c.UserID, Status: c.Status, CreatedAt: c.CreatedAt}
+			var after sqlc.AuctionEnrollment
+			if target == "approved" {
+				after, err = s.queries.ApproveEnrollment(r.Context(), c.AuctionID, c.UserID)
+			} else {
+				after, err = s.queries.RejectEnrollment(r.Context(), c.AuctionID, c.UserID)
+			}
+			if err != nil {
+				res.Result, res.Reason = "failed", "failed to update enrollment"
+				break
+			}
+			s.audit(r, auditEntry{Action: "enrollment." + req.Action, TargetType: "enrollment", TargetID: after.ID, Before: before, After: after})
+			if target == "rejected" && (before.Status == "pending" || before.Status == "approved") {
+				s.promoteWaitlist(r, after.AuctionID)
+			}
+			res.Result = target
+		}
+		counts[res.Result]++
+		results = append(results, res)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"dry_run": req.DryRun,
+		"matched": len(candidates),
+		"summary": counts,
+		"results": results,
+	})
+}
+
+// handleListMyEnrollments lists the caller's enrollments across all auctions.
+func (s *Server) handleListMyEnrollments(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	items, err := s.queries.ListMyEnrollments(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list enrollments")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+// validFilterDate accepts an empty bound, an RFC3339 timestamp or a
+// YYYY-MM-DD date; SQLite's datetime() reads all of them.
+func validFilterDate(v string) bool {
+	if v == "" {
+		return true
+	}
+	if _, err := time.Parse(time.RFC3339, v); err == nil {
+		return true
+	}
+	_, err := time.Parse(time.DateOnly, v)
+	return err == nil
+}
@@ -0,0 +1,552 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/imaging"
+	"maqzone/backend/internal/storage"
+)
+
+// maxImageSize bounds a single photo upload.
+const maxImageSize = 15 << 20
+
+// imageVariants are generated for every upload next to the original. Fit
+// variants keep the aspect ratio within size x size; crop variants are
+// square.
+var imageVariants = []struct {
+	name string
+	size int
+	crop bool
+}{
+	{name: "large", size: 1600},
+	{name: "medium", size: 800},
+	{name: "thumb", size: 240, crop: true},
+}
+
+// imageURLPrefix is where variants are served; image_url values under it were
+// set from a gallery and are cleared when the gallery empties.
+const imageURLPrefix = "/api/images/"
+
+func imageURL(imageID int64, variant string) string {
+	return fmt.Sprintf("%s%d/%s", imageURLPrefix, imageID, variant)
+}
+
+type imageVariantResponse struct {
+	sqlc.ImageVariant
+	URL string `json:"url"`
+}
+
+type imageResponse struct {
+	sqlc.ItemImage
+	Variants map[string]imageVariantResponse `json:"variants"`
+}
+
+func newImageResponse(img sqlc.ItemImage, variants []sqlc.ImageVariant) imageResponse {
+	resp := imageResponse{ItemImage: img, Variants: map[string]imageVariantResponse{}}
+	for _, v := range variants {
+		if v.ImageID == img.ID {
+			resp.Variants[v.Name] = imageVariantResponse{ImageVariant: v, URL: imageURL(img.ID, v.Name)}
+		}
+	}
+	return resp
+}
+
+// itemGallery returns an item's images in display order with their variants.
+func (s *Server) itemGallery(ctx context.Context, itemType string, itemID int64) ([]imageResponse, error) {
+	images, err := s.queries.ListItemImages(ctx, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.queries.ListItemImageVariants(ctx, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]imageResponse, 0, len(images))
+	for _, img := range images {
+		out = append(out, newImageResponse(img, variants))
+	}
+	return out, nil
+}
+
+// galleryItem loads the listing or auction a gallery belongs to and returns
+// its current image_url.
+func (s *Server) galleryItem(ctx context.Context, itemType string, itemID int64) (string, error) {
+	if itemType == "auction" {
+		a, err := s.queries.GetAuction(ctx, itemID)
+		return a.ImageURL, err
+	}
+	l, err := s.queries.GetListing(ctx, itemID)
+	return l.ImageURL, err
+}
+
+// syncGalleryCover mirrors the primary image into the item's image_url. When
+// the gallery is empty a gallery URL is cleared, while an external URL set by
+// hand is left alone.
+func (s *Server) syncGalleryCover(ctx context.Context, itemType string, itemID int64) error {
+	if err := s.queries.EnsurePrimaryItemImage(ctx, itemType, itemID); err != nil {
+		return err
+	}
+	images, err := s.queries.ListItemImages(ctx, itemType, itemID)
+	if err != nil {
+		return err
+	}
+	url := ""
+	for _, img := range images {
+		if img.IsPrimary == 1 {
+			url = imageURL(img.ID, "large")
+		}
+	}
+	current, err := s.galleryItem(ctx, itemType, itemID)
+	if err != nil {
+		return err
+	}
+	if url == current || (url == "" && !strings.HasPrefix(current, imageURLPrefix)) {
+		return nil
+	}
+	if itemType == "auction" {
+		return s.queries.SetAuctionImageURL(ctx, itemID, url)
+	}
+	return s.queries.SetListingImageURL(ctx, itemID, url)
+}
+
+// handleListImages serves GET /api/{listings,auctions}/{id}/images.
+func (s *Server) handleListImages(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		if !s.canViewGalleryItem(w, r, itemType, id) {
+			return
+		}
+		gallery, err := s.itemGallery(r.Context(), itemType, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list images")
+			return
+		}
+		respondJSON(w, http.StatusOK, gallery)
+	}
+}
+
+// canViewGalleryItem writes a 404 and returns false when the item does not
+// exist or is an invite-only auction the caller may not see.
+func (s *Server) canViewGalleryItem(w http.ResponseWriter, r *http.Request, itemType string, id int64) bool {
+	if itemType == "auction" {
+		auction, err := s.queries.GetAuction(r.Context(), id)
+		if err == nil {
+			ok, err := s.canViewAuction(r.Context(), auction)
+			if err == nil && ok {
+				return true
+			}
+		}
+		respondError(w, http.StatusNotFound, "auction not found")
+		return false
+	}
+	if _, err := s.queries.GetListing(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "listing not found")
+		return false
+	}
+	return true
+}
+
+// handleServeImage streams one variant of a gallery image. Variants never
+// change once stored, so they are cacheable indefinitely; images of unlisted
+// and invite-only auctions are kept out of shared caches, so a revoked invite
+// cannot be sidestepped through a proxy.
+func (s *Server) handleServeImage(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	img, err := s.queries.GetItemImage(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	cacheControl := "public, max-age=31536000, immutable"
+	if img.ItemType == "auction" {
+		auction, err := s.queries.GetAuction(r.Context(), img.ItemID)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "image not found")
+			return
+		}
+		if ok, err := s.canViewAuction(r.Context(), auction); err != nil || !ok {
+			respondError(w, http.StatusNotFound, "image not found")
+			return
+		}
+		if auction.Visibility != "public" {
+			cacheControl = "private, no-store"
+		}
+	}
+	variant, err := s.queries.GetImageVariant(r.Context(), id, chi.URLParam(r, "variant"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "image variant not found")
+		return
+	}
+	f, err := s.storage.Open(r.Context(), variant.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "image file missing")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to open image")
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", variant.ContentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = io.Copy(w, f)
+}
+
+// storedVariant is a variant written to storage but not yet recorded.
+type storedVariant struct {
+	name   string
+	key    string
+	width  int
+	height int
+	size   int64
+}
+
+// storeImageVariants encodes the original and every resized variant of img
+// under a fresh random prefix. On error nothing is left in storage.
+func (s *Server) storeImageVariants(ctx context.Context, itemType string, itemID int64, img *imaging.Image) ([]storedVariant, error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("images/%s/%d/%s", itemType, itemID, hex.EncodeToString(token))
+
+	names := []string{"original"}
+	images := []image.Image{img.Image}
+	for _, v := range imageVariants {
+		names = append(names, v.name)
+		if v.crop {
+			images = append(images, imaging.Fill(img.Image, v.size))
+		} else {
+			images = append(images, imaging.Fit(img.Image, v.size, v.size))
+		}
+	}
+
+	var stored []storedVariant
+	for n, name := range names {
+		var buf bytes.Buffer
+		err := img.Encode(&buf, images[n])
+		var size int64
+		key := prefix + "/" + name + img.Ext()
+		if err == nil {
+			size, err = s.storage.Put(ctx, key, &buf)
+		}
+		if err != nil {
+			s.deleteStoredVariants(ctx, stored)
+			return nil, err
+		}
+		b := images[n].Bounds()
+		stored = append(stored, storedVariant{name: name, key: key, width: b.Dx(), height: b.Dy(), size: size})
+	}
+	return stored, nil
+}
+
+func (s *Server) deleteStoredVariants(ctx context.Context, stored []storedVariant) {
+	for _, v := range stored {
+		if err := s.storage.Delete(context.WithoutCancel(ctx), v.key); err != nil {
+			s.logger.Warn().Err(err).Str("key", v.key).Msg("failed to delete image variant")
+		}
+	}
+}
+
+// handleUploadImage adds a photo to a listing or auction gallery. The
+// multipart form carries file, an optional caption and primary=true to make
+// it the cover image.
+func (s *Server) handleUploadImage(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		if _, err := s.galleryItem(r.Context(), itemType, id); err != nil {
+			respondError(w, http.StatusNotFound, itemType+" not found")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImageSize+1<<20)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			respondError(w, http.StatusBadRequest, "expected multipart form with a file field")
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		if header.Size > maxImageSize {
+			respondError(w, http.StatusRequestEntityTooLarge, "image exceeds 15 MB")
+			return
+		}
+		data, err := io.ReadAll(file)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "failed to read file")
+			return
+		}
+		img, err := imaging.Decode(data)
+		switch {
+		case errors.Is(err, imaging.ErrUnsupported):
+			respondError(w, http.StatusUnsupportedMediaType, "images must be JPEG or PNG")
+			return
+		case errors.Is(err, imaging.ErrTooLarge):
+			respondError(w, http.StatusRequestEntityTooLarge, "image dimensions are too large")
+			return
+		case err != nil:
+			respondError(w, http.StatusBadRequest, "could not decode image")
+			return
+		}
+
+		stored, err := s.storeImageVariants(r.Context(), itemType, id, img)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to store image")
+			respondError(w, http.StatusInternalServerError, "failed to store image")
+			return
+		}
+		var item sqlc.ItemImage
+		err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+			var err error
+			item, err = q.CreateItemImage(r.Context(), sqlc.CreateItemImageParams{
+				ItemType: itemType,
+				ItemID:   id,
+				Caption:  strings.TrimSpace(r.FormValue("caption")),
+				Filename: filepath.Base(header.Filename),
+			})
+			if err != nil {
+				return err
+			}
+			for _, v := range stored {
+				if err := q.AddImageVariant(r.Context(), sqlc.ImageVariant{
+					ImageID:     item.ID,
+					Name:        v.name,
+					StorageKey:  v.key,
+					ContentType: img.ContentType(),
+					Width:       int64(v.width),
+					Height:      int64(v.height),
+					SizeBytes:   v.size,
+				}); err != nil {
+					return err
+				}
+			}
+			if r.FormValue("primary") == "true" {
+				return q.SetPrimaryItemImage(r.Context(), itemType, id, item.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			s.deleteStoredVariants(r.Context(), stored)
+			respondError(w, http.StatusInternalServerError, "failed to save image")
+			return
+		}
+		if err := s.syncGalleryCover(r.Context(), itemType, id); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to update cover image")
+		}
+		item, _ = s.queries.GetItemImage(r.Context(), item.ID)
+		variants, _ := s.queries.ListImageVariants(r.Context(), item.ID)
+		resp := newImageResponse(item, variants)
+		s.audit(r, auditEntry{Action: itemType + ".image.add", TargetType: itemType, TargetID: id, After: resp})
+		respondJSON(w, http.StatusCreated, resp)
+	}
+}
+
+// loadGalleryImage reads {imageId} and checks it belongs to the {id} item.
+func (s *Server) loadGalleryImage(w http.ResponseWriter, r *http.Request, itemType string) (sqlc.ItemImage, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return sqlc.ItemImage{}, false
+	}
+	imageID, err := parseID(r, "imageId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid image id")
+		return sqlc.ItemImage{}, false
+	}
+	img, err := s.queries.GetItemImage(r.Context(), imageID)
+	if err != nil || img.ItemType != itemType || img.ItemID != id {
+		respondError(w, http.StatusNotFound, "image not found")
+		return sqlc.ItemImage{}, false
+	}
+	return img, true
+}
+
+type updateImageRequest struct {
+	// Caption is left unchanged when omitted.
+	Caption *string `json:"caption"`
+	Primary bool    `json:"primary"`
+}
+
+// handleUpdateImage edits a caption or makes an image the cover.
+func (s *Server) handleUpdateImage(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		before, ok := s.loadGalleryImage(w, r, itemType)
+		if !ok {
+			return
+		}
+		var req updateImageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+			if req.Caption != nil {
+				if _, err := q.UpdateItemImageCaption(r.Context(), before.ID, strings.TrimSpace(*req.Caption)); err != nil {
+					return err
+				}
+			}
+			if req.Primary {
+				return q.SetPrimaryItemImage(r.Context(), itemType, before.ItemID, before.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to update image")
+			return
+		}
+		if err := s.syncGalleryCover(r.Context(), itemType, before.ItemID); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to update cover image")
+		}
+		item, err := s.queries.GetItemImage(r.Context(), before.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to load image")
+			return
+		}
+		variants, _ := s.queries.ListImageVariants(r.Context(), item.ID)
+		s.audit(r, auditEntry{Action: itemType + ".image.update", TargetType: itemType, TargetID: item.ItemID, Before: before, After: item})
+		respondJSON(w, http.StatusOK, newImageResponse(item, variants))
+	}
+}
+
+type reorderImagesRequest struct {
+	ImageIDs []int64 `json:"image_ids"`
+}
+
+// handleReorderImages sets the gallery order. image_ids must list every image
+// of the item exactly once.
+func (s *Server) handleReorderImages(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseID(r, "id")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var req reorderImagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		images, err := s.queries.ListItemImages(r.Context(), itemType, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to reorder images")
+			return
+		}
+		remaining := make(map[int64]bool, len(images))
+		for _, img := range images {
+			remaining[img.ID] = true
+		}
+		for _, imageID := range req.ImageIDs {
+			if !remaining[imageID] {
+				respondError(w, http.StatusBadRequest, "image_ids must list each image of the gallery once")
+				return
+			}
+			delete(remaining, imageID)
+		}
+		if len(remaining) > 0 {
+			respondError(w, http.StatusBadRequest, "image_ids must list each image of the gallery once")
+			return
+		}
+		if err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+			for position, imageID := range req.ImageIDs {
+				if err := q.SetItemImagePosition(r.Context(), imageID, int64(position)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to reorder images")
+			return
+		}
+		gallery, err := s.itemGallery(r.Context(), itemType, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list images")
+			return
+		}
+		s.audit(r, auditEntry{Action: itemType + ".image.reorder", TargetType: itemType, TargetID: id, Before: images, After: req.ImageIDs})
+		respondJSON(w, http.StatusOK, gallery)
+	}
+}
+
+// handleDeleteImage removes an image and its stored variants. If it was the
+// cover, the next image in order takes over.
+func (s *Server) handleDeleteImage(itemType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		img, ok := s.loadGalleryImage(w, r, itemType)
+		if !ok {
+			return
+		}
+		variants, err := s.queries.ListImageVariants(r.Context(), img.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to delete image")
+			return
+		}
+		if err := s.queries.DeleteItemImage(r.Context(), img.ID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to delete image")
+			return
+		}
+		if err := s.syncGalleryCover(r.Context(), itemType, img.ItemID); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to update cover image")
+		}
+		s.deleteVariantFiles(r.Context(), variants)
+		s.audit(r, auditEntry{Action: itemType + ".image.delete", TargetType: itemType, TargetID: img.ItemID, Before: img})
+		respondJSON(w, http.StatusOK, map[string]any{"deleted": img.ID})
+	}
+}
+
+// deleteGallery drops every image of a deleted listing or auction.
+func (s *Server) deleteGallery(ctx context.Context, itemType string, itemID int64) {
+	variants, err := s.queries.ListItemImageVariants(ctx, itemType, itemID)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to list gallery for deletion")
+		return
+	}
+	images, err := s.queries.ListItemImages(ctx, itemType, itemID)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to list gallery for deletion")
+		return
+	}
+	for _, img := range images {
+		if err := s.queries.DeleteItemImage(ctx, img.ID); err != nil {
+			s.logger.Warn().Err(err).Int64("image_id", img.ID).Msg("failed to delete image")
+		}
+	}
+	s.deleteVariantFiles(ctx, variants)
+}
+
+func (s *Server) deleteVariantFiles(ctx context.Context, variants []sqlc.ImageVariant) {
+	for _, v := range variants {
+		if err := s.storage.Delete(context.WithoutCancel(ctx), v.StorageKey); err != nil {
+			s.logger.Warn().Err(err).Str("key", v.StorageKey).Msg("failed to delete image variant")
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// handleListNotifications returns the caller's alert feed, newest first, with
+// the unread count. next_cursor is the id to pass back as cursor for the next
+// page, and empty on the last page. unread=1 lists unread alerts only.
+func (s *Server) handleListNotifications(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	var before int64
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		before = n
+	}
+	limit := parseLimit(r, 20)
+	items, err := s.queries.ListNotifications(r.Context(), sqlc.ListNotificationsParams{
+		UserID:     claims.UserID,
+		UnreadOnly: r.URL.Query().Get("unread") == "1",
+		BeforeID:   before,
+		Limit:      int64(limit),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load notifications")
+		return
+	}
+	unread, err := s.queries.CountUnreadNotifications(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load notifications")
+		return
+	}
+	next := ""
+	if len(items) == limit {
+		next = strconv.FormatInt(items[len(items)-1].ID, 10)
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"items":       items,
+		"unread":      unread,
+		"next_cursor": next,
+	})
+}
+
+func (s *Server) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.MarkNotificationRead(r.Context(), claims.UserID, id); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to mark notification read")
+		return
+	}
+	unread, err := s.queries.CountUnreadNotifications(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load notifications")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"unread": unread})
+}
+
+func (s *Server) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	marked, err := s.queries.MarkAllNotificationsRead(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to mark notifications read")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"marked": marked, "unread": 0})
+}
+
+func (s *Server) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	prefs, err := s.queries.GetNotificationPreferences(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load preferences")
+		return
+	}
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+type notificationPreferencesRequest struct {
+	PriceDrops      *int64  `json:"price_drops"`
+	StatusChanges   *int64  `json:"status_changes"`
+	AuctionStarting *int64  `json:"auction_starting"`
+	AuctionEnding   *int64  `json:"auction_ending"`
+	AuctionBids     *int64  `json:"auction_bids"`
+	AuctionResults  *int64  `json:"auction_results"`
+	EmailMode       *string `json:"email_mode"`
+	WebSocket       *int64  `json:"websocket"`
+}
+
+// handleUpdateNotificationPreferences changes the fields present in the body.
+// Turning email off drops alerts still waiting to be emailed.
+func (s *Server) handleUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	var req notificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	prefs, err := s.queries.GetNotificationPreferences(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load preferences")
+		return
+	}
+	flags := []struct {
+		name  string
+		value *int64
+		dest  *int64
+	}{
+		{"price_drops", req.PriceDrops, &prefs.PriceDrops},
+		{"status_changes", req.StatusChanges, &prefs.StatusChanges},
+		{"auction_starting", req.AuctionStarting, &prefs.AuctionStarting},
+		{"auction_ending", req.AuctionEnding, &prefs.AuctionEnding},
+		{"auction_bids", req.AuctionBids, &prefs.AuctionBids},
+		{"auction_results", req.AuctionResults, &prefs.AuctionResults},
+		{"websocket", req.WebSocket, &prefs.WebSocket},
+	}
+	for _, f := range flags {
+		if f.value == nil {
+			continue
+		}
+		if *f.value != 0 && *f.value != 1 {
+			respondError(w, http.StatusBadRequest, f.name+" must be 0 or 1")
+			return
+		}
+		*f.dest = *f.value
+	}
+	if req.EmailMode != nil {
+		switch *req.EmailMode {
+		case "instant", "digest", "off":
+			prefs.EmailMode = *req.EmailMode
+		default:
+			respondError(w, http.StatusBadRequest, "email_mode must be instant, digest or off")
+			return
+		}
+	}
+
+	var updated sqlc.NotificationPreferences
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		updated, err = q.UpsertNotificationPreferences(r.Context(), sqlc.UpsertNotificationPreferencesParams{
+			UserID:          claims.UserID,
+			PriceDrops:      prefs.PriceDrops,
+			StatusChanges:   prefs.StatusChanges,
+			EmailMode:       prefs.EmailMode,
+			WebSocket:       prefs.WebSocket,
+			AuctionStarting: prefs.AuctionStarting,
+			AuctionEnding:   prefs.AuctionEnding,
+			AuctionBids:     prefs.AuctionBids,
+			AuctionResults:  prefs.AuctionResults,
+		})
+		if err != nil {
+			return err
+		}
+		if updated.EmailMode == "off" {
+			return q.SkipPendingNotificationEmails(r.Context(), claims.UserID)
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update preferences")
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
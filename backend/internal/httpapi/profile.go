@@ -0,0 +1,162 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/validation"
+)
+
+// updateProfileRequest uses pointers so omitted fields keep their value.
+type updateProfileRequest struct {
+	BusinessName        *string `json:"business_name"`
+	LegalRepresentative *string `json:"legal_representative"`
+	RFC                 *string `json:"rfc"`
+	StreetAddress       *string `json:"street_address"`
+	Colony              *string `json:"colony"`
+	Municipality        *string `json:"municipality"`
+	PostalCode          *string `json:"postal_code"`
+	City                *string `json:"city"`
+	State               *string `json:"state"`
+	Phone               *string `json:"phone"`
+	Mobile              *string `json:"mobile"`
+}
+
+func (req updateProfileRequest) apply(p *validation.Profile) {
+	set := func(dst *string, v *string) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&p.BusinessName, req.BusinessName)
+	set(&p.LegalRepresentative, req.LegalRepresentative)
+	set(&p.RFC, req.RFC)
+	set(&p.StreetAddress, req.StreetAddress)
+	set(&p.Colony, req.Colony)
+	set(&p.Municipality, req.Municipality)
+	set(&p.PostalCode, req.PostalCode)
+	set(&p.City, req.City)
+	set(&p.State, req.State)
+	set(&p.Phone, req.Phone)
+	set(&p.Mobile, req.Mobile)
+}
+
+func userProfile(u sqlc.User) validation.Profile {
+	return validation.Profile{
+		BusinessName:        u.BusinessName,
+		LegalRepresentative: u.LegalRepresentative,
+		RFC:                 u.RFC,
+		StreetAddress:       u.StreetAddress,
+		Colony:              u.Colony,
+		Municipality:        u.Municipality,
+		PostalCode:          u.PostalCode,
+		City:                u.City,
+		State:               u.State,
+		Phone:               u.Phone,
+		Mobile:              u.Mobile,
+	}
+}
+
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	var req updateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	user, err := s.queries.GetUserByID(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load user")
+		return
+	}
+
+	profile := userProfile(user)
+	req.apply(&profile)
+	if errs := profile.Normalize(); len(errs) > 0 {
+		respondValidation(w, errs)
+		return
+	}
+
+	fiscalChanged := profile.RFC != user.RFC ||
+		profile.BusinessName != user.BusinessName ||
+		profile.LegalRepresentative != user.LegalRepresentative
+	// Until the registration has been decided, the reviewer sees the fiscal
+	// data anyway, so it can still be edited directly; answering an
+	// information request often means correcting exactly these fields.
+	needsReview := fiscalChanged && user.Status != "pending" && user.Status != "info_requested"
+
+	params := sqlc.UpdateUserProfileParams{
+		BusinessName:        profile.BusinessName,
+		LegalRepresentative: profile.LegalRepresentative,
+		RFC:                 profile.RFC,
+		StreetAddress:       profile.StreetAddress,
+		Colony:              profile.Colony,
+		Municipality:        profile.Municipality,
+		PostalCode:          profile.PostalCode,
+		City:                profile.City,
+		State:               profile.State,
+		Phone:               profile.Phone,
+		Mobile:              profile.Mobile,
+		ID:                  user.ID,
+	}
+	if needsReview {
+		params.BusinessName = user.BusinessName
+		params.LegalRepresentative = user.LegalRepresentative
+		params.RFC = user.RFC
+	}
+
+	var updated sqlc.User
+	var change *sqlc.ProfileChangeRequest
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		if updated, err = q.UpdateUserProfile(r.Context(), params); err != nil {
+			return err
+		}
+		if !needsReview {
+			return nil
+		}
+		// A new request replaces any earlier one still awaiting review.
+		if err := q.SupersedeProfileChanges(r.Context(), user.ID); err != nil {
+			return err
+		}
+		c, err := q.CreateProfileChange(r.Context(), sqlc.CreateProfileChangeParams{
+			UserID:                      user.ID,
+			BusinessName:                profile.BusinessName,
+			LegalRepresentative:         profile.LegalRepresentative,
+			RFC:                         profile.RFC,
+			PreviousBusinessName:        user.BusinessName,
+			PreviousLegalRepresentative: user.LegalRepresentative,
+			PreviousRFC:                 user.RFC,
+		})
+		change = &c
+		return err
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update profile")
+		return
+	}
+	resp := userResponse(updated)
+	if change != nil {
+		resp["pending_profile_change"] = change
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleListMyProfileChanges(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	items, err := s.queries.ListProfileChangesForUser(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list profile changes")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
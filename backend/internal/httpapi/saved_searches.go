@@ -0,0 +1,347 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+const maxSavedSearches = 20
+
+type savedSearchRequest struct {
+	Name string `json:"name"`
+	// Query is a catalog query string: the filters of /api/listings and
+	// /api/auctions plus q for text and type (all, listings, auctions).
+	Query  *string `json:"query"`
+	Notify *int64  `json:"notify"`
+}
+
+// parsedSearch is a saved search query checked and reduced to what the match
+// job evaluates.
+type parsedSearch struct {
+	itemTypes string
+	query     string
+	filter    sqlc.CatalogFilter
+	match     string
+}
+
+// parseSavedSearchQuery validates a saved search query string with the
+// catalog's own parser. Sorting and paging do not apply and are dropped. On a
+// bad parameter it returns a message for a 400.
+func (s *Server) parseSavedSearchQuery(r *http.Request, raw string) (parsedSearch, string, error) {
+	var p parsedSearch
+	values, err := url.ParseQuery(strings.TrimPrefix(raw, "?"))
+	if err != nil {
+		return p, "invalid query", nil
+	}
+	for _, name := range []string{"sort", "cursor", "limit"} {
+		values.Del(name)
+	}
+	switch values.Get("type") {
+	case "", "all":
+		p.itemTypes = "all"
+	case "listings":
+		p.itemTypes = "listing"
+	case "auctions":
+		p.itemTypes = "auction"
+	default:
+		return p, "type must be all, listings or auctions", nil
+	}
+	text := values.Get("q")
+	p.match = buildMatchQuery(text)
+	if strings.TrimSpace(text) != "" && p.match == "" {
+		return p, "q has no searchable words", nil
+	}
+
+	cr := r.Clone(r.Context())
+	cr.URL.RawQuery = values.Encode()
+	c, msg, err := s.parseCatalogQuery(cr, sqlc.ListingSorts)
+	if msg != "" || err != nil {
+		return p, msg, err
+	}
+	if c.filter.CategoryID == -1 {
+		return p, "unknown category", nil
+	}
+	p.filter = c.filter
+	p.filter.ViewerID = 0
+	p.query = values.Encode()
+	return p, "", nil
+}
+
+// recordSearchBaseline records what a search matches right now, so only
+// items that start matching later count as new.
+func recordSearchBaseline(ctx context.Context, q *sqlc.Queries, search sqlc.SavedSearch) error {
+	f := search.Filter
+	f.ViewerID = search.UserID
+	for _, itemType := range search.Types() {
+		items, err := q.MatchCatalogItems(ctx, itemType, f, search.MatchQuery, nil)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if _, err := q.AddSavedSearchMatch(ctx, search.ID, itemType, item.ID, 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadOwnSavedSearch loads the {id} saved search, answering 404 when it does
+// not exist or belongs to someone else.
+func (s *Server) loadOwnSavedSearch(w http.ResponseWriter, r *http.Request) (sqlc.SavedSearch, bool) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return sqlc.SavedSearch{}, false
+	}
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return sqlc.SavedSearch{}, false
+	}
+	search, err := s.queries.GetSavedSearch(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "saved search not found")
+			return sqlc.SavedSearch{}, false
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load saved search")
+		return sqlc.SavedSearch{}, false
+	}
+	if search.UserID != claims.UserID {
+		respondError(w, http.StatusNotFound, "saved search not found")
+		return sqlc.SavedSearch{}, false
+	}
+	return search, true
+}
+
+// handleListSavedSearches returns the caller's saved searches with their
+// match_count and new_count (matches since the last visit).
+func (s *Server) handleListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	items, err := s.queries.ListSavedSearches(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load saved searches")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleCreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	var req savedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	notify := int64(1)
+	if req.Notify != nil {
+		if *req.Notify != 0 && *req.Notify != 1 {
+			respondError(w, http.StatusBadRequest, "notify must be 0 or 1")
+			return
+		}
+		notify = *req.Notify
+	}
+	raw := ""
+	if req.Query != nil {
+		raw = *req.Query
+	}
+	parsed, msg, err := s.parseSavedSearchQuery(r, raw)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load category")
+		return
+	}
+	if msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	count, err := s.queries.CountSavedSearches(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to save search")
+		return
+	}
+	if count >= maxSavedSearches {
+		respondError(w, http.StatusConflict, "saved search limit reached")
+		return
+	}
+
+	var search sqlc.SavedSearch
+	err = s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		search, err = q.CreateSavedSearch(r.Context(), sqlc.CreateSavedSearchParams{
+			UserID:     claims.UserID,
+			Name:       req.Name,
+			ItemTypes:  parsed.itemTypes,
+			Query:      parsed.query,
+			Filter:     parsed.filter,
+			MatchQuery: parsed.match,
+			Notify:     notify,
+		})
+		if err != nil {
+			return err
+		}
+		return recordSearchBaseline(r.Context(), q, search)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to save search")
+		respondError(w, http.StatusInternalServerError, "failed to save search")
+		return
+	}
+	respondJSON(w, http.StatusCreated, search)
+}
+
+// handleUpdateSavedSearch renames a search, changes its query or turns its
+// notifications on or off. A new query starts over: what it matches now is
+// the new baseline.
+func (s *Server) handleUpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, ok := s.loadOwnSavedSearch(w, r)
+	if !ok {
+		return
+	}
+	var req savedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	params := sqlc.UpdateSavedSearchParams{
+		ID:         search.ID,
+		Name:       search.Name,
+		ItemTypes:  search.ItemTypes,
+		Query:      search.Query,
+		Filter:     search.Filter,
+		MatchQuery: search.MatchQuery,
+		Notify:     search.Notify,
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		params.Name = name
+	}
+	if req.Notify != nil {
+		if *req.Notify != 0 && *req.Notify != 1 {
+			respondError(w, http.StatusBadRequest, "notify must be 0 or 1")
+			return
+		}
+		params.Notify = *req.Notify
+	}
+	requery := false
+	if req.Query != nil {
+		parsed, msg, err := s.parseSavedSearchQuery(r, *req.Query)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to load category")
+			return
+		}
+		if msg != "" {
+			respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		requery = parsed.query != search.Query
+		params.ItemTypes = parsed.itemTypes
+		params.Query = parsed.query
+		params.Filter = parsed.filter
+		params.MatchQuery = parsed.match
+	}
+
+	var updated sqlc.SavedSearch
+	err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		updated, err = q.UpdateSavedSearch(r.Context(), params)
+		if err != nil || !requery {
+			return err
+		}
+		if err := q.ResetSavedSearchMatches(r.Context(), search.ID); err != nil {
+			return err
+		}
+		return recordSearchBaseline(r.Context(), q, updated)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Int64("saved_search_id", search.ID).Msg("failed to update saved search")
+		respondError(w, http.StatusInternalServerError, "failed to update saved search")
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, ok := s.loadOwnSavedSearch(w, r)
+	if !ok {
+		return
+	}
+	if err := s.queries.DeleteSavedSearch(r.Context(), search.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to delete saved search")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"deleted": search.ID})
+}
+
+type savedSearchMatchResponse struct {
+	sqlc.SavedSearchMatch
+	Listing *sqlc.Listing `json:"listing,omitempty"`
+	Auction *sqlc.Auction `json:"auction,omitempty"`
+}
+
+// handleListSavedSearchMatches returns the items a search has matched since
+// it was saved, newest first, each flagged new until the caller marks the
+// search seen. new=1 lists only those.
+func (s *Server) handleListSavedSearchMatches(w http.ResponseWriter, r *http.Request) {
+	search, ok := s.loadOwnSavedSearch(w, r)
+	if !ok {
+		return
+	}
+	matches, err := s.queries.ListSavedSearchMatches(r.Context(), search.ID, r.URL.Query().Get("new") == "1", int64(parseLimit(r, 20)))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load matches")
+		return
+	}
+	items := make([]savedSearchMatchResponse, 0, len(matches))
+	for _, m := range matches {
+		item := savedSearchMatchResponse{SavedSearchMatch: m}
+		if m.ItemType == "listing" {
+			listing, err := s.queries.GetListing(r.Context(), m.ItemID)
+			if err != nil {
+				continue
+			}
+			item.Listing = &listing
+		} else {
+			auction, err := s.queries.GetAuction(r.Context(), m.ItemID)
+			if err != nil {
+				continue
+			}
+			item.Auction = &auction
+		}
+		items = append(items, item)
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+// handleMarkSavedSearchSeen records a visit: every match so far stops
+// counting as new.
+func (s *Server) handleMarkSavedSearchSeen(w http.ResponseWriter, r *http.Request) {
+	search, ok := s.loadOwnSavedSearch(w, r)
+	if !ok {
+		return
+	}
+	if err := s.queries.MarkSavedSearchSeen(r.Context(), search.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to mark saved search seen")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"new_count": 0})
+}
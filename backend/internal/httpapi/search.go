@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"html"
+	"math"
+	"net/http"
+	"strings"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// searchStopwords are Spanish function words dropped from queries so
+// "retroexcavadora de Caterpillar" does not require the word "de".
+var searchStopwords = map[string]bool{
+	"a": true, "al": true, "con": true, "de": true, "del": true, "el": true,
+	"en": true, "la": true, "las": true, "lo": true, "los": true, "o": true,
+	"para": true, "por": true, "sin": true, "un": true, "una": true, "y": true,
+}
+
+// buildMatchQuery turns free text into an FTS query: accents are folded,
+// stopwords dropped and Spanish plurals reduced to a prefix, so "grúas
+// Tadano" becomes "grua* tadano*". Only letters and digits survive, so user
+// input cannot inject FTS operators. It returns "" when nothing is left.
+func buildMatchQuery(q string) string {
+	q = strings.ToLower(accentFolder.Replace(q))
+	fields := strings.FieldsFunc(q, func(r rune) bool {
+		return !((r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'))
+	})
+	var terms []string
+	for _, f := range fields {
+		if searchStopwords[f] {
+			continue
+		}
+		terms = append(terms, spanishStem(f)+"*")
+	}
+	return strings.Join(terms, " ")
+}
+
+// spanishStem strips plural endings: "motores" -> "motor", "gruas" -> "grua".
+// It is deliberately light; the prefix match does the rest.
+func spanishStem(w string) string {
+	if len(w) > 5 && strings.HasSuffix(w, "es") && !strings.ContainsRune("aeiou", rune(w[len(w)-3])) {
+		return w[:len(w)-2]
+	}
+	if len(w) > 3 && strings.HasSuffix(w, "s") {
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// searchMarks turns the snippet markers into <mark> tags once the text
+// around them has been escaped.
+var searchMarks = strings.NewReplacer(sqlc.SearchMarkStart, "<mark>", sqlc.SearchMarkEnd, "</mark>")
+
+func highlight(snippet string) string {
+	return searchMarks.Replace(html.EscapeString(snippet))
+}
+
+type searchResult struct {
+	Type       string            `json:"type"`
+	ID         int64             `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+	Listing    *sqlc.Listing     `json:"listing,omitempty"`
+	Auction    *sqlc.Auction     `json:"auction,omitempty"`
+}
+
+// handleSearch ranks active listings and auctions against ?q=. ?type narrows
+// to listings or auctions; invite-only auctions only match for invitees.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	match := buildMatchQuery(r.URL.Query().Get("q"))
+	if match == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	kind := ""
+	switch r.URL.Query().Get("type") {
+	case "", "all":
+	case "listings":
+		kind = "listing"
+	case "auctions":
+		kind = "auction"
+	default:
+		respondError(w, http.StatusBadRequest, "type must be all, listings or auctions")
+		return
+	}
+	limit := parseLimit(r, 20)
+	var viewerID int64
+	if claims := GetClaims(r.Context()); claims != nil {
+		viewerID = claims.UserID
+	}
+
+	hits, err := s.queries.SearchIndex(r.Context(), match, kind, viewerID, int64(limit))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "search failed")
+		return
+	}
+	total, err := s.queries.CountSearchIndex(r.Context(), match, kind, viewerID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "search failed")
+		return
+	}
+
+	results := make([]searchResult, 0, len(hits))
+	for _, h := range hits {
+		res := searchResult{
+			Type:  h.Kind(),
+			ID:    h.ItemID(),
+			Score: math.Round(h.Score*1000) / 1000,
+			Highlights: map[string]string{
+				"title":       highlight(h.TitleSnippet),
+				"description": highlight(h.DescriptionSnippet),
+			},
+		}
+		if res.Type == "listing" {
+			item, err := s.queries.GetListing(r.Context(), res.ID)
+			if err != nil {
+				continue
+			}
+			res.Listing = &item
+		} else {
+			item, err := s.queries.GetAuction(r.Context(), res.ID)
+			if err != nil {
+				continue
+			}
+			res.Auction = &item
+		}
+		results = append(results, res)
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"query":   r.URL.Query().Get("q"),
+		"total":   total,
+		"results": results,
+	})
+}
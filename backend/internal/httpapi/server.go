@@ -14,8 +14,12 @@ import (
   "github.com/go-chi/cors"
   "github.com/rs/zerolog"
 
+  "maqzone/backend/internal/auth"
   "maqzone/backend/internal/config"
   sqlc "maqzone/backend/internal/db/sqlc"
+  "maqzone/backend/internal/mailer"
+  "maqzone/backend/internal/storage"
+  "maqzone/backend/internal/validation"
 )
 
 type Server struct {
@@ -24,10 +28,36 @@ type Server struct {
   logger  zerolog.Logger
   limiter *rateLimiter
   hub     *Hub
+  keys    *auth.KeySet
+  storage storage.Storage
+  mailer  mailer.Mailer
 }
 
 func New(cfg config.Config, queries *sqlc.Queries, logger zerolog.Logger) *Server {
-  return &Server{cfg: cfg, queries: queries, logger: logger, limiter: newRateLimiter()}
+  return &Server{
+    cfg:     cfg,
+    queries: queries,
+    logger:  logger,
+    limiter: newRateLimiter(),
+    keys:    auth.NewHMACKeySet(cfg.JWTSecret),
+    storage: storage.NewLocal(cfg.UploadDir),
+    mailer:  mailer.NewLog(logger),
+  }
+}
+
+// SetKeys replaces the default HS256 key set built from cfg.JWTSecret.
+func (s *Server) SetKeys(k *auth.KeySet) {
+  s.keys = k
+}
+
+// SetStorage replaces the default local-disk storage rooted at cfg.UploadDir.
+func (s *Server) SetStorage(st storage.Storage) {
+  s.storage = st
+}
+
+// SetMailer replaces the default mailer, which only logs messages.
+func (s *Server) SetMailer(m mailer.Mailer) {
+  s.mailer = m
 }
 
 func (s *Server) SetHub(h *Hub) {
@@ -45,7 +75,7 @@ func (s *Server) Routes() http.Handler {
 
   corsOptions := cors.Options{
     AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-    AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-Admin-Token"},
+    AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", "X-API-Key"},
     ExposedHeaders:   []string{"Link"},
     AllowCredentials: false,
     MaxAge:           300,
@@ -58,42 +88,74 @@ func (s *Server) Routes() http.Handler {
   r.Use(cors.Handler(corsOptions))
 
   r.Get("/api/health", s.handleHealth)
+  r.Get("/api/.well-known/jwks.json", s.handleJWKS)
 
   r.Route("/api/auctions", func(r chi.Router) {
+    r.Use(s.optionalUserAuth)
     r.Get("/", s.handleListAuctions)
     r.Get("/{id}", s.handleGetAuction)
+    r.Get("/{id}/images", s.handleListImages("auction"))
+    r.Get("/{id}/attachments", s.handleListAttachments("auction"))
   })
 
+  r.Get("/api/categories", s.handleListCategories)
+  r.Get("/api/categories/{slug}", s.handleGetCategory)
+  r.Get("/api/categories/{slug}/specs", s.handleListCategorySpecs)
+  r.With(s.optionalUserAuth).Get("/api/search", s.handleSearch)
+
   r.Route("/api/listings", func(r chi.Router) {
+    r.Use(s.optionalUserAuth)
     r.Get("/", s.handleListListings)
     r.Get("/{id}", s.handleGetListing)
+    r.Get("/{id}/images", s.handleListImages("listing"))
+    r.Get("/{id}/attachments", s.handleListAttachments("listing"))
   })
 
+  r.With(s.optionalUserAuth).Get("/api/images/{id}/{variant}", s.handleServeImage)
+  r.With(s.optionalUserAuth).Get("/api/attachments/{id}/file", s.handleDownloadAttachment)
+
   // Auth routes (public, rate-limited)
   r.Route("/api/auth", func(r chi.Router) {
-    r.Use(s.rateLimit)
-    r.Post("/register", s.handleRegister)
-    r.Post("/login", s.handleLogin)
+    r.Group(func(r chi.Router) {
+      r.Use(s.rateLimit)
+      r.Post("/register", s.handleRegister)
+      r.Post("/login", s.handleLogin)
+      r.Group(func(r chi.Router) {
+        r.Use(s.userAuth)
+        r.Get("/me", s.handleMe)
+        r.Put("/profile", s.handleUpdateProfile)
+        r.Get("/profile/changes", s.handleListMyProfileChanges)
+        r.Post("/registration/resubmit", s.handleResubmitRegistration)
+        r.With(s.denyImpersonation).Put("/password", s.handleChangePassword)
+        r.Get("/documents", s.handleDocuments)
+        r.Get("/export", s.handleExportMyData)
+        r.Get("/account/closure", s.handleGetMyClosure)
+        r.With(s.denyImpersonation).Post("/account/closure", s.handleRequestClosure)
+        r.With(s.denyImpersonation).Delete("/account/closure", s.handleCancelMyClosure)
+      })
+    })
+    // KYC uploads take several requests in a row, so they are not rate-limited.
     r.Group(func(r chi.Router) {
       r.Use(s.userAuth)
-      r.Get("/me", s.handleMe)
-      r.Put("/password", s.handleChangePassword)
-      r.Get("/documents", s.handleDocuments)
+      r.Post("/documents/{type}", s.handleUploadDocument)
+      r.Get("/documents/{type}/file", s.handleDownloadMyDocument)
     })
   })
 
   // Bid endpoints
   r.Route("/api/auctions/{id}/bids", func(r chi.Router) {
-    r.Get("/", s.handleListBids)
+    r.With(s.optionalUserAuth).Get("/", s.handleListBids)
     r.Group(func(r chi.Router) {
       r.Use(s.userAuth)
       r.Use(s.requireApproved)
+      r.Use(s.denyImpersonation)
       r.Post("/", s.handlePlaceBid)
     })
   })
 
   // WebSocket
   r.Get("/api/ws/auctions/{id}", s.handleWSAuction)
+  r.Get("/api/ws/me", s.handleWSUser)
 
   // Enrollment request (authenticated user)
   r.Route("/api/auctions/{id}/enroll", func(r chi.Router) {
@@ -103,6 +165,8 @@ func (s *Server) Routes() http.Handler {
     r.Get("/", s.handleGetMyEnrollment)
   })
 
+  r.With(s.userAuth).Get("/api/enrollments", s.handleListMyEnrollments)
+
   // Likes / favorites
   r.Route("/api/likes", func(r chi.Router) {
     // Optional auth: returns {liked: false} for unauthenticated users
@@ -117,31 +181,186 @@ func (s *Server) Routes() http.Handler {
     })
   })
 
+  // Auction watchlist
+  r.Route("/api/watches", func(r chi.Router) {
+    r.With(s.optionalUserAuth).Get("/check/{id}", s.handleIsWatching)
+    r.Group(func(r chi.Router) {
+      r.Use(s.userAuth)
+      r.Get("/", s.handleGetUserWatches)
+      r.Post("/{id}", s.handleWatchAuction)
+      r.Delete("/{id}", s.handleUnwatchAuction)
+    })
+  })
+
+  // Saved catalog searches
+  r.Route("/api/saved-searches", func(r chi.Router) {
+    r.Use(s.userAuth)
+    r.Get("/", s.handleListSavedSearches)
+    r.Post("/", s.handleCreateSavedSearch)
+    r.Put("/{id}", s.handleUpdateSavedSearch)
+    r.Delete("/{id}", s.handleDeleteSavedSearch)
+    r.Get("/{id}/matches", s.handleListSavedSearchMatches)
+    r.Put("/{id}/seen", s.handleMarkSavedSearchSeen)
+  })
+
+  // Alerts for liked listings, watched auctions and saved searches
+  r.Route("/api/notifications", func(r chi.Router) {
+    r.Use(s.userAuth)
+    r.Get("/", s.handleListNotifications)
+    r.Put("/read-all", s.handleMarkAllNotificationsRead)
+    r.Put("/{id}/read", s.handleMarkNotificationRead)
+    r.Get("/preferences", s.handleGetNotificationPreferences)
+    r.Put("/preferences", s.handleUpdateNotificationPreferences)
+  })
+
   r.Route("/api/admin", func(r chi.Router) {
     r.Use(s.adminAuth)
+    r.Use(s.auditMutations)
     r.Route("/auctions", func(r chi.Router) {
-      r.Get("/", s.handleAdminListAuctions)
-      r.Post("/", s.handleCreateAuction)
-      r.Put("/{id}", s.handleUpdateAuction)
-      r.Delete("/{id}", s.handleDeleteAuction)
-      r.Get("/{id}/enrollments", s.handleListEnrollments)
-      r.Put("/{id}/enrollments/{userId}/approve", s.handleApproveEnrollment)
-      r.Put("/{id}/enrollments/{userId}/reject", s.handleRejectEnrollment)
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeAuctionsRead))
+        r.Get("/", s.handleAdminListAuctions)
+        r.Get("/{id}/enrollments", s.handleListEnrollments)
+        r.Get("/{id}/enrollment-policy", s.handleGetEnrollmentPolicy)
+        r.Get("/{id}/invites", s.handleListAuctionInvites)
+        r.Get("/{id}/attachments", s.handleAdminListAttachments("auction"))
+        r.Get("/{id}/attachments/{attachmentId}/file", s.handleAdminDownloadAttachment("auction"))
+      })
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeAuctionsWrite))
+        r.Post("/", s.handleCreateAuction)
+        r.Put("/{id}", s.handleUpdateAuction)
+        r.Delete("/{id}", s.handleDeleteAuction)
+        r.Put("/{id}/categories", s.handleSetAuctionCategories)
+        r.Put("/{id}/specs", s.handleSetAuctionSpecs)
+        r.Post("/{id}/images", s.handleUploadImage("auction"))
+        r.Put("/{id}/images/order", s.handleReorderImages("auction"))
+        r.Put("/{id}/images/{imageId}", s.handleUpdateImage("auction"))
+        r.Delete("/{id}/images/{imageId}", s.handleDeleteImage("auction"))
+        r.Post("/{id}/attachments", s.handleUploadAttachment("auction"))
+        r.Put("/{id}/attachments/{attachmentId}", s.handleUpdateAttachment("auction"))
+        r.Delete("/{id}/attachments/{attachmentId}", s.handleDeleteAttachment("auction"))
+        r.With(s.requireScope(auth.ScopeListingsWrite)).Post("/{id}/return-to-listing", s.handleReturnAuctionToListing)
+      })
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeEnrollmentsWrite))
+        r.Put("/{id}/enrollments/{userId}/approve", s.handleApproveEnrollment)
+        r.Put("/{id}/enrollments/{userId}/reject", s.handleRejectEnrollment)
+        r.Put("/{id}/enrollment-policy", s.handlePutEnrollmentPolicy)
+        r.Post("/{id}/enrollments/bulk", s.handleBulkAuctionEnrollments)
+        r.Post("/{id}/invites", s.handleInviteUsers)
+        r.Delete("/{id}/invites/{userId}", s.handleRevokeInvite)
+        r.Delete("/{id}/enrollment-policy", s.handleDeleteEnrollmentPolicy)
+      })
     })
+    r.With(s.requireScope(auth.ScopeEnrollmentsWrite)).Post("/enrollments/bulk", s.handleBulkEnrollments)
     r.Route("/listings", func(r chi.Router) {
-      r.Get("/", s.handleAdminListListings)
-      r.Post("/", s.handleCreateListing)
-      r.Put("/{id}", s.handleUpdateListing)
-      r.Delete("/{id}", s.handleDeleteListing)
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeListingsRead))
+        r.Get("/", s.handleAdminListListings)
+        r.Get("/{id}/attachments", s.handleAdminListAttachments("listing"))
+        r.Get("/{id}/attachments/{attachmentId}/file", s.handleAdminDownloadAttachment("listing"))
+      })
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeListingsWrite))
+        r.Post("/", s.handleCreateListing)
+        r.Put("/{id}", s.handleUpdateListing)
+        r.Delete("/{id}", s.handleDeleteListing)
+        r.Put("/{id}/categories", s.handleSetListingCategories)
+        r.Put("/{id}/specs", s.handleSetListingSpecs)
+        r.Post("/{id}/images", s.handleUploadImage("listing"))
+        r.Put("/{id}/images/order", s.handleReorderImages("listing"))
+        r.Put("/{id}/images/{imageId}", s.handleUpdateImage("listing"))
+        r.Delete("/{id}/images/{imageId}", s.handleDeleteImage("listing"))
+        r.Post("/{id}/attachments", s.handleUploadAttachment("listing"))
+        r.Put("/{id}/attachments/{attachmentId}", s.handleUpdateAttachment("listing"))
+        r.Delete("/{id}/attachments/{attachmentId}", s.handleDeleteAttachment("listing"))
+        r.With(s.requireScope(auth.ScopeAuctionsWrite)).Post("/{id}/convert-to-auction", s.handleConvertListingToAuction)
+      })
+    })
+    r.Route("/categories", func(r chi.Router) {
+      r.Use(s.requireScope(auth.ScopeListingsWrite))
+      r.Post("/", s.handleCreateCategory)
+      r.Put("/{id}", s.handleUpdateCategory)
+      r.Delete("/{id}", s.handleDeleteCategory)
+    })
+    r.Route("/spec-attributes", func(r chi.Router) {
+      r.With(s.requireScope(auth.ScopeListingsRead)).Get("/", s.handleListSpecAttributes)
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeListingsWrite))
+        r.Post("/", s.handleCreateSpecAttribute)
+        r.Put("/{id}", s.handleUpdateSpecAttribute)
+        r.Delete("/{id}", s.handleDeleteSpecAttribute)
+      })
     })
     r.Route("/users", func(r chi.Router) {
-      r.Get("/", s.handleListUsers)
-      r.Get("/{id}", s.handleGetUser)
-      r.Put("/{id}/approve", s.handleApproveUser)
-      r.Put("/{id}/reject", s.handleRejectUser)
-      r.Put("/{id}/password", s.handleSetUserPassword)
-      r.Put("/{id}/admin", s.handleSetUserAdmin)
-      r.Put("/{id}/opportunities", s.handleSetUserOpportunities)
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeUsersRead))
+        r.Get("/", s.handleListUsers)
+        r.Get("/{id}", s.handleGetUser)
+        r.Get("/{id}/documents", s.handleAdminListDocuments)
+        r.Get("/{id}/profile-changes", s.handleListUserProfileChanges)
+        r.Get("/{id}/documents/{docId}/file", s.handleAdminDownloadDocument)
+        r.Get("/{id}/notes", s.handleListReviewNotes)
+        r.Get("/{id}/status-history", s.handleUserStatusHistory)
+      })
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeUsersReview))
+        r.Post("/{id}/claim", s.handleClaimUser)
+        r.Put("/{id}/assignee", s.handleAssignReviewer)
+        r.Post("/{id}/notes", s.handleAddReviewNote)
+        r.Put("/{id}/request-info", s.handleRequestUserInfo)
+        r.Put("/{id}/documents/{docId}/review", s.handleReviewDocument)
+        r.Put("/{id}/approve", s.handleApproveUser)
+        r.Put("/{id}/reject", s.handleRejectUser)
+      })
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeUsersManage))
+        r.Put("/{id}/password", s.handleSetUserPassword)
+        r.Put("/{id}/suspend", s.handleSuspendUser)
+        r.Put("/{id}/reinstate", s.handleReinstateUser)
+      })
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeRolesManage))
+        r.Put("/{id}/admin", s.handleSetUserAdmin)
+        r.Get("/{id}/roles", s.handleGetUserRoles)
+        r.Put("/{id}/roles", s.handleSetUserRoles)
+      })
+      r.With(s.requireScope(auth.ScopeOpportunitiesWrite)).Put("/{id}/opportunities", s.handleSetUserOpportunities)
+      r.With(s.requireScope(auth.ScopeUsersImpersonate)).Post("/{id}/impersonate", s.handleImpersonateUser)
+    })
+    r.Route("/review-queue", func(r chi.Router) {
+      r.Use(s.requireScope(auth.ScopeUsersRead))
+      r.Get("/", s.handleReviewQueue)
+      r.Get("/metrics", s.handleReviewMetrics)
+    })
+    r.Route("/profile-changes", func(r chi.Router) {
+      r.With(s.requireScope(auth.ScopeUsersRead)).Get("/", s.handleListProfileChanges)
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeUsersReview))
+        r.Put("/{id}/approve", s.handleApproveProfileChange)
+        r.Put("/{id}/reject", s.handleRejectProfileChange)
+      })
+    })
+    r.Route("/account-closures", func(r chi.Router) {
+      r.With(s.requireScope(auth.ScopeUsersRead)).Get("/", s.handleListClosures)
+      r.Group(func(r chi.Router) {
+        r.Use(s.requireScope(auth.ScopeUsersManage))
+        r.Put("/{id}/approve", s.handleApproveClosure)
+        r.Put("/{id}/reject", s.handleRejectClosure)
+      })
+    })
+    r.With(s.requireScope(auth.ScopeRolesManage)).Get("/roles", s.handleListRoles)
+    r.Route("/api-keys", func(r chi.Router) {
+      r.Use(s.requireScope(auth.ScopeAPIKeysManage))
+      r.Get("/", s.handleListAPIKeys)
+      r.Post("/", s.handleCreateAPIKey)
+      r.Delete("/{id}", s.handleRevokeAPIKey)
+    })
+    r.Route("/audit", func(r chi.Router) {
+      r.Use(s.requireScope(auth.ScopeAuditRead))
+      r.Get("/", s.handleListAudit)
+      r.Get("/export.csv", s.handleExportAudit)
     })
   })
 
@@ -155,14 +374,46 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
   })
 }
 
+// handleJWKS publishes the public keys that verify our tokens so other
+// services can authenticate MAQZONE users without sharing a secret.
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+  w.Header().Set("Cache-Control", "public, max-age=300")
+  respondJSON(w, http.StatusOK, map[string]any{
+    "keys": s.keys.JWKS(),
+  })
+}
+
+// handleListAuctions serves the auction catalog: filters, sort and cursor
+// pagination are described in parseCatalogQuery. Facets ignore the cursor.
 func (s *Server) handleListAuctions(w http.ResponseWriter, r *http.Request) {
-  limit := parseLimit(r, 20)
-  items, err := s.queries.ListActiveAuctions(r.Context(), int64(limit))
+  c, msg, err := s.parseCatalogQuery(r, sqlc.AuctionSorts)
   if err != nil {
     respondError(w, http.StatusInternalServerError, "failed to list auctions")
     return
   }
-  respondJSON(w, http.StatusOK, items)
+  if msg != "" {
+    respondError(w, http.StatusBadRequest, msg)
+    return
+  }
+  if c.filter.YearMin != 0 || c.filter.YearMax != 0 {
+    respondError(w, http.StatusBadRequest, "year filters apply to listings only")
+    return
+  }
+  items, next, err := s.queries.ListCatalogAuctions(r.Context(), c.filter, c.sort, c.after, c.limit)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to list auctions")
+    return
+  }
+  facets, err := s.queries.AuctionFacets(r.Context(), c.filter)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to list auctions")
+    return
+  }
+  respondJSON(w, http.StatusOK, map[string]any{
+    "items":       items,
+    "next_cursor": encodeCatalogCursor(c.sort, next),
+    "facets":      facets,
+  })
 }
 
 func (s *Server) handleGetAuction(w http.ResponseWriter, r *http.Request) {
@@ -184,7 +435,57 @@ func (s *Server) handleGetAuction(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusInternalServerError, "failed to load auction")
     return
   }
-  respondJSON(w, http.StatusOK, item)
+  // Invite-only auctions do not exist for anyone who was not invited.
+  if ok, err := s.canViewAuction(r.Context(), item); err != nil || !ok {
+    respondError(w, http.StatusNotFound, "auction not found")
+    return
+  }
+  resp := auctionDetailResponse{Auction: item}
+  resp.Categories, err = s.queries.ListCategoriesForAuction(r.Context(), id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
+  resp.Specs, err = s.queries.ListAuctionSpecs(r.Context(), id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
+  resp.Images, err = s.itemGallery(r.Context(), "auction", id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
+  resp.Attachments, err = s.itemAttachments(r.Context(), "auction", id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load auction")
+    return
+  }
+  if link, err := s.queries.GetLinkForAuction(r.Context(), id); err == nil {
+    resp.ListingLink = &link
+  }
+  // Signed-in users also get their own enrollment, so the page can show
+  // whether they can bid without a second request.
+  if claims := GetClaims(r.Context()); claims != nil {
+    if enrollment, err := s.queries.GetEnrollment(r.Context(), id, claims.UserID); err == nil {
+      resp.MyEnrollment = &enrollment
+    }
+  }
+  respondJSON(w, http.StatusOK, resp)
+}
+
+type auctionDetailResponse struct {
+  sqlc.Auction
+  Categories  []sqlc.Category      `json:"categories"`
+  Specs       []sqlc.SpecValue     `json:"specs"`
+  Images      []imageResponse      `json:"images"`
+  Attachments []attachmentResponse `json:"attachments"`
+  // ListingLink is set when the auction was created from a listing or
+  // returned to one.
+  ListingLink *sqlc.ListingAuctionLink `json:"listing_link"`
+  // MyEnrollment is null for anonymous callers and users who have not asked
+  // to enroll.
+  MyEnrollment *sqlc.AuctionEnrollment `json:"my_enrollment"`
 }
 
 type createAuctionRequest struct {
@@ -204,17 +505,29 @@ type createAuctionRequest struct {
   AutoExtendMinutes       int64  `json:"auto_extend_minutes"`
   AutoExtendWindowMinutes int64  `json:"auto_extend_window_minutes"`
   PriceVisible            *int64 `json:"price_visible"`
+  Visibility              string `json:"visibility"`
 }
 
-func (s *Server) handleCreateAuction(w http.ResponseWriter, r *http.Request) {
-  var req createAuctionRequest
-  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-    respondError(w, http.StatusBadRequest, "invalid json")
-    return
+// auctionTimesError checks that end_time, and start_time when given, are
+// RFC3339 timestamps; the scheduler and the catalog sort cannot use anything
+// else. It returns a message for a 400, or "" when both are fine.
+func auctionTimesError(startTime, endTime string) string {
+  if _, err := time.Parse(time.RFC3339, endTime); err != nil {
+    return "end_time must be an RFC3339 timestamp"
   }
-  if req.Title == "" || req.Description == "" || req.Location == "" || req.EndTime == "" {
-    respondError(w, http.StatusBadRequest, "missing required fields")
-    return
+  if startTime != "" {
+    if _, err := time.Parse(time.RFC3339, startTime); err != nil {
+      return "start_time must be an RFC3339 timestamp"
+    }
+  }
+  return ""
+}
+
+// params fills in the defaults for omitted settings. On an invalid field it
+// returns a message for a 400.
+func (req createAuctionRequest) params() (sqlc.CreateAuctionParams, string) {
+  if msg := auctionTimesError(req.StartTime, req.EndTime); msg != "" {
+    return sqlc.CreateAuctionParams{}, msg
   }
   if req.Status == "" {
     req.Status = "active"
@@ -234,11 +547,17 @@ func (s *Server) handleCreateAuction(w http.ResponseWriter, r *http.Request) {
   if req.AutoExtendWindowMinutes == 0 {
     req.AutoExtendWindowMinutes = 2
   }
+  if req.Visibility == "" {
+    req.Visibility = "public"
+  }
+  if !validVisibility(req.Visibility) {
+    return sqlc.CreateAuctionParams{}, "visibility must be public, unlisted or invite_only"
+  }
   priceVisible := int64(0)
   if req.PriceVisible != nil {
     priceVisible = *req.PriceVisible
   }
-  item, err := s.queries.CreateAuction(r.Context(), sqlc.CreateAuctionParams{
+  return sqlc.CreateAuctionParams{
     Title:                   req.Title,
     Description:             req.Description,
     Location:                req.Location,
@@ -255,23 +574,61 @@ func (s *Server) handleCreateAuction(w http.ResponseWriter, r *http.Request) {
     AutoExtendMinutes:       req.AutoExtendMinutes,
     AutoExtendWindowMinutes: req.AutoExtendWindowMinutes,
     PriceVisible:            priceVisible,
-  })
+    Visibility:              req.Visibility,
+  }, ""
+}
+
+func (s *Server) handleCreateAuction(w http.ResponseWriter, r *http.Request) {
+  var req createAuctionRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    respondError(w, http.StatusBadRequest, "invalid json")
+    return
+  }
+  if req.Title == "" || req.Description == "" || req.Location == "" || req.EndTime == "" {
+    respondError(w, http.StatusBadRequest, "missing required fields")
+    return
+  }
+  params, msg := req.params()
+  if msg != "" {
+    respondError(w, http.StatusBadRequest, msg)
+    return
+  }
+  item, err := s.queries.CreateAuction(r.Context(), params)
   if err != nil {
     s.logger.Error().Err(err).Msg("failed to create auction")
     respondError(w, http.StatusInternalServerError, "failed to create auction")
     return
   }
+  s.audit(r, auditEntry{Action: "auction.create", TargetType: "auction", TargetID: item.ID, After: item})
   respondJSON(w, http.StatusCreated, item)
 }
 
+// handleListListings serves the listing catalog; see handleListAuctions.
 func (s *Server) handleListListings(w http.ResponseWriter, r *http.Request) {
-  limit := parseLimit(r, 20)
-  items, err := s.queries.ListListings(r.Context(), int64(limit))
+  c, msg, err := s.parseCatalogQuery(r, sqlc.ListingSorts)
   if err != nil {
     respondError(w, http.StatusInternalServerError, "failed to list listings")
     return
   }
-  respondJSON(w, http.StatusOK, items)
+  if msg != "" {
+    respondError(w, http.StatusBadRequest, msg)
+    return
+  }
+  items, next, err := s.queries.ListCatalogListings(r.Context(), c.filter, c.sort, c.after, c.limit)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to list listings")
+    return
+  }
+  facets, err := s.queries.ListingFacets(r.Context(), c.filter)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to list listings")
+    return
+  }
+  respondJSON(w, http.StatusOK, map[string]any{
+    "items":       items,
+    "next_cursor": encodeCatalogCursor(c.sort, next),
+    "facets":      facets,
+  })
 }
 
 func (s *Server) handleGetListing(w http.ResponseWriter, r *http.Request) {
@@ -289,7 +646,48 @@ func (s *Server) handleGetListing(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusInternalServerError, "failed to load listing")
     return
   }
-  respondJSON(w, http.StatusOK, item)
+  categories, err := s.queries.ListCategoriesForListing(r.Context(), id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load listing")
+    return
+  }
+  specs, err := s.queries.ListListingSpecs(r.Context(), id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load listing")
+    return
+  }
+  images, err := s.itemGallery(r.Context(), "listing", id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load listing")
+    return
+  }
+  attachments, err := s.itemAttachments(r.Context(), "listing", id)
+  if err != nil {
+    respondError(w, http.StatusInternalServerError, "failed to load listing")
+    return
+  }
+  resp := listingDetailResponse{
+    Listing:     item,
+    Categories:  categories,
+    Specs:       specs,
+    Images:      images,
+    Attachments: attachments,
+  }
+  if link, err := s.queries.GetLatestLinkForListing(r.Context(), id); err == nil {
+    resp.AuctionLink = &link
+  }
+  respondJSON(w, http.StatusOK, resp)
+}
+
+type listingDetailResponse struct {
+  sqlc.Listing
+  Categories  []sqlc.Category      `json:"categories"`
+  Specs       []sqlc.SpecValue     `json:"specs"`
+  Images      []imageResponse      `json:"images"`
+  Attachments []attachmentResponse `json:"attachments"`
+  // AuctionLink is the latest auction the listing was sent to or came back
+  // from, if any.
+  AuctionLink *sqlc.ListingAuctionLink `json:"auction_link"`
 }
 
 type createListingRequest struct {
@@ -330,6 +728,7 @@ func (s *Server) handleCreateListing(w http.ResponseWriter, r *http.Request) {
     respondError(w, http.StatusInternalServerError, "failed to create listing")
     return
   }
+  s.audit(r, auditEntry{Action: "listing.create", TargetType: "listing", TargetID: item.ID, After: item})
   respondJSON(w, http.StatusCreated, item)
 }
 
@@ -375,3 +774,11 @@ func respondError(w http.ResponseWriter, status int, message string) {
     "error": message,
   })
 }
+
+// respondValidation reports per-field problems so forms can highlight them.
+func respondValidation(w http.ResponseWriter, errs validation.Errors) {
+  respondJSON(w, http.StatusUnprocessableEntity, map[string]any{
+    "error":  "validation failed",
+    "fields": errs,
+  })
+}
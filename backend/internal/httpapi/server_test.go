@@ -6,21 +6,36 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rs/zerolog"
 
+	"maqzone/backend/internal/auth"
 	"maqzone/backend/internal/config"
 	"maqzone/backend/internal/db"
 	sqlc "maqzone/backend/internal/db/sqlc"
 	"maqzone/backend/internal/httpapi"
+	"maqzone/backend/internal/mailer"
+	"maqzone/backend/internal/scheduler"
 )
 
-const testToken = "test-admin-token"
+const (
+	testKeyID     = "00000000"
+	testKeySecret = "test-admin-secret"
+	testToken     = auth.APIKeyPrefix + testKeyID + "_" + testKeySecret
+)
 
 func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB) {
 	t.Helper()
@@ -47,12 +62,21 @@ func setupTestServer(t *testing.T) (*httptest.Server, *sql.DB) {
 		SQLitePath:         tmpFile.Name(),
 		CorsAllowAll:       true,
 		LogLevel:           "disabled",
-		AdminToken:         testToken,
+		UploadDir:          t.TempDir(),
 	}
 
 	queries := sqlc.New(database)
+	if _, err := queries.CreateAPIKey(context.Background(), sqlc.CreateAPIKeyParams{
+		Name:       "test",
+		Prefix:     testKeyID,
+		SecretHash: auth.HashAPIKeySecret(testKeySecret),
+		Scopes:     auth.JoinScopes(auth.AllScopes),
+	}); err != nil {
+		t.Fatal(err)
+	}
 	logger := zerolog.Nop()
 	srv := httpapi.New(cfg, queries, logger)
+	srv.SetHub(httpapi.NewHub(logger))
 	ts := httptest.NewServer(srv.Routes())
 	t.Cleanup(ts.Close)
 
@@ -82,6 +106,18 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 }
 
+// decodePage reads a catalog response and returns its items.
+func decodePage(t *testing.T, resp *http.Response) []map[string]any {
+	t.Helper()
+	var page struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("decode catalog page: %v", err)
+	}
+	return page.Items
+}
+
 func TestListAuctions(t *testing.T) {
 	ts, _ := setupTestServer(t)
 
@@ -96,7 +132,7 @@ func TestListAuctions(t *testing.T) {
 	}
 
 	var auctions []map[string]any
-	json.NewDecoder(resp.Body).Decode(&auctions)
+	auctions = decodePage(t, resp)
 	if len(auctions) < 1 {
 		t.Fatal("expected at least 1 auction from seed data")
 	}
@@ -115,7 +151,7 @@ func TestListAuctionsWithLimit(t *testing.T) {
 	defer resp.Body.Close()
 
 	var auctions []map[string]any
-	json.NewDecoder(resp.Body).Decode(&auctions)
+	auctions = decodePage(t, resp)
 	if len(auctions) != 1 {
 		t.Fatalf("expected 1 auction, got %d", len(auctions))
 	}
@@ -183,7 +219,7 @@ func TestListListings(t *testing.T) {
 	}
 
 	var listings []map[string]any
-	json.NewDecoder(resp.Body).Decode(&listings)
+	listings = decodePage(t, resp)
 	if len(listings) < 1 {
 		t.Fatal("expected at least 1 listing from seed data")
 	}
@@ -240,7 +276,7 @@ func TestAdminInvalidToken(t *testing.T) {
 	body := `{"title":"Test","description":"Desc","location":"MX","end_time":"2026-12-31T23:59:59Z"}`
 	req, _ := http.NewRequest("POST", ts.URL+"/api/admin/auctions", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Admin-Token", "wrong-token")
+	req.Header.Set("X-API-Key", "mqz_00000000_wrong-secret")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -266,7 +302,7 @@ func adminRequest(t *testing.T, method, url string, body any) *http.Response {
 		t.Fatal(err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Admin-Token", testToken)
+	req.Header.Set("X-API-Key", testToken)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		t.Fatal(err)
@@ -382,6 +418,2419 @@ func TestDeleteAuction(t *testing.T) {
 	}
 }
 
+// --- API keys ---
+
+func keyRequest(t *testing.T, method, url, key string, body any) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func createTestAPIKey(t *testing.T, ts *httptest.Server, scopes ...string) (string, int) {
+	t.Helper()
+	resp := adminRequest(t, "POST", ts.URL+"/api/admin/api-keys", map[string]any{
+		"name":   "scoped",
+		"scopes": scopes,
+	})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 creating api key, got %d", resp.StatusCode)
+	}
+	var created struct {
+		Key    string         `json:"key"`
+		APIKey map[string]any `json:"api_key"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.Key, int(created.APIKey["id"].(float64))
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	key, _ := createTestAPIKey(t, ts, auth.ScopeAuctionsRead)
+
+	resp := keyRequest(t, "GET", ts.URL+"/api/admin/auctions", key, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with auctions:read, got %d", resp.StatusCode)
+	}
+
+	resp = keyRequest(t, "DELETE", ts.URL+"/api/admin/auctions/1", key, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without auctions:write, got %d", resp.StatusCode)
+	}
+
+	resp = keyRequest(t, "POST", ts.URL+"/api/admin/api-keys", key, map[string]any{
+		"name":   "escalation",
+		"scopes": []string{auth.ScopeAuctionsWrite},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 creating keys without apikeys:manage, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIKeyRevocation(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	key, id := createTestAPIKey(t, ts, auth.ScopeListingsRead)
+
+	resp := adminRequest(t, "DELETE", ts.URL+"/api/admin/api-keys/"+itoa(id), nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 revoking key, got %d", resp.StatusCode)
+	}
+
+	resp = keyRequest(t, "GET", ts.URL+"/api/admin/listings", key, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with revoked key, got %d", resp.StatusCode)
+	}
+}
+
+// --- Audit log ---
+
+func TestAuditLogRecordsChanges(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1", map[string]any{
+		"title":       "Audited Excavadora",
+		"description": "Updated description",
+		"location":    "Updated City, MX",
+		"status":      "active",
+		"end_time":    "2026-12-31T23:59:59Z",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 updating auction, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/audit?target_type=auction&target_id=1", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 listing audit log, got %d", resp.StatusCode)
+	}
+	var page struct {
+		Items []struct {
+			Action        string                    `json:"action"`
+			ActorAPIKeyID int                       `json:"actor_api_key_id"`
+			Changes       map[string]map[string]any `json:"changes"`
+		} `json:"items"`
+		Total int `json:"total"`
+	}
+	json.NewDecoder(resp.Body).Decode(&page)
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", page.Total)
+	}
+	entry := page.Items[0]
+	if entry.Action != "auction.update" || entry.ActorAPIKeyID == 0 {
+		t.Fatalf("unexpected audit entry: %+v", entry)
+	}
+	if entry.Changes["title"]["to"] != "Audited Excavadora" {
+		t.Fatalf("expected title change in diff, got %v", entry.Changes["title"])
+	}
+
+	if _, err := database.Exec("DELETE FROM admin_audit_log"); err == nil {
+		t.Fatal("audit log rows must not be deletable")
+	}
+}
+
+// --- Roles ---
+
+func registerTestUser(t *testing.T, ts *httptest.Server, email string) (string, int) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"email": email, "password": "password123"})
+	resp, err := http.Post(ts.URL+"/api/auth/register", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 registering %s, got %d", email, resp.StatusCode)
+	}
+	var out struct {
+		Token string         `json:"token"`
+		User  map[string]any `json:"user"`
+	}
+	json.NewDecoder(resp.Body).Decode(&out)
+	return out.Token, int(out.User["id"].(float64))
+}
+
+func bearerRequest(t *testing.T, method, url, token string, body any) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestRoleRestrictsAdminAccess(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	staffToken, staffID := registerTestUser(t, ts, "reviewer@example.com")
+	_, applicantID := registerTestUser(t, ts, "applicant@example.com")
+
+	resp := bearerRequest(t, "GET", ts.URL+"/api/admin/users", staffToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 before role assignment, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(staffID)+"/roles", map[string]any{
+		"roles": []string{auth.RoleRegistrationsReviewer},
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 assigning role, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(applicantID)+"/reject", staffToken, map[string]any{
+		"reason": "incomplete documents",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected reviewer to reject registrations, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "DELETE", ts.URL+"/api/admin/auctions/1", staffToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected reviewer to be denied auction deletion, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(applicantID)+"/opportunities", staffToken, map[string]any{
+		"opportunities": 10,
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected reviewer to be denied opportunity changes, got %d", resp.StatusCode)
+	}
+}
+
+// --- Impersonation ---
+
+func TestImpersonation(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	adminToken, adminID := registerTestUser(t, ts, "support@example.com")
+	_, buyerID := registerTestUser(t, ts, "buyer@example.com")
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(adminID)+"/roles", map[string]any{
+		"roles": []string{auth.RoleSuperadmin},
+	})
+	resp.Body.Close()
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/admin/users/"+itoa(buyerID)+"/impersonate", adminToken, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 starting impersonation, got %d", resp.StatusCode)
+	}
+	var session struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(resp.Body).Decode(&session)
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auth/me", session.Token, nil)
+	var me map[string]any
+	json.NewDecoder(resp.Body).Decode(&me)
+	resp.Body.Close()
+	if int(me["id"].(float64)) != buyerID || int(me["impersonated_by"].(float64)) != adminID {
+		t.Fatalf("expected to see buyer as impersonated by admin, got %v", me)
+	}
+
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/auth/password", session.Token, map[string]any{
+		"current_password": "password123",
+		"new_password":     "hijacked123",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 changing password while impersonating, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/admin/users", session.Token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected impersonation token to be denied admin access, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/audit?action=user.impersonate&actor_user_id="+itoa(adminID), nil)
+	var page struct {
+		Total int `json:"total"`
+	}
+	json.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if page.Total != 1 {
+		t.Fatalf("expected impersonation session in audit log, got %d entries", page.Total)
+	}
+}
+
+// --- Documents and fiscal data ---
+
+func uploadTestDocument(t *testing.T, ts *httptest.Server, token, docType string, content []byte) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", docType+".pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	mw.Close()
+	req, err := http.NewRequest("POST", ts.URL+"/api/auth/documents/"+docType, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestApprovalRequiresReviewedDocuments(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	token, userID := registerTestUser(t, ts, "kyc@example.com")
+	approve := map[string]any{"guarantee_tier": "50k"}
+
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/approve", approve)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 approving without documents, got %d", resp.StatusCode)
+	}
+
+	resp = uploadTestDocument(t, ts, token, "tax_certificate", []byte("not a pdf"))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 for a text upload, got %d", resp.StatusCode)
+	}
+
+	pdf := []byte("%PDF-1.4\n%test document\n")
+	for _, docType := range []string{"registration_sheet", "tax_certificate", "representative_id", "proof_of_address"} {
+		resp = uploadTestDocument(t, ts, token, docType, pdf)
+		var doc map[string]any
+		json.NewDecoder(resp.Body).Decode(&doc)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected 201 uploading %s, got %d", docType, resp.StatusCode)
+		}
+		docID := int(doc["id"].(float64))
+
+		resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(userID)+"/documents/"+itoa(docID)+"/file", nil)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !bytes.Equal(body, pdf) {
+			t.Fatalf("expected stored %s to round-trip, got %q", docType, body)
+		}
+
+		resp = adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/documents/"+itoa(docID)+"/review", map[string]any{
+			"status": "approved",
+		})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 reviewing %s, got %d", docType, resp.StatusCode)
+		}
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/approve", approve)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 approving with reviewed documents, got %d", resp.StatusCode)
+	}
+}
+
+func TestRegisterValidatesFiscalData(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	body, _ := json.Marshal(map[string]any{
+		"email":       "fiscal@example.com",
+		"password":    "password123",
+		"rfc":         "SAT970701NN4",
+		"postal_code": "123",
+	})
+	resp, err := http.Post(ts.URL+"/api/auth/register", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var invalid struct {
+		Fields map[string]string `json:"fields"`
+	}
+	json.NewDecoder(resp.Body).Decode(&invalid)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", resp.StatusCode)
+	}
+	if invalid.Fields["rfc"] == "" || invalid.Fields["postal_code"] == "" {
+		t.Fatalf("expected rfc and postal_code errors, got %v", invalid.Fields)
+	}
+
+	token, _ := registerTestUser(t, ts, "fiscal@example.com")
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/auth/profile", token, map[string]any{
+		"rfc":   "sat970701nn3",
+		"state": "cdmx",
+	})
+	var user map[string]any
+	json.NewDecoder(resp.Body).Decode(&user)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 updating profile, got %d", resp.StatusCode)
+	}
+	if user["rfc"] != "SAT970701NN3" || user["state"] != "Ciudad de México" {
+		t.Fatalf("expected normalized profile, got rfc=%v state=%v", user["rfc"], user["state"])
+	}
+}
+
+func TestFiscalProfileChangesNeedReview(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	token, userID := registerTestUser(t, ts, "change@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved', rfc = 'GODE561231GR8' WHERE id = ?", userID); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := bearerRequest(t, "PUT", ts.URL+"/api/auth/profile", token, map[string]any{
+		"phone": "81 1234 5678",
+		"rfc":   "SAT970701NN3",
+	})
+	var profile struct {
+		RFC     string `json:"rfc"`
+		Phone   string `json:"phone"`
+		Pending struct {
+			ID int `json:"id"`
+		} `json:"pending_profile_change"`
+	}
+	json.NewDecoder(resp.Body).Decode(&profile)
+	resp.Body.Close()
+	if profile.Phone != "8112345678" || profile.RFC != "GODE561231GR8" || profile.Pending.ID == 0 {
+		t.Fatalf("expected phone applied and rfc held for review, got %+v", profile)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/profile-changes/"+itoa(profile.Pending.ID)+"/approve", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 approving change, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(userID)+"/profile-changes", nil)
+	var history []map[string]any
+	json.NewDecoder(resp.Body).Decode(&history)
+	resp.Body.Close()
+	if len(history) != 1 || history[0]["status"] != "approved" || history[0]["previous_rfc"] != "GODE561231GR8" {
+		t.Fatalf("expected approved change with previous rfc in history, got %v", history)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(userID), nil)
+	var user map[string]any
+	json.NewDecoder(resp.Body).Decode(&user)
+	resp.Body.Close()
+	if user["rfc"] != "SAT970701NN3" {
+		t.Fatalf("expected approved rfc on user, got %v", user["rfc"])
+	}
+}
+
+// --- Account export and closure ---
+
+func TestAccountExportAndClosure(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	token, userID := registerTestUser(t, ts, "closing@example.com")
+	resp := uploadTestDocument(t, ts, token, "tax_certificate", []byte("%PDF-1.4 test"))
+	resp.Body.Close()
+	if _, err := database.Exec(`
+		INSERT INTO notifications (user_id, kind, item_type, item_id, title, body) VALUES (?, 'price_drop', 'listing', 1, 'Bajó de precio', 'Aviso');
+		INSERT INTO notification_preferences (user_id, email_mode) VALUES (?, 'digest');`, userID, userID); err != nil {
+		t.Fatal(err)
+	}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/watches/3", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 watching an auction, got %d", resp.StatusCode)
+	}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/saved-searches", token, map[string]any{"name": "Subastas", "query": "type=auctions"})
+	var search struct {
+		ID int64 `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&search)
+	resp.Body.Close()
+	var matches int
+	database.QueryRow("SELECT COUNT(*) FROM saved_search_matches WHERE search_id = ?", search.ID).Scan(&matches)
+	if resp.StatusCode != http.StatusCreated || matches == 0 {
+		t.Fatalf("expected a saved search with baseline matches, got %d and %d matches", resp.StatusCode, matches)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auth/export", token, nil)
+	var bundle struct {
+		Profile       map[string]any   `json:"profile"`
+		Documents     []map[string]any `json:"documents"`
+		Notifications []map[string]any `json:"notifications"`
+		Preferences   map[string]any   `json:"notification_preferences"`
+	}
+	json.NewDecoder(resp.Body).Decode(&bundle)
+	resp.Body.Close()
+	if bundle.Profile["email"] != "closing@example.com" || len(bundle.Documents) != 1 ||
+		len(bundle.Notifications) != 1 || bundle.Preferences["email_mode"] != "digest" {
+		t.Fatalf("expected profile, document and notifications in export, got %+v", bundle)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auth/account/closure", token, map[string]any{"reason": "no longer needed"})
+	var closure struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+	json.NewDecoder(resp.Body).Decode(&closure)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || closure.Status != "pending" {
+		t.Fatalf("expected pending closure request, got %d %+v", resp.StatusCode, closure)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/account-closures/"+itoa(closure.ID)+"/approve", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 approving closure, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(userID), nil)
+	var user map[string]any
+	json.NewDecoder(resp.Body).Decode(&user)
+	resp.Body.Close()
+	if user["email"] != "deleted-"+itoa(userID)+"@closed.invalid" || user["phone"] != "" || user["closed_at"] == "" {
+		t.Fatalf("expected anonymized user, got %v", user)
+	}
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auth/me", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the closed account's token to be rejected, got %d", resp.StatusCode)
+	}
+	resp = bearerRequest(t, "GET", ts.URL+"/api/admin/review-queue/metrics", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the closed account kept out of the admin API, got %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(ts.URL + "/api/ws/me?token=" + token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the closed account's notification channel to be refused, got %d", resp.StatusCode)
+	}
+
+	var leaked, cleared int
+	database.QueryRow("SELECT COUNT(*) FROM admin_audit_log WHERE before_json || after_json || changes_json LIKE '%closing@example.com%'").Scan(&leaked)
+	database.QueryRow("SELECT COUNT(*) FROM admin_audit_log WHERE action = 'user.closure.approve' AND after_json LIKE '%\"cleared\"%'").Scan(&cleared)
+	if leaked != 0 || cleared != 1 {
+		t.Fatalf("expected the closure audited by field name only, got %d entries with the email and %d closure entries", leaked, cleared)
+	}
+	if docs, _ := user["documents"].([]any); len(docs) == 0 || docs[0].(map[string]any)["status"] != "missing" {
+		t.Fatalf("expected documents removed, got %v", user["documents"])
+	}
+	var remaining int
+	database.QueryRow(`SELECT (SELECT COUNT(*) FROM notifications WHERE user_id = ?1)
+		+ (SELECT COUNT(*) FROM notification_preferences WHERE user_id = ?1)
+		+ (SELECT COUNT(*) FROM auction_watches WHERE user_id = ?1)
+		+ (SELECT COUNT(*) FROM saved_searches WHERE user_id = ?1)
+		+ (SELECT COUNT(*) FROM saved_search_matches WHERE search_id = ?2)`, userID, search.ID).Scan(&remaining)
+	if remaining != 0 {
+		t.Fatalf("expected notifications, preferences, watches and saved searches deleted, got %d rows left", remaining)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auth/login", "", map[string]any{
+		"email":    "closing@example.com",
+		"password": "password123",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected closed account to be unable to log in, got %d", resp.StatusCode)
+	}
+}
+
+// --- Registration review ---
+
+func TestRegistrationReviewWorkflow(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	staffToken, staffID := registerTestUser(t, ts, "queue@example.com")
+	applicantToken, applicantID := registerTestUser(t, ts, "waiting@example.com")
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(staffID)+"/roles", map[string]any{
+		"roles": []string{auth.RoleRegistrationsReviewer},
+	})
+	resp.Body.Close()
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/admin/users/"+itoa(applicantID)+"/claim", staffToken, nil)
+	var claimed map[string]any
+	json.NewDecoder(resp.Body).Decode(&claimed)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || int(claimed["assigned_reviewer_id"].(float64)) != staffID {
+		t.Fatalf("expected reviewer to claim registration, got %d %v", resp.StatusCode, claimed)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/admin/users/"+itoa(applicantID)+"/notes", staffToken, map[string]any{
+		"body": "RFC does not match the tax certificate",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 adding note, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(applicantID)+"/request-info", staffToken, map[string]any{
+		"message": "Please upload an updated tax certificate",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 requesting info, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auth/me", applicantToken, nil)
+	var me map[string]any
+	json.NewDecoder(resp.Body).Decode(&me)
+	resp.Body.Close()
+	if me["status"] != "info_requested" || me["info_request"] != "Please upload an updated tax certificate" {
+		t.Fatalf("expected applicant to see the information request, got %v", me)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auth/registration/resubmit", applicantToken, nil)
+	json.NewDecoder(resp.Body).Decode(&me)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || me["status"] != "pending" {
+		t.Fatalf("expected resubmission back to pending, got %d %v", resp.StatusCode, me)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(applicantID)+"/notes", nil)
+	var notes []map[string]any
+	json.NewDecoder(resp.Body).Decode(&notes)
+	resp.Body.Close()
+	if len(notes) != 3 {
+		t.Fatalf("expected note, request and resubmission in thread, got %v", notes)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/review-queue/metrics", nil)
+	var metrics struct {
+		Queue struct {
+			Pending int `json:"pending"`
+		} `json:"queue"`
+		Reviewers []struct {
+			ReviewerID int `json:"reviewer_id"`
+			Assigned   int `json:"assigned"`
+		} `json:"reviewers"`
+	}
+	json.NewDecoder(resp.Body).Decode(&metrics)
+	resp.Body.Close()
+	if metrics.Queue.Pending != 2 || len(metrics.Reviewers) != 1 || metrics.Reviewers[0].ReviewerID != staffID {
+		t.Fatalf("unexpected queue metrics: %+v", metrics)
+	}
+}
+
+// --- Suspensions ---
+
+func TestSuspensionBlocksParticipation(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	token, userID := registerTestUser(t, ts, "suspended@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved' WHERE id = ?", userID); err != nil {
+		t.Fatal(err)
+	}
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/roles", map[string]any{
+		"roles": []string{auth.RoleRegistrationsReviewer},
+	})
+	resp.Body.Close()
+	staffStatus := func() int {
+		t.Helper()
+		resp := bearerRequest(t, "GET", ts.URL+"/api/admin/review-queue/metrics", token, nil)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := staffStatus(); code != http.StatusOK {
+		t.Fatalf("expected staff access before the suspension, got %d", code)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/suspend", map[string]any{
+		"reason": "unpaid invoice",
+		"until":  time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339),
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 suspending user, got %d", resp.StatusCode)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+	var denied map[string]any
+	json.NewDecoder(resp.Body).Decode(&denied)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || denied["reason"] != "unpaid invoice" {
+		t.Fatalf("expected suspended user to be refused with reason, got %d %v", resp.StatusCode, denied)
+	}
+	if code := staffStatus(); code != http.StatusForbidden {
+		t.Fatalf("expected a suspended staff member kept out of the admin API, got %d", code)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/reinstate", map[string]any{"note": "paid"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 reinstating user, got %d", resp.StatusCode)
+	}
+	if code := staffStatus(); code != http.StatusOK {
+		t.Fatalf("expected staff access back after reinstatement, got %d", code)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(userID)+"/status-history", nil)
+	var history []map[string]any
+	json.NewDecoder(resp.Body).Decode(&history)
+	resp.Body.Close()
+	if len(history) != 3 || history[1]["to_status"] != "suspended" || history[2]["to_status"] != "approved" {
+		t.Fatalf("expected registration, suspension and reinstatement in history, got %v", history)
+	}
+}
+
+func TestExpiredSuspensionIsLifted(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	token, userID := registerTestUser(t, ts, "timed@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved' WHERE id = ?", userID); err != nil {
+		t.Fatal(err)
+	}
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/users/"+itoa(userID)+"/suspend", map[string]any{
+		"reason": "late payment",
+		"until":  time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	resp.Body.Close()
+
+	// Still suspended before the end date.
+	runSchedulerOnce(t, database, &recordingMailer{})
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected suspension to hold until its end date, got %d", resp.StatusCode)
+	}
+
+	database.Exec("UPDATE users SET suspended_until = ? WHERE id = ?", time.Now().Add(-time.Minute).UTC().Format(time.RFC3339), userID)
+	runSchedulerOnce(t, database, &recordingMailer{})
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected reinstated user to enroll, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "GET", ts.URL+"/api/admin/users/"+itoa(userID)+"/status-history", nil)
+	var history []map[string]any
+	json.NewDecoder(resp.Body).Decode(&history)
+	resp.Body.Close()
+	if len(history) != 3 {
+		t.Fatalf("expected registration, suspension and reinstatement in history, got %v", history)
+	}
+	if last := history[2]; last["from_status"] != "suspended" || last["to_status"] != "approved" || last["actor_user_id"] != float64(0) || last["actor_api_key_id"] != float64(0) {
+		t.Fatalf("expected an unattributed reinstatement, got %v", last)
+	}
+}
+
+// --- Enrollments ---
+
+func TestEnrollmentPolicyAutoApproval(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollment-policy", map[string]any{
+		"auto_approve_tiers": []string{"100k"},
+		"max_enrollees":      1,
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 saving policy, got %d", resp.StatusCode)
+	}
+
+	token, userID := registerTestUser(t, ts, "tier100@example.com")
+	otherToken, otherID := registerTestUser(t, ts, "late@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved', guarantee_tier = '100k' WHERE id IN (?, ?)", userID, otherID); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+	var enrollment map[string]any
+	json.NewDecoder(resp.Body).Decode(&enrollment)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || enrollment["status"] != "approved" {
+		t.Fatalf("expected auto-approved enrollment, got %d %v", resp.StatusCode, enrollment)
+	}
+
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", otherToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 once the auction is full, got %d", resp.StatusCode)
+	}
+
+	start := time.Now().Add(30 * time.Minute).UTC().Format(time.RFC3339)
+	if _, err := database.Exec("UPDATE auctions SET start_time = ? WHERE id = 1", start); err != nil {
+		t.Fatal(err)
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollment-policy", map[string]any{
+		"deadline_minutes_before_start": 60,
+	})
+	resp.Body.Close()
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", otherToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 after the enrollment deadline, got %d", resp.StatusCode)
+	}
+}
+
+func TestBidRequiresApprovedEnrollment(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	token, userID := registerTestUser(t, ts, "bidder@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved', remaining_opportunities = 5 WHERE id = ?", userID); err != nil {
+		t.Fatal(err)
+	}
+	resp := bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+	resp.Body.Close()
+
+	bid := map[string]any{"amount": 60000}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/bids", token, bid)
+	var body map[string]any
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || body["error"] != "enrollment pending approval" {
+		t.Fatalf("expected pending enrollment to block bid, got %d %v", resp.StatusCode, body)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auctions/1", token, nil)
+	var detail struct {
+		ID           int64          `json:"id"`
+		MyEnrollment map[string]any `json:"my_enrollment"`
+	}
+	json.NewDecoder(resp.Body).Decode(&detail)
+	resp.Body.Close()
+	if detail.ID != 1 || detail.MyEnrollment["status"] != "pending" {
+		t.Fatalf("expected auction detail with pending enrollment, got %+v", detail)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollments/"+itoa(userID)+"/reject", nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/bids", token, bid)
+	body = nil
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || body["error"] != "enrollment rejected" {
+		t.Fatalf("expected rejected enrollment to block bid, got %d %v", resp.StatusCode, body)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollments/"+itoa(userID)+"/approve", nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/bids", token, bid)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected approved enrollment to bid, got %d", resp.StatusCode)
+	}
+}
+
+func TestBulkEnrollmentReview(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	token100, id100 := registerTestUser(t, ts, "bulk100@example.com")
+	token50, id50 := registerTestUser(t, ts, "bulk50@example.com")
+	_, pendingID := registerTestUser(t, ts, "bulkpending@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved', guarantee_tier = '100k' WHERE id = ?", id100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec("UPDATE users SET status = 'approved', guarantee_tier = '50k' WHERE id = ?", id50); err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{token100, token50} {
+		resp := bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+		resp.Body.Close()
+	}
+	if _, err := database.Exec("INSERT INTO auction_enrollments (auction_id, user_id) VALUES (1, ?)", pendingID); err != nil {
+		t.Fatal(err)
+	}
+
+	type bulkResponse struct {
+		Matched int              `json:"matched"`
+		Results []map[string]any `json:"results"`
+	}
+	resp := adminRequest(t, "POST", ts.URL+"/api/admin/enrollments/bulk", map[string]any{
+		"action":         "approve",
+		"auction_ids":    []int64{1},
+		"guarantee_tier": "100k",
+		"dry_run":        true,
+	})
+	var preview bulkResponse
+	json.NewDecoder(resp.Body).Decode(&preview)
+	resp.Body.Close()
+	if preview.Matched != 1 || preview.Results[0]["result"] != "would_approve" {
+		t.Fatalf("expected dry run to preview one approval, got %+v", preview)
+	}
+
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions/1/enrollments/bulk", map[string]any{"action": "approve"})
+	var applied bulkResponse
+	json.NewDecoder(resp.Body).Decode(&applied)
+	resp.Body.Close()
+	got := map[float64]string{}
+	for _, res := range applied.Results {
+		got[res["user_id"].(float64)] = res["result"].(string)
+	}
+	if got[float64(id100)] != "approved" || got[float64(id50)] != "approved" || got[float64(pendingID)] != "skipped" {
+		t.Fatalf("expected two approvals and one skip, got %v", got)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/enrollments", token50, nil)
+	var mine []map[string]any
+	json.NewDecoder(resp.Body).Decode(&mine)
+	resp.Body.Close()
+	if len(mine) != 1 || mine[0]["status"] != "approved" || mine[0]["auction_title"] == "" {
+		t.Fatalf("expected one approved enrollment with auction title, got %v", mine)
+	}
+
+	database.Exec("UPDATE auction_enrollments SET status = 'waitlisted' WHERE user_id = ?", pendingID)
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/enrollments/bulk", map[string]any{
+		"action":          "reject",
+		"auction_ids":     []int64{1},
+		"status":          "waitlisted",
+		"registered_from": "2000-01-01",
+		"registered_to":   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	applied = bulkResponse{}
+	json.NewDecoder(resp.Body).Decode(&applied)
+	resp.Body.Close()
+	if applied.Matched != 1 || applied.Results[0]["result"] != "rejected" {
+		t.Fatalf("expected the waitlisted enrollment rejected, got %d %+v", resp.StatusCode, applied)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/enrollments/bulk", map[string]any{
+		"action": "approve", "auction_ids": []int64{1}, "registered_from": "ayer",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unparseable registered_from, got %d", resp.StatusCode)
+	}
+}
+
+// --- Auction visibility ---
+
+func TestInviteOnlyAuctionAndWaitlist(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	resp := adminRequest(t, "POST", ts.URL+"/api/admin/auctions", map[string]any{
+		"title":       "Private lot",
+		"description": "Invite only",
+		"location":    "Monterrey, MX",
+		"end_time":    time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+		"visibility":  "invite_only",
+	})
+	var private map[string]any
+	json.NewDecoder(resp.Body).Decode(&private)
+	resp.Body.Close()
+	privateID := itoa(int(private["id"].(float64)))
+
+	guestToken, guestID := registerTestUser(t, ts, "guest@example.com")
+	outsiderToken, outsiderID := registerTestUser(t, ts, "outsider@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved' WHERE id IN (?, ?)", guestID, outsiderID); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions/"+privateID+"/invites", map[string]any{"emails": []string{"Guest@example.com", "nobody@example.com"}})
+	var invited struct {
+		Results []map[string]any `json:"results"`
+	}
+	json.NewDecoder(resp.Body).Decode(&invited)
+	resp.Body.Close()
+	if len(invited.Results) != 2 || invited.Results[1]["result"] != "invited" {
+		t.Fatalf("expected guest invited and unknown email reported, got %v", invited.Results)
+	}
+
+	listed := func(token string) bool {
+		resp := bearerRequest(t, "GET", ts.URL+"/api/auctions", token, nil)
+		defer resp.Body.Close()
+		var items []map[string]any
+		items = decodePage(t, resp)
+		for _, a := range items {
+			if a["id"] == private["id"] {
+				return true
+			}
+		}
+		return false
+	}
+	if !listed(guestToken) || listed(outsiderToken) {
+		t.Fatal("expected private auction listed for the invited user only")
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auctions/"+privateID, outsiderToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for uninvited user, got %d", resp.StatusCode)
+	}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/"+privateID+"/enroll", outsiderToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 enrolling uninvited, got %d", resp.StatusCode)
+	}
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auctions/"+privateID+"/enroll", guestToken, nil)
+	var enrollment map[string]any
+	json.NewDecoder(resp.Body).Decode(&enrollment)
+	resp.Body.Close()
+	if enrollment["status"] != "approved" {
+		t.Fatalf("expected invite to approve enrollment, got %v", enrollment)
+	}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/"+privateID+"/enroll", guestToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected invited user to reach enrollment, got %d", resp.StatusCode)
+	}
+
+	for _, tc := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/api/auctions/" + privateID + "/bids", outsiderToken, http.StatusNotFound},
+		{"GET", "/api/auctions/" + privateID + "/bids", "", http.StatusNotFound},
+		{"GET", "/api/auctions/" + privateID + "/bids", guestToken, http.StatusOK},
+		{"POST", "/api/auctions/" + privateID + "/bids", outsiderToken, http.StatusNotFound},
+		{"GET", "/api/ws/auctions/" + privateID + "?token=" + outsiderToken, "", http.StatusNotFound},
+		// A plain GET cannot upgrade, so an allowed caller gets 400 instead.
+		{"GET", "/api/ws/auctions/" + privateID + "?token=" + guestToken, "", http.StatusBadRequest},
+	} {
+		var body any
+		if tc.method == "POST" {
+			body = map[string]any{"amount": 50000}
+		}
+		resp = bearerRequest(t, tc.method, ts.URL+tc.path, tc.token, body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Fatalf("expected %d for %s %s, got %d", tc.want, tc.method, tc.path, resp.StatusCode)
+		}
+	}
+
+	// Revoking the invite hides the auction from the guest's watchlist and
+	// stops its alerts.
+	resp = bearerRequest(t, "POST", ts.URL+"/api/watches/"+privateID, guestToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected invited user to watch the auction, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "DELETE", ts.URL+"/api/admin/auctions/"+privateID+"/invites/"+itoa(guestID), nil)
+	resp.Body.Close()
+	database.Exec("UPDATE auctions SET current_bid = current_bid + 5000 WHERE id = ?", privateID)
+	runSchedulerOnce(t, database, &recordingMailer{})
+	resp = bearerRequest(t, "GET", ts.URL+"/api/watches", guestToken, nil)
+	var watches []map[string]any
+	json.NewDecoder(resp.Body).Decode(&watches)
+	resp.Body.Close()
+	if len(watches) != 0 {
+		t.Fatalf("expected revoked auction hidden from the watchlist, got %v", watches)
+	}
+	resp = bearerRequest(t, "GET", ts.URL+"/api/notifications", guestToken, nil)
+	var notes struct {
+		Items []map[string]any `json:"items"`
+	}
+	json.NewDecoder(resp.Body).Decode(&notes)
+	resp.Body.Close()
+	for _, n := range notes.Items {
+		if n["item_type"] == "auction" && n["item_id"] == private["id"] {
+			t.Fatalf("expected no alerts after the invite was revoked, got %v", n)
+		}
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollment-policy", map[string]any{"max_enrollees": 1, "waitlist": true})
+	resp.Body.Close()
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", guestToken, nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", outsiderToken, nil)
+	json.NewDecoder(resp.Body).Decode(&enrollment)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || enrollment["status"] != "waitlisted" {
+		t.Fatalf("expected second enrollment waitlisted, got %d %v", resp.StatusCode, enrollment)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollments/"+itoa(guestID)+"/reject", nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "GET", ts.URL+"/api/auctions/1/enroll", outsiderToken, nil)
+	json.NewDecoder(resp.Body).Decode(&enrollment)
+	resp.Body.Close()
+	if enrollment["status"] != "pending" {
+		t.Fatalf("expected waitlisted enrollment promoted after rejection, got %v", enrollment)
+	}
+}
+
+// --- Catalog and search ---
+
+func TestCategoryTreeAndFiltering(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/api/categories/maquinaria-de-construccion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root struct {
+		Category map[string]any `json:"category"`
+	}
+	json.NewDecoder(resp.Body).Decode(&root)
+	resp.Body.Close()
+	rootID := root.Category["id"].(float64)
+
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/categories", map[string]any{"name": "Excavadoras", "parent_id": rootID})
+	var child map[string]any
+	json.NewDecoder(resp.Body).Decode(&child)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || child["slug"] != "excavadoras" {
+		t.Fatalf("expected child category with slug, got %d %v", resp.StatusCode, child)
+	}
+	childID := child["id"].(float64)
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/categories/"+itoa(int(rootID)), map[string]any{"name": "Maquinaria de construcción", "parent_id": childID})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 moving a category under its child, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "DELETE", ts.URL+"/api/admin/categories/"+itoa(int(rootID)), nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 deleting a category with children, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/1/categories", map[string]any{"category_ids": []float64{childID}})
+	resp.Body.Close()
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/categories", map[string]any{"category_ids": []float64{childID}})
+	resp.Body.Close()
+
+	var listings []map[string]any
+	resp, _ = http.Get(ts.URL + "/api/listings?category=maquinaria-de-construccion")
+	listings = decodePage(t, resp)
+	resp.Body.Close()
+	if len(listings) != 1 || listings[0]["id"].(float64) != 1 {
+		t.Fatalf("expected parent category to include the child's listing, got %v", listings)
+	}
+	var auctions []map[string]any
+	resp, _ = http.Get(ts.URL + "/api/auctions?category=excavadoras")
+	auctions = decodePage(t, resp)
+	resp.Body.Close()
+	if len(auctions) != 1 || auctions[0]["id"].(float64) != 1 {
+		t.Fatalf("expected one auction in category, got %v", auctions)
+	}
+	resp, _ = http.Get(ts.URL + "/api/listings?category=no-existe")
+	listings = nil
+	listings = decodePage(t, resp)
+	resp.Body.Close()
+	if len(listings) != 0 {
+		t.Fatalf("expected no listings for unknown category, got %v", listings)
+	}
+
+	var categories []map[string]any
+	resp, _ = http.Get(ts.URL + "/api/categories")
+	json.NewDecoder(resp.Body).Decode(&categories)
+	resp.Body.Close()
+	for _, c := range categories {
+		if c["id"].(float64) == rootID && (c["listing_count"].(float64) != 1 || c["auction_count"].(float64) != 1) {
+			t.Fatalf("expected root counts to include descendants, got %v", c)
+		}
+	}
+
+	var detail map[string]any
+	resp, _ = http.Get(ts.URL + "/api/listings/1")
+	json.NewDecoder(resp.Body).Decode(&detail)
+	resp.Body.Close()
+	if cats, _ := detail["categories"].([]any); len(cats) != 1 {
+		t.Fatalf("expected listing detail to carry its category, got %v", detail["categories"])
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	search := func(query string) map[string]any {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/api/search?" + query)
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for %q, got %d", query, resp.StatusCode)
+		}
+		var body map[string]any
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body
+	}
+
+	body := search("q=gruas")
+	results := body["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected plural, accent-free query to match the crane auction, got %v", results)
+	}
+	hit := results[0].(map[string]any)
+	if hit["type"] != "auction" || hit["id"].(float64) != 2 {
+		t.Fatalf("expected auction 2, got %v", hit)
+	}
+	if title := hit["highlights"].(map[string]any)["title"].(string); !strings.Contains(title, "<mark>Grúa</mark>") {
+		t.Fatalf("expected highlighted title, got %q", title)
+	}
+
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/listings/3/categories", map[string]any{"category_ids": []float64{1}})
+	resp.Body.Close()
+	body = search("q=maquinaria+de+construccion&type=listings")
+	results = body["results"].([]any)
+	if len(results) != 1 || results[0].(map[string]any)["id"].(float64) != 3 {
+		t.Fatalf("expected category name to be searchable, got %v", results)
+	}
+
+	body = search("q=gruas&type=listings")
+	if len(body["results"].([]any)) != 0 {
+		t.Fatalf("expected type filter to exclude auctions, got %v", body["results"])
+	}
+
+	database.Exec("UPDATE listings SET title = 'Grúa <b>articulada</b>' WHERE id = 1")
+	body = search("q=grua&limit=1")
+	results = body["results"].([]any)
+	if body["total"].(float64) != 2 || len(results) != 1 {
+		t.Fatalf("expected total to count past the limit, got %v of %v", len(results), body["total"])
+	}
+	body = search("q=articulada")
+	results = body["results"].([]any)
+	if title := results[0].(map[string]any)["highlights"].(map[string]any)["title"].(string); title != "Grúa &lt;b&gt;<mark>articulada</mark>&lt;/b&gt;" {
+		t.Fatalf("expected item text escaped around the marks, got %q", title)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/search?q=+de+")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a stopword-only query, got %d", resp.StatusCode)
+	}
+}
+
+func TestCatalogFiltersAndPagination(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	type page struct {
+		Items      []map[string]any `json:"items"`
+		NextCursor string           `json:"next_cursor"`
+		Facets     map[string]any   `json:"facets"`
+	}
+	get := func(path string) page {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", path, resp.StatusCode)
+		}
+		var p page
+		json.NewDecoder(resp.Body).Decode(&p)
+		return p
+	}
+
+	var prices []float64
+	p := get("/api/listings?sort=price_asc&limit=2")
+	for {
+		for _, item := range p.Items {
+			prices = append(prices, item["price"].(float64))
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		p = get("/api/listings?sort=price_asc&limit=2&cursor=" + p.NextCursor)
+	}
+	if len(prices) != 4 || prices[0] != 21900 || prices[3] != 110000 {
+		t.Fatalf("expected all four listings by ascending price across pages, got %v", prices)
+	}
+
+	p = get("/api/listings?sale_type=direct")
+	if len(p.Items) != 2 {
+		t.Fatalf("expected 2 direct listings, got %d", len(p.Items))
+	}
+	if saleTypes := p.Facets["sale_type"].([]any); len(saleTypes) != 2 {
+		t.Fatalf("expected sale_type facet to ignore its own filter, got %v", saleTypes)
+	}
+	if year := p.Facets["year"].(map[string]any); year["min"].(float64) != 2015 || year["max"].(float64) != 2020 {
+		t.Fatalf("expected year range of direct listings, got %v", year)
+	}
+
+	p = get("/api/listings?year_min=2016&location=le%C3%B3n")
+	if len(p.Items) != 1 || p.Items[0]["title"] != "Plataforma Genie Z-45" {
+		t.Fatalf("expected year and location filters to combine, got %v", p.Items)
+	}
+	for _, location := range []string{"%25", "_", "%5C"} {
+		if p = get("/api/listings?location=" + location); len(p.Items) != 0 {
+			t.Fatalf("expected location %s matched literally, got %v", location, p.Items)
+		}
+	}
+
+	p = get("/api/auctions?sort=price_desc&price_max=60000")
+	if len(p.Items) != 2 || p.Items[0]["current_bid"].(float64) != 58000 {
+		t.Fatalf("expected auctions under the price cap by descending bid, got %v", p.Items)
+	}
+	if price := p.Facets["price"].(map[string]any); price["max"].(float64) != 72500 {
+		t.Fatalf("expected price facet to ignore the price filter, got %v", price)
+	}
+
+	first := get("/api/listings?limit=1")
+	for _, path := range []string{
+		"/api/listings?sort=cheapest",
+		"/api/listings?sort=price_asc&cursor=" + first.NextCursor,
+		"/api/listings?cursor=garbage",
+		"/api/listings?price_min=abc",
+		"/api/auctions?year_min=2010",
+		"/api/listings?status=inactive",
+		"/api/auctions?status=draft",
+	} {
+		resp, _ := http.Get(ts.URL + path)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestAuctionTimesMustBeRFC3339(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	resp := adminRequest(t, "POST", ts.URL+"/api/admin/auctions", map[string]any{
+		"title": "Test", "description": "Desc", "location": "MX", "end_time": "pronto",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 creating with a bad end_time, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1", map[string]any{
+		"title": "Test", "description": "Desc", "location": "MX", "status": "active",
+		"start_time": "mañana", "end_time": "2026-12-31T23:59:59Z",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 updating with a bad start_time, got %d", resp.StatusCode)
+	}
+
+	// Rows written before validation existed still page through ending_soon.
+	database.Exec("UPDATE auctions SET end_time = 'pronto' WHERE id IN (1, 2)")
+	type page struct {
+		Items []struct {
+			ID int64 `json:"id"`
+		} `json:"items"`
+		NextCursor string `json:"next_cursor"`
+	}
+	get := func(path string) page {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", path, resp.StatusCode)
+		}
+		var p page
+		json.NewDecoder(resp.Body).Decode(&p)
+		return p
+	}
+	all := get("/api/auctions?sort=ending_soon&limit=50")
+	seen := map[int64]bool{}
+	p := get("/api/auctions?sort=ending_soon&limit=1")
+	for {
+		for _, item := range p.Items {
+			seen[item.ID] = true
+		}
+		if p.NextCursor == "" {
+			break
+		}
+		p = get("/api/auctions?sort=ending_soon&limit=1&cursor=" + p.NextCursor)
+	}
+	if len(all.Items) < 3 || len(seen) != len(all.Items) || !seen[1] || !seen[2] {
+		t.Fatalf("expected every auction across pages, got %v of %d", seen, len(all.Items))
+	}
+}
+
+// --- Specifications ---
+
+func TestListingSpecifications(t *testing.T) {
+	ts, _ := setupTestServer(t)
+
+	var attrs []map[string]any
+	resp, _ := http.Get(ts.URL + "/api/categories/equipo-de-elevacion/specs")
+	json.NewDecoder(resp.Body).Decode(&attrs)
+	resp.Body.Close()
+	keys := map[string]bool{}
+	for _, a := range attrs {
+		keys[a["key"].(string)] = true
+	}
+	if !keys["make"] || !keys["lift_capacity"] || keys["load_capacity"] {
+		t.Fatalf("expected global and category attributes only, got %v", keys)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/2/categories", map[string]any{"category_ids": []int{2}})
+	resp.Body.Close()
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/2/specs", map[string]any{"specs": map[string]any{
+		"make": "Genie", "hours": "3200", "condition": "Usado", "lift_capacity": 0.23,
+	}})
+	var specs []map[string]any
+	json.NewDecoder(resp.Body).Decode(&specs)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(specs) != 4 {
+		t.Fatalf("expected 4 specs stored, got %d %v", resp.StatusCode, specs)
+	}
+	for _, bad := range []map[string]any{
+		{"load_capacity": 1500},
+		{"hours": -5},
+		{"condition": "roto"},
+		{"make": 12},
+	} {
+		resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/2/specs", map[string]any{"specs": bad})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %v, got %d", bad, resp.StatusCode)
+		}
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/3/specs", map[string]any{"specs": map[string]any{"make": "Caterpillar", "hours": 8000}})
+	resp.Body.Close()
+
+	var detail map[string]any
+	resp, _ = http.Get(ts.URL + "/api/listings/2")
+	json.NewDecoder(resp.Body).Decode(&detail)
+	resp.Body.Close()
+	found := false
+	for _, sp := range detail["specs"].([]any) {
+		sp := sp.(map[string]any)
+		if sp["key"] == "condition" && sp["value"] != "usado" {
+			t.Fatalf("expected enum value normalized to its option, got %v", sp["value"])
+		}
+		if sp["key"] == "hours" {
+			found = sp["value"].(float64) == 3200 && sp["unit"] == "h"
+		}
+	}
+	if !found {
+		t.Fatalf("expected numeric hours spec in detail, got %v", detail["specs"])
+	}
+
+	for query, want := range map[string]float64{
+		"spec.make=genie":      2,
+		"spec.hours_max=5000":  2,
+		"spec.hours_min=5000":  3,
+		"spec.condition=usado": 2,
+	} {
+		resp, _ = http.Get(ts.URL + "/api/listings?" + query)
+		items := decodePage(t, resp)
+		resp.Body.Close()
+		if len(items) != 1 || items[0]["id"].(float64) != want {
+			t.Fatalf("expected listing %v for %s, got %v", want, query, items)
+		}
+	}
+
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/spec-attributes", map[string]any{"category_id": 1, "key": "make", "label": "Marca", "type": "text"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 redefining a global key, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/spec-attributes", map[string]any{"key": "hours_max", "label": "x", "type": "number"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a reserved key suffix, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/spec-attributes", map[string]any{"category_id": 2, "key": "boom_length", "label": "Alcance", "type": "number", "unit": "m", "required": 1})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected attribute created, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/2/specs", map[string]any{"specs": map[string]any{"make": "Genie"}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 when a required spec is missing, got %d", resp.StatusCode)
+	}
+}
+
+// --- Image gallery ---
+
+func uploadTestImage(t *testing.T, ts *httptest.Server, path string, w, h int, fields map[string]string) *http.Response {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	part, err := mw.CreateFormFile("file", "foto.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(part, img); err != nil {
+		t.Fatal(err)
+	}
+	mw.Close()
+	req, err := http.NewRequest("POST", ts.URL+path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-API-Key", testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestItemImageGallery(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	type variant struct {
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}
+	type galleryImage struct {
+		ID        int64              `json:"id"`
+		Caption   string             `json:"caption"`
+		IsPrimary int                `json:"is_primary"`
+		Variants  map[string]variant `json:"variants"`
+	}
+	upload := func(w, h int, fields map[string]string) galleryImage {
+		t.Helper()
+		resp := uploadTestImage(t, ts, "/api/admin/listings/1/images", w, h, fields)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			body, _ := io.ReadAll(resp.Body)
+			t.Fatalf("expected 201 uploading image, got %d: %s", resp.StatusCode, body)
+		}
+		var img galleryImage
+		json.NewDecoder(resp.Body).Decode(&img)
+		return img
+	}
+	listingImageURL := func() string {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/api/listings/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var listing struct {
+			ImageURL string `json:"image_url"`
+		}
+		json.NewDecoder(resp.Body).Decode(&listing)
+		return listing.ImageURL
+	}
+
+	first := upload(400, 300, map[string]string{"caption": "Vista frontal"})
+	if first.IsPrimary != 1 || first.Caption != "Vista frontal" {
+		t.Fatalf("expected first image to be primary with caption, got %+v", first)
+	}
+	if v := first.Variants["thumb"]; v.Width != 240 || v.Height != 240 {
+		t.Fatalf("expected 240x240 thumbnail, got %+v", v)
+	}
+	if v := first.Variants["original"]; v.Width != 400 || v.Height != 300 {
+		t.Fatalf("expected original size kept, got %+v", v)
+	}
+	if got := listingImageURL(); got != first.Variants["large"].URL {
+		t.Fatalf("expected image_url to follow the primary image, got %q", got)
+	}
+
+	resp, err := http.Get(ts.URL + first.Variants["medium"].URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("expected png variant, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("served variant is not a png: %v", err)
+	}
+	if cc := resp.Header.Get("Cache-Control"); !strings.HasPrefix(cc, "public") {
+		t.Fatalf("expected listing images cached publicly, got %q", cc)
+	}
+
+	resp = uploadTestImage(t, ts, "/api/admin/auctions/3/images", 64, 48, nil)
+	var auctionImage galleryImage
+	json.NewDecoder(resp.Body).Decode(&auctionImage)
+	resp.Body.Close()
+	database.Exec("UPDATE auctions SET visibility = 'unlisted' WHERE id = 3")
+	resp, err = http.Get(ts.URL + auctionImage.Variants["thumb"].URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if cc := resp.Header.Get("Cache-Control"); resp.StatusCode != http.StatusOK || strings.Contains(cc, "public") {
+		t.Fatalf("expected a non-public auction's image kept out of shared caches, got %d %q", resp.StatusCode, cc)
+	}
+
+	second := upload(50, 80, map[string]string{"primary": "true"})
+	if second.IsPrimary != 1 {
+		t.Fatal("expected primary=true to make the upload the cover")
+	}
+	if got := listingImageURL(); got != second.Variants["large"].URL {
+		t.Fatalf("expected image_url to switch to the new cover, got %q", got)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/1/images/order", map[string]any{"image_ids": []int64{second.ID}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an incomplete order, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/1/images/order", map[string]any{"image_ids": []int64{second.ID, first.ID}})
+	var gallery []galleryImage
+	json.NewDecoder(resp.Body).Decode(&gallery)
+	resp.Body.Close()
+	if len(gallery) != 2 || gallery[0].ID != second.ID {
+		t.Fatalf("expected reordered gallery, got %+v", gallery)
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/2/images/"+itoa(int(first.ID)), map[string]any{"caption": "x"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 editing an image through another listing, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "DELETE", ts.URL+"/api/admin/listings/1/images/"+itoa(int(second.ID)), nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 deleting image, got %d", resp.StatusCode)
+	}
+	if got := listingImageURL(); got != first.Variants["large"].URL {
+		t.Fatalf("expected remaining image to become the cover, got %q", got)
+	}
+	resp, _ = http.Get(ts.URL + second.Variants["thumb"].URL)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected deleted variant to be gone, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "DELETE", ts.URL+"/api/admin/listings/1/images/"+itoa(int(first.ID)), nil)
+	resp.Body.Close()
+	if got := listingImageURL(); got != "" {
+		t.Fatalf("expected image_url cleared with the gallery, got %q", got)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, _ := mw.CreateFormFile("file", "ficha.pdf")
+	part.Write([]byte("%PDF-1.4 not an image"))
+	mw.Close()
+	req, _ := http.NewRequest("POST", ts.URL+"/api/admin/listings/1/images", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-API-Key", testToken)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 for a pdf, got %d", resp.StatusCode)
+	}
+}
+
+// --- Attachments ---
+
+func uploadTestAttachment(t *testing.T, ts *httptest.Server, path, filename string, content []byte, fields map[string]string) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	mw.Close()
+	req, err := http.NewRequest("POST", ts.URL+path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-API-Key", testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestItemAttachmentAccess(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	type attachment struct {
+		ID        int64  `json:"id"`
+		Title     string `json:"title"`
+		URL       string `json:"url"`
+		Available bool   `json:"available"`
+	}
+	pdf := []byte("%PDF-1.4\n% inspection report\n")
+	upload := func(title, visibility string) attachment {
+		t.Helper()
+		resp := uploadTestAttachment(t, ts, "/api/admin/auctions/1/attachments", "inspeccion.pdf", pdf, map[string]string{
+			"title":      title,
+			"visibility": visibility,
+		})
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected 201 uploading attachment, got %d", resp.StatusCode)
+		}
+		var a attachment
+		json.NewDecoder(resp.Body).Decode(&a)
+		return a
+	}
+	public := upload("Ficha técnica", "public")
+	report := upload("Reporte de inspección", "enrolled")
+
+	resp := uploadTestAttachment(t, ts, "/api/admin/auctions/1/attachments", "notas.txt", []byte("just text"), map[string]string{"title": "Notas"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 for a text file, got %d", resp.StatusCode)
+	}
+	resp = uploadTestAttachment(t, ts, "/api/admin/auctions/1/attachments", "x.pdf", pdf, map[string]string{"title": "X", "visibility": "staff"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown visibility, got %d", resp.StatusCode)
+	}
+
+	detail := func(token string) []attachment {
+		t.Helper()
+		resp := bearerRequest(t, "GET", ts.URL+"/api/auctions/1", token, nil)
+		defer resp.Body.Close()
+		var out struct {
+			Attachments []attachment `json:"attachments"`
+		}
+		json.NewDecoder(resp.Body).Decode(&out)
+		return out.Attachments
+	}
+	download := func(url, token string) int {
+		t.Helper()
+		resp := bearerRequest(t, "GET", ts.URL+url, token, nil)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	anon := detail("")
+	if len(anon) != 2 || !anon[0].Available || anon[1].Available || anon[1].Title != "Reporte de inspección" {
+		t.Fatalf("expected both titles with only the public one available, got %+v", anon)
+	}
+	if code := download(public.URL, ""); code != http.StatusOK {
+		t.Fatalf("expected public attachment to download, got %d", code)
+	}
+	if code := download(report.URL, ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an anonymous enrolled-only download, got %d", code)
+	}
+
+	token, userID := registerTestUser(t, ts, "inspector@example.com")
+	if _, err := database.Exec("UPDATE users SET status = 'approved', remaining_opportunities = 5 WHERE id = ?", userID); err != nil {
+		t.Fatal(err)
+	}
+	if code := download(report.URL, token); code != http.StatusForbidden {
+		t.Fatalf("expected 403 before enrolling, got %d", code)
+	}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/auctions/1/enroll", token, nil)
+	resp.Body.Close()
+	if code := download(report.URL, token); code != http.StatusForbidden {
+		t.Fatalf("expected 403 while the enrollment is pending, got %d", code)
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/enrollments/"+itoa(userID)+"/approve", nil)
+	resp.Body.Close()
+	if got := detail(token); len(got) != 2 || !got[1].Available {
+		t.Fatalf("expected enrolled bidder to see the report as available, got %+v", got)
+	}
+	resp = bearerRequest(t, "GET", ts.URL+report.URL, token, nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" || !bytes.Equal(body, pdf) {
+		t.Fatalf("expected the report for an enrolled bidder, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/2/attachments/"+itoa(int(report.ID)), map[string]any{"title": "X", "visibility": "public"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 editing through another auction, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/auctions/1/attachments/"+itoa(int(report.ID)), map[string]any{"title": "Reporte de inspección", "visibility": "public"})
+	resp.Body.Close()
+	if code := download(report.URL, ""); code != http.StatusOK {
+		t.Fatalf("expected report to be public after update, got %d", code)
+	}
+
+	resp = adminRequest(t, "DELETE", ts.URL+"/api/admin/auctions/1/attachments/"+itoa(int(public.ID)), nil)
+	resp.Body.Close()
+	if code := download(public.URL, ""); code != http.StatusNotFound {
+		t.Fatalf("expected deleted attachment to be gone, got %d", code)
+	}
+}
+
+// --- Listing conversions ---
+
+func TestConvertListingToAuction(t *testing.T) {
+	ts, database := setupTestServer(t)
+
+	resp := adminRequest(t, "PUT", ts.URL+"/api/admin/listings/1/categories", map[string]any{"category_ids": []int{1}})
+	resp.Body.Close()
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/1/specs", map[string]any{"specs": map[string]any{"make": "Caterpillar"}})
+	resp.Body.Close()
+	resp = uploadTestImage(t, ts, "/api/admin/listings/1/images", 64, 48, nil)
+	var listingImage struct {
+		Variants map[string]struct {
+			URL string `json:"url"`
+		} `json:"variants"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listingImage)
+	resp.Body.Close()
+
+	end := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/listings/1/convert-to-auction", map[string]any{"reserve_price": 100000})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without end_time, got %d", resp.StatusCode)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/listings/1/convert-to-auction", map[string]any{
+		"end_time": end, "reserve_price": 100000, "return_if_unsold": 1,
+	})
+	var converted struct {
+		Auction struct {
+			ID       int64  `json:"id"`
+			Title    string `json:"title"`
+			ImageURL string `json:"image_url"`
+		} `json:"auction"`
+	}
+	json.NewDecoder(resp.Body).Decode(&converted)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || converted.Auction.Title != "Bulldozer D6T" {
+		t.Fatalf("expected auction created from the listing, got %d %+v", resp.StatusCode, converted)
+	}
+	auctionID := int(converted.Auction.ID)
+
+	resp, _ = http.Get(ts.URL + "/api/auctions/" + itoa(auctionID))
+	var auction struct {
+		ImageURL   string           `json:"image_url"`
+		Categories []map[string]any `json:"categories"`
+		Specs      []map[string]any `json:"specs"`
+		Images     []map[string]any `json:"images"`
+		Link       struct {
+			ListingID int64 `json:"listing_id"`
+		} `json:"listing_link"`
+	}
+	json.NewDecoder(resp.Body).Decode(&auction)
+	resp.Body.Close()
+	if len(auction.Categories) != 1 || len(auction.Specs) != 1 || len(auction.Images) != 1 || auction.Link.ListingID != 1 {
+		t.Fatalf("expected categories, specs, photo and link copied, got %+v", auction)
+	}
+	if auction.ImageURL == listingImage.Variants["large"].URL || !strings.HasPrefix(auction.ImageURL, "/api/images/") {
+		t.Fatalf("expected the auction to use its own copy of the photo, got %q", auction.ImageURL)
+	}
+
+	var listing struct {
+		Status string `json:"status"`
+		Price  int64  `json:"price"`
+	}
+	resp, _ = http.Get(ts.URL + "/api/listings/1")
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if listing.Status != "auction" {
+		t.Fatalf("expected listing marked as in auction, got %q", listing.Status)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/listings/1/convert-to-auction", map[string]any{"end_time": end})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 converting a listing already in auction, got %d", resp.StatusCode)
+	}
+
+	// Concurrent conversions of one listing create a single auction.
+	var wg sync.WaitGroup
+	codes := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := adminRequest(t, "POST", ts.URL+"/api/admin/listings/2/convert-to-auction", map[string]any{"end_time": end})
+			resp.Body.Close()
+			codes <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	created := 0
+	for code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("expected 201 or 409 converting concurrently, got %d", code)
+		}
+	}
+	var links int
+	database.QueryRow("SELECT COUNT(*) FROM listing_auction_links WHERE listing_id = 2").Scan(&links)
+	if created != 1 || links != 1 {
+		t.Fatalf("expected one auction from concurrent conversions, got %d created and %d links", created, links)
+	}
+
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions/"+itoa(auctionID)+"/return-to-listing", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 returning a running auction, got %d", resp.StatusCode)
+	}
+	if _, err := database.Exec("UPDATE auctions SET status = 'closed' WHERE id IN (?, 3)", auctionID); err != nil {
+		t.Fatal(err)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions/"+itoa(auctionID)+"/return-to-listing", map[string]any{"price": 99000})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 returning an unsold auction, got %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(ts.URL + "/api/listings/1")
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if listing.Status != "active" || listing.Price != 99000 {
+		t.Fatalf("expected listing back in the catalog repriced, got %+v", listing)
+	}
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions/"+itoa(auctionID)+"/return-to-listing", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 returning twice, got %d", resp.StatusCode)
+	}
+
+	// Photos were copied, so deleting the auction leaves the listing's intact.
+	resp = adminRequest(t, "DELETE", ts.URL+"/api/admin/auctions/"+itoa(auctionID), nil)
+	resp.Body.Close()
+	resp, _ = http.Get(ts.URL + listingImage.Variants["thumb"].URL)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected listing photo to survive the auction's deletion, got %d", resp.StatusCode)
+	}
+
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions/3/return-to-listing", nil)
+	var returned struct {
+		Listing struct {
+			Title    string `json:"title"`
+			SaleType string `json:"sale_type"`
+			Status   string `json:"status"`
+		} `json:"listing"`
+	}
+	json.NewDecoder(resp.Body).Decode(&returned)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || returned.Listing.Title != "Cargador Cat 950H" || returned.Listing.Status != "active" {
+		t.Fatalf("expected a new listing from the auction, got %d %+v", resp.StatusCode, returned)
+	}
+}
+
+// --- Alerts ---
+
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) messagesTo(email string) []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []mailer.Message
+	for _, msg := range m.sent {
+		if msg.To == email {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// runSchedulerOnce runs a single scheduler tick: Start ticks before it first
+// checks for Stop.
+func runSchedulerOnce(t *testing.T, database *sql.DB, m mailer.Mailer) {
+	t.Helper()
+	sched := scheduler.New(sqlc.New(database), zerolog.Nop())
+	sched.SetMailer(m)
+	done := make(chan struct{})
+	go func() {
+		sched.Start(context.Background())
+		close(done)
+	}()
+	sched.Stop()
+	<-done
+}
+
+func TestLikeAlerts(t *testing.T) {
+	ts, database := setupTestServer(t)
+	m := &recordingMailer{}
+
+	instantToken, _ := registerTestUser(t, ts, "instant@example.com")
+	digestToken, _ := registerTestUser(t, ts, "digest@example.com")
+	quietToken, _ := registerTestUser(t, ts, "quiet@example.com")
+	for _, token := range []string{instantToken, digestToken, quietToken} {
+		resp := bearerRequest(t, "POST", ts.URL+"/api/likes/1", token, nil)
+		resp.Body.Close()
+	}
+	resp := bearerRequest(t, "PUT", ts.URL+"/api/notifications/preferences", digestToken, map[string]any{"email_mode": "digest"})
+	resp.Body.Close()
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/notifications/preferences", quietToken, map[string]any{"price_drops": 0, "email_mode": "off"})
+	var prefs map[string]any
+	json.NewDecoder(resp.Body).Decode(&prefs)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || prefs["price_drops"] != float64(0) || prefs["status_changes"] != float64(1) || prefs["email_mode"] != "off" {
+		t.Fatalf("expected partial preference update, got %d %v", resp.StatusCode, prefs)
+	}
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/notifications/preferences", quietToken, map[string]any{"websocket": 2})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid flag, got %d", resp.StatusCode)
+	}
+
+	type feed struct {
+		Items []struct {
+			ID        int64  `json:"id"`
+			Kind      string `json:"kind"`
+			ItemType  string `json:"item_type"`
+			ItemID    int64  `json:"item_id"`
+			OldPrice  int64  `json:"old_price"`
+			NewPrice  int64  `json:"new_price"`
+			NewStatus string `json:"new_status"`
+			ReadAt    string `json:"read_at"`
+		} `json:"items"`
+		Unread     int64  `json:"unread"`
+		NextCursor string `json:"next_cursor"`
+	}
+	getFeed := func(token, query string) feed {
+		t.Helper()
+		resp := bearerRequest(t, "GET", ts.URL+"/api/notifications"+query, token, nil)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 from feed, got %d", resp.StatusCode)
+		}
+		var f feed
+		json.NewDecoder(resp.Body).Decode(&f)
+		return f
+	}
+
+	// A price increase moves the snapshot without an alert.
+	database.Exec("UPDATE listings SET price = 120000 WHERE id = 1")
+	runSchedulerOnce(t, database, m)
+	if f := getFeed(instantToken, ""); len(f.Items) != 0 {
+		t.Fatalf("expected no alert for a price increase, got %+v", f)
+	}
+
+	database.Exec("UPDATE listings SET price = 95000 WHERE id = 1")
+	runSchedulerOnce(t, database, m)
+	f := getFeed(instantToken, "")
+	if len(f.Items) != 1 || f.Items[0].Kind != "price_drop" || f.Items[0].ItemType != "listing" || f.Items[0].ItemID != 1 || f.Items[0].OldPrice != 120000 || f.Items[0].NewPrice != 95000 || f.Unread != 1 {
+		t.Fatalf("expected one price drop alert, got %+v", f)
+	}
+	if f := getFeed(quietToken, ""); len(f.Items) != 0 {
+		t.Fatalf("expected price drops muted, got %+v", f)
+	}
+	if msgs := m.messagesTo("instant@example.com"); len(msgs) != 1 || !strings.Contains(msgs[0].Body, "$120,000 MXN a $95,000 MXN") {
+		t.Fatalf("expected one instant email, got %+v", msgs)
+	}
+	if msgs := m.messagesTo("digest@example.com"); len(msgs) != 1 || msgs[0].Subject != "MAQZONE: resumen de tus avisos" {
+		t.Fatalf("expected first digest, got %+v", msgs)
+	}
+
+	database.Exec("UPDATE listings SET status = 'sold' WHERE id = 1")
+	runSchedulerOnce(t, database, m)
+	f = getFeed(instantToken, "?limit=1")
+	if len(f.Items) != 1 || f.Items[0].Kind != "status_change" || f.Items[0].NewStatus != "sold" || f.Unread != 2 || f.NextCursor == "" {
+		t.Fatalf("expected status change alert first, got %+v", f)
+	}
+	if older := getFeed(instantToken, "?limit=1&cursor="+f.NextCursor); len(older.Items) != 1 || older.Items[0].Kind != "price_drop" {
+		t.Fatalf("expected price drop on the next page, got %+v", older)
+	}
+	if f := getFeed(quietToken, ""); len(f.Items) != 1 || f.Items[0].Kind != "status_change" {
+		t.Fatalf("expected status change for muted price drops, got %+v", f)
+	}
+	if msgs := m.messagesTo("quiet@example.com"); len(msgs) != 0 {
+		t.Fatalf("expected no email with email off, got %+v", msgs)
+	}
+	if msgs := m.messagesTo("digest@example.com"); len(msgs) != 1 {
+		t.Fatalf("expected the next digest to wait a day, got %+v", msgs)
+	}
+
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/notifications/"+itoa(int(f.Items[0].ID))+"/read", digestToken, nil)
+	resp.Body.Close()
+	if f := getFeed(instantToken, "?unread=1"); len(f.Items) != 2 {
+		t.Fatalf("expected other users unable to mark alerts read, got %+v", f)
+	}
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/notifications/"+itoa(int(f.Items[0].ID))+"/read", instantToken, nil)
+	resp.Body.Close()
+	if f := getFeed(instantToken, "?unread=1"); len(f.Items) != 1 || f.Unread != 1 {
+		t.Fatalf("expected one unread alert, got %+v", f)
+	}
+	resp = bearerRequest(t, "PUT", ts.URL+"/api/notifications/read-all", instantToken, nil)
+	resp.Body.Close()
+	if f := getFeed(instantToken, ""); f.Unread != 0 || len(f.Items) != 2 || f.Items[1].ReadAt == "" {
+		t.Fatalf("expected all alerts read, got %+v", f)
+	}
+}
+
+// --- Watchlist ---
+
+func TestAuctionWatchlist(t *testing.T) {
+	ts, database := setupTestServer(t)
+	m := &recordingMailer{}
+	token, userID := registerTestUser(t, ts, "watcher@example.com")
+	bidderToken, bidderID := registerTestUser(t, ts, "bidder@example.com")
+	at := func(d time.Duration) string { return time.Now().UTC().Add(d).Format(time.RFC3339) }
+
+	resp := bearerRequest(t, "POST", ts.URL+"/api/watches/3", token, map[string]any{"lead_minutes": 0})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid lead time, got %d", resp.StatusCode)
+	}
+	resp = bearerRequest(t, "POST", ts.URL+"/api/watches/999", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown auction, got %d", resp.StatusCode)
+	}
+	database.Exec("UPDATE auctions SET end_time = ? WHERE id = 3", at(2*time.Hour))
+	resp = bearerRequest(t, "POST", ts.URL+"/api/watches/3", token, map[string]any{"lead_minutes": 30})
+	resp.Body.Close()
+	resp = bearerRequest(t, "POST", ts.URL+"/api/watches/3", bidderToken, nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "GET", ts.URL+"/api/watches/check/3", token, nil)
+	var check struct {
+		Watching bool `json:"watching"`
+		Watch    struct {
+			LeadMinutes int64 `json:"lead_minutes"`
+		} `json:"watch"`
+	}
+	json.NewDecoder(resp.Body).Decode(&check)
+	resp.Body.Close()
+	if !check.Watching || check.Watch.LeadMinutes != 30 {
+		t.Fatalf("expected watch with a 30 minute lead, got %+v", check)
+	}
+
+	type feed struct {
+		Items []struct {
+			Kind     string `json:"kind"`
+			ItemType string `json:"item_type"`
+			ItemID   int64  `json:"item_id"`
+			OldPrice int64  `json:"old_price"`
+			NewPrice int64  `json:"new_price"`
+			Body     string `json:"body"`
+		} `json:"items"`
+	}
+	getFeed := func(token string) feed {
+		t.Helper()
+		resp := bearerRequest(t, "GET", ts.URL+"/api/notifications", token, nil)
+		defer resp.Body.Close()
+		var f feed
+		json.NewDecoder(resp.Body).Decode(&f)
+		return f
+	}
+
+	// The auction is live and far from its end: nothing to report.
+	runSchedulerOnce(t, database, m)
+	if f := getFeed(token); len(f.Items) != 0 {
+		t.Fatalf("expected no alerts yet, got %+v", f)
+	}
+
+	database.Exec("UPDATE auctions SET current_bid = 45000, highest_bidder_id = ? WHERE id = 3", bidderID)
+	runSchedulerOnce(t, database, m)
+	f := getFeed(token)
+	if len(f.Items) != 1 || f.Items[0].Kind != "auction_bid" || f.Items[0].ItemType != "auction" || f.Items[0].ItemID != 3 ||
+		f.Items[0].OldPrice != 39900 || f.Items[0].NewPrice != 45000 {
+		t.Fatalf("expected a bid alert, got %+v", f)
+	}
+	if f := getFeed(bidderToken); len(f.Items) != 0 {
+		t.Fatalf("expected no alert for the bidder's own bid, got %+v", f)
+	}
+
+	database.Exec("UPDATE auctions SET current_bid = 46000, highest_bidder_id = ?, end_time = ? WHERE id = 3", userID, at(10*time.Minute))
+	runSchedulerOnce(t, database, m)
+	f = getFeed(token)
+	if len(f.Items) != 2 || f.Items[0].Kind != "auction_ending" {
+		t.Fatalf("expected an ending soon alert and no alert for the watcher's bid, got %+v", f)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/watches", token, nil)
+	var watches []struct {
+		AuctionID        int64  `json:"auction_id"`
+		Title            string `json:"title"`
+		Status           string `json:"status"`
+		CurrentBid       int64  `json:"current_bid"`
+		Leading          bool   `json:"leading"`
+		SecondsRemaining int64  `json:"seconds_remaining"`
+	}
+	json.NewDecoder(resp.Body).Decode(&watches)
+	resp.Body.Close()
+	if len(watches) != 1 || watches[0].Title != "Cargador Cat 950H" || watches[0].CurrentBid != 46000 || !watches[0].Leading ||
+		watches[0].SecondsRemaining <= 0 || watches[0].SecondsRemaining > 600 {
+		t.Fatalf("expected the watched auction with its bid and time left, got %+v", watches)
+	}
+
+	database.Exec("UPDATE auctions SET status = 'closed', current_bid = 53000 WHERE id = 3")
+	runSchedulerOnce(t, database, m)
+	f = getFeed(token)
+	if len(f.Items) != 3 || f.Items[0].Kind != "auction_result" || !strings.Contains(f.Items[0].Body, "Ganaste") {
+		t.Fatalf("expected a single result alert, got %+v", f)
+	}
+	if f := getFeed(bidderToken); len(f.Items) != 3 || f.Items[0].Kind != "auction_result" || !strings.Contains(f.Items[0].Body, "venta por $53,000 MXN") {
+		t.Fatalf("expected the other watcher to hear the result, got %+v", f)
+	}
+
+	database.Exec("UPDATE auctions SET status = 'scheduled', start_time = ?, end_time = ? WHERE id = 1", at(20*time.Minute), at(3*time.Hour))
+	resp = bearerRequest(t, "POST", ts.URL+"/api/watches/1", token, nil)
+	resp.Body.Close()
+	runSchedulerOnce(t, database, m)
+	if f := getFeed(token); len(f.Items) != 4 || f.Items[0].Kind != "auction_starting" || f.Items[0].ItemID != 1 {
+		t.Fatalf("expected a starting soon alert with the default lead time, got %+v", f)
+	}
+
+	resp = bearerRequest(t, "DELETE", ts.URL+"/api/watches/1", token, nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "GET", ts.URL+"/api/watches", token, nil)
+	watches = nil
+	json.NewDecoder(resp.Body).Decode(&watches)
+	resp.Body.Close()
+	if len(watches) != 1 || watches[0].AuctionID != 3 {
+		t.Fatalf("expected only auction 3 left, got %+v", watches)
+	}
+}
+
+func TestAlertEmailsAreBatched(t *testing.T) {
+	ts, database := setupTestServer(t)
+	m := &recordingMailer{}
+	_, userID := registerTestUser(t, ts, "busy@example.com")
+	if _, err := database.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 60)
+		INSERT INTO notifications (user_id, kind, item_type, item_id, title, body)
+		SELECT ?, 'price_drop', 'listing', 1, 'Bajó de precio', 'Aviso ' || i FROM n`, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	runSchedulerOnce(t, database, m)
+	if sent := len(m.messagesTo("busy@example.com")); sent != 50 {
+		t.Fatalf("expected one tick to stop at 50 emails, got %d", sent)
+	}
+	runSchedulerOnce(t, database, m)
+	if sent := len(m.messagesTo("busy@example.com")); sent != 60 {
+		t.Fatalf("expected the next tick to send the rest, got %d", sent)
+	}
+}
+
+func TestWatchlistToleratesBadAuctionTimes(t *testing.T) {
+	ts, database := setupTestServer(t)
+	m := &recordingMailer{}
+	token, _ := registerTestUser(t, ts, "watcher@example.com")
+	for _, id := range []string{"2", "3"} {
+		resp := bearerRequest(t, "POST", ts.URL+"/api/watches/"+id, token, nil)
+		resp.Body.Close()
+	}
+	database.Exec("UPDATE auctions SET end_time = 'pronto' WHERE id = 2")
+	database.Exec("UPDATE auctions SET current_bid = 45000, end_time = ? WHERE id = 3", time.Now().UTC().Add(10*time.Minute).Format(time.RFC3339))
+
+	// One auction with an unreadable end time must not hold back the others.
+	runSchedulerOnce(t, database, m)
+	resp := bearerRequest(t, "GET", ts.URL+"/api/notifications", token, nil)
+	var feed struct {
+		Items []struct {
+			Kind   string `json:"kind"`
+			ItemID int64  `json:"item_id"`
+		} `json:"items"`
+	}
+	json.NewDecoder(resp.Body).Decode(&feed)
+	resp.Body.Close()
+	if len(feed.Items) != 2 || feed.Items[0].ItemID != 3 || feed.Items[1].ItemID != 3 {
+		t.Fatalf("expected bid and ending alerts for auction 3, got %+v", feed)
+	}
+
+	resp = bearerRequest(t, "GET", ts.URL+"/api/watches", token, nil)
+	var watches []struct {
+		AuctionID        int64 `json:"auction_id"`
+		SecondsRemaining int64 `json:"seconds_remaining"`
+	}
+	json.NewDecoder(resp.Body).Decode(&watches)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(watches) != 2 {
+		t.Fatalf("expected both watches listed, got %d %+v", resp.StatusCode, watches)
+	}
+	for _, w := range watches {
+		if w.AuctionID == 2 && w.SecondsRemaining != 0 {
+			t.Fatalf("expected no time left for an unreadable end time, got %+v", w)
+		}
+	}
+}
+
+// --- Saved searches ---
+
+func TestSavedSearches(t *testing.T) {
+	ts, database := setupTestServer(t)
+	m := &recordingMailer{}
+	token, _ := registerTestUser(t, ts, "fleet@example.com")
+	otherToken, _ := registerTestUser(t, ts, "other@example.com")
+
+	for _, body := range []map[string]any{
+		{"query": "q=retroexcavadora"},
+		{"name": "Retros", "query": "type=trucks"},
+		{"name": "Retros", "query": "category=no-existe"},
+		{"name": "Retros", "query": "price_max=barato"},
+		{"name": "Retros", "query": "q=de la"},
+		{"name": "Retros", "query": "status=inactive"},
+	} {
+		resp := bearerRequest(t, "POST", ts.URL+"/api/saved-searches", token, body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %v, got %d", body, resp.StatusCode)
+		}
+	}
+
+	resp := bearerRequest(t, "POST", ts.URL+"/api/saved-searches", token, map[string]any{
+		"name": "Retros baratas", "query": "q=retroexcavadoras&type=listings&price_max=60000&sort=price_asc",
+	})
+	var search struct {
+		ID        int64  `json:"id"`
+		ItemTypes string `json:"item_types"`
+		Query     string `json:"query"`
+		Notify    int64  `json:"notify"`
+	}
+	json.NewDecoder(resp.Body).Decode(&search)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || search.ItemTypes != "listing" || search.Notify != 1 || strings.Contains(search.Query, "sort") {
+		t.Fatalf("expected saved search without the sort, got %d %+v", resp.StatusCode, search)
+	}
+	searchPath := ts.URL + "/api/saved-searches/" + itoa(int(search.ID))
+
+	type summary struct {
+		MatchCount int64 `json:"match_count"`
+		NewCount   int64 `json:"new_count"`
+	}
+	getSummary := func() summary {
+		t.Helper()
+		resp := bearerRequest(t, "GET", ts.URL+"/api/saved-searches", token, nil)
+		defer resp.Body.Close()
+		var list []summary
+		json.NewDecoder(resp.Body).Decode(&list)
+		if len(list) != 1 {
+			t.Fatalf("expected one saved search, got %+v", list)
+		}
+		return list[0]
+	}
+	createListing := func(title string, price int) int {
+		t.Helper()
+		resp := adminRequest(t, "POST", ts.URL+"/api/admin/listings", map[string]any{
+			"title": title, "description": "Lista para trabajar.", "location": "Monterrey, MX",
+			"price": price, "sale_type": "direct", "year": 2019,
+		})
+		defer resp.Body.Close()
+		var created map[string]any
+		json.NewDecoder(resp.Body).Decode(&created)
+		return int(created["id"].(float64))
+	}
+
+	// Retroexcavadora 416F already matched when the search was saved, so a
+	// change to it is not a new match.
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/3", map[string]any{
+		"title": "Retroexcavadora 416F", "description": "Unidad en excelente estado operativo.", "location": "Puebla, MX",
+		"price": 33000, "sale_type": "direct", "year": 2015, "status": "active",
+	})
+	resp.Body.Close()
+	createListing("Retroexcavadora JCB 3CX", 55000)
+	expensive := createListing("Retroexcavadora Case 580N", 90000)
+	resp = adminRequest(t, "POST", ts.URL+"/api/admin/auctions", map[string]any{
+		"title": "Retroexcavadora Deere 310L", "description": "Subasta", "location": "León, MX", "current_bid": 20000,
+		"end_time": time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+	})
+	resp.Body.Close()
+	runSchedulerOnce(t, database, m)
+
+	if s := getSummary(); s.MatchCount != 1 || s.NewCount != 1 {
+		t.Fatalf("expected one new match, got %+v", s)
+	}
+	resp = bearerRequest(t, "GET", ts.URL+"/api/notifications", token, nil)
+	var feed struct {
+		Items []struct {
+			Kind     string `json:"kind"`
+			ItemType string `json:"item_type"`
+			Title    string `json:"title"`
+			Body     string `json:"body"`
+		} `json:"items"`
+	}
+	json.NewDecoder(resp.Body).Decode(&feed)
+	resp.Body.Close()
+	if len(feed.Items) != 1 || feed.Items[0].Kind != "search_match" || feed.Items[0].ItemType != "listing" ||
+		!strings.Contains(feed.Items[0].Body, "Retroexcavadora JCB 3CX") || feed.Items[0].Title != "Nuevos resultados: Retros baratas" {
+		t.Fatalf("expected a search match alert, got %+v", feed)
+	}
+	if msgs := m.messagesTo("fleet@example.com"); len(msgs) != 1 {
+		t.Fatalf("expected one alert email, got %+v", msgs)
+	}
+
+	// A listing that later drops into the price range becomes a match.
+	resp = adminRequest(t, "PUT", ts.URL+"/api/admin/listings/"+itoa(expensive), map[string]any{
+		"title": "Retroexcavadora Case 580N", "description": "Lista para trabajar.", "location": "Monterrey, MX",
+		"price": 58000, "sale_type": "direct", "year": 2019, "status": "active",
+	})
+	resp.Body.Close()
+	runSchedulerOnce(t, database, m)
+	if s := getSummary(); s.MatchCount != 2 || s.NewCount != 2 {
+		t.Fatalf("expected two new matches, got %+v", s)
+	}
+
+	resp = bearerRequest(t, "GET", searchPath+"/matches", otherToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's search, got %d", resp.StatusCode)
+	}
+	resp = bearerRequest(t, "PUT", searchPath+"/seen", token, nil)
+	resp.Body.Close()
+	if s := getSummary(); s.MatchCount != 2 || s.NewCount != 0 {
+		t.Fatalf("expected matches seen, got %+v", s)
+	}
+	resp = bearerRequest(t, "GET", searchPath+"/matches", token, nil)
+	var matches []struct {
+		ItemID  int64 `json:"item_id"`
+		New     bool  `json:"new"`
+		Listing struct {
+			Title string `json:"title"`
+			Price int64  `json:"price"`
+		} `json:"listing"`
+	}
+	json.NewDecoder(resp.Body).Decode(&matches)
+	resp.Body.Close()
+	if len(matches) != 2 || matches[0].ItemID != int64(expensive) || matches[0].New || matches[0].Listing.Price != 58000 {
+		t.Fatalf("expected matches newest first with their listings, got %+v", matches)
+	}
+
+	// Changing the query starts over from what matches now.
+	resp = bearerRequest(t, "PUT", searchPath, token, map[string]any{"query": "q=retroexcavadora&type=auctions", "notify": 0})
+	json.NewDecoder(resp.Body).Decode(&search)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || search.ItemTypes != "auction" || search.Notify != 0 {
+		t.Fatalf("expected updated search, got %d %+v", resp.StatusCode, search)
+	}
+	if s := getSummary(); s.MatchCount != 0 {
+		t.Fatalf("expected matches reset, got %+v", s)
+	}
+
+	resp = bearerRequest(t, "DELETE", searchPath, token, nil)
+	resp.Body.Close()
+	resp = bearerRequest(t, "GET", searchPath+"/matches", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected deleted search gone, got %d", resp.StatusCode)
+	}
+}
+
 // --- Admin CRUD listings ---
 
 func TestCreateListing(t *testing.T) {
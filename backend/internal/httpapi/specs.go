@@ -0,0 +1,409 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// specKeyPattern keeps keys usable as spec.<key> query parameters. The _min
+// and _max suffixes are reserved for range filters.
+var specKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+const maxSpecTextLength = 200
+
+func validSpecType(t string) bool {
+	return t == "text" || t == "number" || t == "enum"
+}
+
+// handleListCategorySpecs returns the attributes that apply to a category,
+// including global and inherited ones, so clients can build spec forms and
+// filters.
+func (s *Server) handleListCategorySpecs(w http.ResponseWriter, r *http.Request) {
+	c, err := s.queries.GetCategoryBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "category not found")
+		return
+	}
+	items, err := s.queries.ListSpecAttributesForCategory(r.Context(), c.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list specifications")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) handleListSpecAttributes(w http.ResponseWriter, r *http.Request) {
+	items, err := s.queries.ListSpecAttributes(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list specifications")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+type specAttributeRequest struct {
+	CategoryID int64    `json:"category_id"`
+	Key        string   `json:"key"`
+	Label      string   `json:"label"`
+	Type       string   `json:"type"`
+	Unit       string   `json:"unit"`
+	Options    []string `json:"options"`
+	MinValue   float64  `json:"min_value"`
+	MaxValue   float64  `json:"max_value"`
+	Required   int64    `json:"required"`
+	Position   int64    `json:"position"`
+}
+
+// validateSpecAttribute checks the fields that may change on update; typ is
+// the attribute's type.
+func validateSpecAttribute(req *specAttributeRequest, typ string) string {
+	req.Label = strings.TrimSpace(req.Label)
+	req.Unit = strings.TrimSpace(req.Unit)
+	if req.Label == "" {
+		return "label is required"
+	}
+	if req.Required != 0 && req.Required != 1 {
+		return "required must be 0 or 1"
+	}
+	if req.MinValue < 0 || req.MaxValue < 0 || (req.MaxValue != 0 && req.MaxValue < req.MinValue) {
+		return "min_value and max_value must be non-negative with max_value >= min_value"
+	}
+	if typ != "enum" {
+		req.Options = nil
+		return ""
+	}
+	seen := map[string]bool{}
+	options := req.Options[:0]
+	for _, o := range req.Options {
+		o = strings.TrimSpace(o)
+		if o == "" || seen[strings.ToLower(o)] {
+			continue
+		}
+		seen[strings.ToLower(o)] = true
+		options = append(options, o)
+	}
+	req.Options = options
+	if len(req.Options) == 0 {
+		return "enum attributes need options"
+	}
+	return ""
+}
+
+func (s *Server) handleCreateSpecAttribute(w http.ResponseWriter, r *http.Request) {
+	var req specAttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Key = strings.ToLower(strings.TrimSpace(req.Key))
+	if !specKeyPattern.MatchString(req.Key) || strings.HasSuffix(req.Key, "_min") || strings.HasSuffix(req.Key, "_max") {
+		respondError(w, http.StatusBadRequest, "key must be lowercase letters, digits and underscores and not end in _min or _max")
+		return
+	}
+	if !validSpecType(req.Type) {
+		respondError(w, http.StatusBadRequest, "type must be text, number or enum")
+		return
+	}
+	if msg := validateSpecAttribute(&req, req.Type); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if req.CategoryID != 0 {
+		if _, err := s.queries.GetCategory(r.Context(), req.CategoryID); err != nil {
+			respondError(w, http.StatusBadRequest, "category not found")
+			return
+		}
+	}
+	inUse, err := s.queries.SpecKeyInUse(r.Context(), req.CategoryID, req.Key)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to create specification")
+		return
+	}
+	if inUse {
+		respondError(w, http.StatusConflict, "key is already defined for this category, a parent or a subcategory")
+		return
+	}
+	item, err := s.queries.CreateSpecAttribute(r.Context(), sqlc.CreateSpecAttributeParams{
+		CategoryID: req.CategoryID,
+		Key:        req.Key,
+		Label:      req.Label,
+		Type:       req.Type,
+		Unit:       req.Unit,
+		Options:    req.Options,
+		MinValue:   req.MinValue,
+		MaxValue:   req.MaxValue,
+		Required:   req.Required,
+		Position:   req.Position,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to create specification")
+		return
+	}
+	s.audit(r, auditEntry{Action: "spec_attribute.create", TargetType: "spec_attribute", TargetID: item.ID, After: item})
+	respondJSON(w, http.StatusCreated, item)
+}
+
+// handleUpdateSpecAttribute edits an attribute's presentation and validation
+// rules. Category, key and type cannot change once values may exist.
+func (s *Server) handleUpdateSpecAttribute(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req specAttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	before, err := s.queries.GetSpecAttribute(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "specification not found")
+		return
+	}
+	if msg := validateSpecAttribute(&req, before.Type); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	item, err := s.queries.UpdateSpecAttribute(r.Context(), sqlc.UpdateSpecAttributeParams{
+		ID:       id,
+		Label:    req.Label,
+		Unit:     req.Unit,
+		Options:  req.Options,
+		MinValue: req.MinValue,
+		MaxValue: req.MaxValue,
+		Required: req.Required,
+		Position: req.Position,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update specification")
+		return
+	}
+	s.audit(r, auditEntry{Action: "spec_attribute.update", TargetType: "spec_attribute", TargetID: id, Before: before, After: item})
+	respondJSON(w, http.StatusOK, item)
+}
+
+// handleDeleteSpecAttribute removes an attribute together with its values.
+func (s *Server) handleDeleteSpecAttribute(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	before, err := s.queries.GetSpecAttribute(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "specification not found")
+		return
+	}
+	if err := s.queries.DeleteSpecAttribute(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to delete specification")
+		return
+	}
+	s.audit(r, auditEntry{Action: "spec_attribute.delete", TargetType: "spec_attribute", TargetID: id, Before: before})
+	respondJSON(w, http.StatusOK, map[string]any{"deleted": id})
+}
+
+// normalizeSpecs validates submitted values against the attributes that apply
+// to an item and returns them keyed by attribute id, in storage form.
+func normalizeSpecs(attrs []sqlc.SpecAttribute, specs map[string]any) (map[int64]string, string) {
+	byKey := make(map[string]sqlc.SpecAttribute, len(attrs))
+	for _, a := range attrs {
+		byKey[a.Key] = a
+	}
+	out := make(map[int64]string, len(specs))
+	for key, raw := range specs {
+		a, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Sprintf("unknown specification %q for this item's categories", key)
+		}
+		if raw == nil {
+			continue
+		}
+		value, msg := normalizeSpecValue(a, raw)
+		if msg != "" {
+			return nil, fmt.Sprintf("%s: %s", key, msg)
+		}
+		out[a.ID] = value
+	}
+	for _, a := range attrs {
+		if _, ok := out[a.ID]; a.Required == 1 && !ok {
+			return nil, fmt.Sprintf("%s is required", a.Key)
+		}
+	}
+	return out, ""
+}
+
+func normalizeSpecValue(a sqlc.SpecAttribute, raw any) (string, string) {
+	switch a.Type {
+	case "number":
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", "must be a number"
+			}
+			n = parsed
+		default:
+			return "", "must be a number"
+		}
+		if n < a.MinValue || (a.MaxValue != 0 && n > a.MaxValue) {
+			if a.MaxValue != 0 {
+				return "", fmt.Sprintf("must be between %g and %g", a.MinValue, a.MaxValue)
+			}
+			return "", fmt.Sprintf("must be at least %g", a.MinValue)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), ""
+	case "enum":
+		v, ok := raw.(string)
+		if !ok {
+			return "", "must be one of " + strings.Join(a.Options, ", ")
+		}
+		for _, o := range a.Options {
+			if strings.EqualFold(o, strings.TrimSpace(v)) {
+				return o, ""
+			}
+		}
+		return "", "must be one of " + strings.Join(a.Options, ", ")
+	default:
+		v, ok := raw.(string)
+		v = strings.TrimSpace(v)
+		if !ok || v == "" {
+			return "", "must be a non-empty string"
+		}
+		if len(v) > maxSpecTextLength {
+			return "", fmt.Sprintf("must be at most %d characters", maxSpecTextLength)
+		}
+		return v, ""
+	}
+}
+
+type setSpecsRequest struct {
+	Specs map[string]any `json:"specs"`
+}
+
+// handleSetListingSpecs replaces a listing's specifications. Only attributes
+// that apply through the listing's categories are accepted.
+func (s *Server) handleSetListingSpecs(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.GetListing(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "listing not found")
+		return
+	}
+	s.setSpecs(w, r, "listing", id, s.queries.ListSpecAttributesForListing, s.queries.ListListingSpecs,
+		func(q *sqlc.Queries, values map[int64]string) error {
+			if err := q.ClearListingSpecs(r.Context(), id); err != nil {
+				return err
+			}
+			for attributeID, v := range values {
+				if err := q.SetListingSpec(r.Context(), id, attributeID, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// handleSetAuctionSpecs replaces an auction's specifications.
+func (s *Server) handleSetAuctionSpecs(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.queries.GetAuction(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+	s.setSpecs(w, r, "auction", id, s.queries.ListSpecAttributesForAuction, s.queries.ListAuctionSpecs,
+		func(q *sqlc.Queries, values map[int64]string) error {
+			if err := q.ClearAuctionSpecs(r.Context(), id); err != nil {
+				return err
+			}
+			for attributeID, v := range values {
+				if err := q.SetAuctionSpec(r.Context(), id, attributeID, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (s *Server) setSpecs(
+	w http.ResponseWriter, r *http.Request, targetType string, id int64,
+	attributes func(context.Context, int64) ([]sqlc.SpecAttribute, error),
+	list func(context.Context, int64) ([]sqlc.SpecValue, error),
+	replace func(q *sqlc.Queries, values map[int64]string) error,
+) {
+	var req setSpecsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	attrs, err := attributes(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to set specifications")
+		return
+	}
+	values, msg := normalizeSpecs(attrs, req.Specs)
+	if msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	before, _ := list(r.Context(), id)
+	if err := s.queries.ExecTx(r.Context(), func(q *sqlc.Queries) error {
+		return replace(q, values)
+	}); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to set specifications")
+		return
+	}
+	after, err := list(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list specifications")
+		return
+	}
+	s.audit(r, auditEntry{Action: targetType + ".specs", TargetType: targetType, TargetID: id, Before: before, After: after})
+	respondJSON(w, http.StatusOK, after)
+}
+
+// parseSpecFilters reads spec.<key>=value and spec.<key>_min / _max catalog
+// parameters.
+func parseSpecFilters(r *http.Request) ([]sqlc.SpecFilter, string) {
+	var filters []sqlc.SpecFilter
+	for param, values := range r.URL.Query() {
+		key, ok := strings.CutPrefix(param, "spec.")
+		if !ok || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		v := values[0]
+		if base, isMin := strings.CutSuffix(key, "_min"); isMin || strings.HasSuffix(key, "_max") {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				return nil, "invalid " + param
+			}
+			if isMin {
+				filters = append(filters, sqlc.SpecFilter{Key: base, Min: n})
+			} else {
+				filters = append(filters, sqlc.SpecFilter{Key: strings.TrimSuffix(key, "_max"), Max: n})
+			}
+			continue
+		}
+		filters = append(filters, sqlc.SpecFilter{Key: key, Value: v})
+	}
+	return filters, ""
+}
@@ -0,0 +1,125 @@
+package httpapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	defaultWatchLeadMinutes = 60
+	maxWatchLeadMinutes     = 7 * 24 * 60
+)
+
+type watchRequest struct {
+	// LeadMinutes is how long before the start and the end to alert; omitted
+	// keeps the current value, or the default for a new watch.
+	LeadMinutes *int64 `json:"lead_minutes"`
+}
+
+// handleWatchAuction adds an auction to the caller's watchlist, or changes the
+// lead time of an existing watch.
+func (s *Server) handleWatchAuction(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	auctionID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req watchRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	auction, err := s.queries.GetAuction(r.Context(), auctionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "auction not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load auction")
+		return
+	}
+	if ok, err := s.canViewAuction(r.Context(), auction); err != nil || !ok {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+	lead := int64(defaultWatchLeadMinutes)
+	if existing, err := s.queries.GetAuctionWatch(r.Context(), claims.UserID, auctionID); err == nil {
+		lead = existing.LeadMinutes
+	}
+	if req.LeadMinutes != nil {
+		if *req.LeadMinutes < 1 || *req.LeadMinutes > maxWatchLeadMinutes {
+			respondError(w, http.StatusBadRequest, "lead_minutes must be between 1 and 10080")
+			return
+		}
+		lead = *req.LeadMinutes
+	}
+	watch, err := s.queries.WatchAuction(r.Context(), claims.UserID, auctionID, lead)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to watch auction")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"watching": true, "watch": watch})
+}
+
+func (s *Server) handleUnwatchAuction(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	auctionID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if err := s.queries.UnwatchAuction(r.Context(), claims.UserID, auctionID); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to unwatch auction")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"watching": false})
+}
+
+func (s *Server) handleIsWatching(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondJSON(w, http.StatusOK, map[string]any{"watching": false})
+		return
+	}
+	auctionID, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	watch, err := s.queries.GetAuctionWatch(r.Context(), claims.UserID, auctionID)
+	if err != nil {
+		respondJSON(w, http.StatusOK, map[string]any{"watching": false})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"watching": true, "watch": watch})
+}
+
+// handleGetUserWatches returns the caller's watched auctions with their
+// current bid and the time left to start and to end.
+func (s *Server) handleGetUserWatches(w http.ResponseWriter, r *http.Request) {
+	claims := GetClaims(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	items, err := s.queries.ListUserWatches(r.Context(), claims.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load watchlist")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
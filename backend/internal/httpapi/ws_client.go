@@ -19,6 +19,8 @@ type WSClient struct {
 	send   chan []byte
 	hub    *Hub
 	roomID int64
+	// userID is set instead of roomID on personal channel connections.
+	userID int64
 	logger zerolog.Logger
 }
 
@@ -34,7 +36,11 @@ func NewWSClient(conn *websocket.Conn, hub *Hub, roomID int64, logger zerolog.Lo
 
 func (c *WSClient) ReadPump() {
 	defer func() {
-		c.hub.Unsubscribe(c.roomID, c)
+		if c.userID != 0 {
+			c.hub.UnsubscribeUser(c.userID, c)
+		} else {
+			c.hub.Unsubscribe(c.roomID, c)
+		}
 		c.conn.Close()
 	}()
 
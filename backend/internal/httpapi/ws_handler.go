@@ -1,6 +1,9 @@
 package httpapi
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/websocket"
@@ -14,6 +17,8 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// handleWSAuction streams an auction's bid events. Invite-only auctions need
+// the access token of an invited user in the token query parameter.
 func (s *Server) handleWSAuction(w http.ResponseWriter, r *http.Request) {
 	if s.hub == nil {
 		respondError(w, http.StatusServiceUnavailable, "websocket hub not initialized")
@@ -26,6 +31,24 @@ func (s *Server) handleWSAuction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx := r.Context()
+	if claims, err := s.keys.ValidateToken(r.URL.Query().Get("token")); err == nil {
+		ctx = context.WithValue(ctx, userClaimsKey, claims)
+	}
+	auction, err := s.queries.GetAuction(ctx, auctionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "auction not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to load auction")
+		return
+	}
+	if ok, err := s.canViewAuction(ctx, auction); err != nil || !ok {
+		respondError(w, http.StatusNotFound, "auction not found")
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("ws: upgrade failed")
@@ -38,3 +61,35 @@ func (s *Server) handleWSAuction(w http.ResponseWriter, r *http.Request) {
 	go client.WritePump()
 	go client.ReadPump()
 }
+
+// handleWSUser opens the caller's personal channel, which carries their
+// notifications. Browsers cannot set headers on WebSocket requests, so the
+// access token comes in the token query parameter.
+func (s *Server) handleWSUser(w http.ResponseWriter, r *http.Request) {
+	if s.hub == nil {
+		respondError(w, http.StatusServiceUnavailable, "websocket hub not initialized")
+		return
+	}
+
+	claims, err := s.keys.ValidateToken(r.URL.Query().Get("token"))
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+	if s.respondClosedAccount(w, r, claims.UserID) {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("ws: upgrade failed")
+		return
+	}
+
+	client := NewWSClient(conn, s.hub, 0, s.logger)
+	client.userID = claims.UserID
+	s.hub.SubscribeUser(claims.UserID, client)
+
+	go client.WritePump()
+	go client.ReadPump()
+}
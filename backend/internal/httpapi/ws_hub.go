@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	"github.com/rs/zerolog"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
 )
 
 type WSMessage struct {
@@ -16,17 +18,21 @@ type WSMessage struct {
 	Status    string `json:"status,omitempty"`
 	EndTime   string `json:"end_time,omitempty"`
 	UserID    int64  `json:"user_id,omitempty"`
+
+	Notification *sqlc.Notification `json:"notification,omitempty"`
 }
 
 type Hub struct {
 	mu     sync.RWMutex
 	rooms  map[int64]map[*WSClient]bool
+	users  map[int64]map[*WSClient]bool
 	logger zerolog.Logger
 }
 
 func NewHub(logger zerolog.Logger) *Hub {
 	return &Hub{
 		rooms:  make(map[int64]map[*WSClient]bool),
+		users:  make(map[int64]map[*WSClient]bool),
 		logger: logger,
 	}
 }
@@ -51,6 +57,45 @@ func (h *Hub) Unsubscribe(auctionID int64, client *WSClient) {
 	}
 }
 
+func (h *Hub) SubscribeUser(userID int64, client *WSClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.users[userID] == nil {
+		h.users[userID] = make(map[*WSClient]bool)
+	}
+	h.users[userID][client] = true
+}
+
+func (h *Hub) UnsubscribeUser(userID int64, client *WSClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if conns, ok := h.users[userID]; ok {
+		delete(conns, client)
+		if len(conns) == 0 {
+			delete(h.users, userID)
+		}
+	}
+}
+
+// NotifyUser satisfies the scheduler.Notifier interface.
+func (h *Hub) NotifyUser(userID int64, n sqlc.Notification) {
+	data, err := json.Marshal(WSMessage{Type: "notification", UserID: userID, Notification: &n})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("ws: failed to marshal message")
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.users[userID] {
+		select {
+		case client.send <- data:
+		default:
+			// Client buffer full, skip
+		}
+	}
+}
+
 // BroadcastStatus satisfies the scheduler.Broadcaster interface.
 func (h *Hub) BroadcastStatus(auctionID int64, status string) {
 	h.Broadcast(auctionID, WSMessage{
@@ -0,0 +1,246 @@
+// Package imaging decodes uploaded photos, applies their EXIF orientation and
+// produces resized variants using only the standard library. Re-encoding
+// drops every metadata segment, so stored images carry no EXIF (GPS
+// positions, camera serials and the like).
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+var (
+	ErrUnsupported = errors.New("imaging: only JPEG and PNG images are supported")
+	ErrTooLarge    = errors.New("imaging: image dimensions are too large")
+)
+
+// MaxPixels bounds width*height before a full decode so a small file cannot
+// expand into gigabytes of pixels.
+const MaxPixels = 50_000_000
+
+// Image is a decoded, upright photo.
+type Image struct {
+	image.Image
+	// Format is "jpeg" or "png"; variants are encoded in the same format.
+	Format string
+}
+
+// Decode reads a JPEG or PNG and rotates it according to its EXIF
+// orientation.
+func Decode(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, ErrUnsupported
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	var img image.Image
+	if format == "jpeg" {
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	} else {
+		img, err = png.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+	return &Image{Image: img, Format: format}, nil
+}
+
+// ContentType is the MIME type of the image's format.
+func (i *Image) ContentType() string {
+	if i.Format == "png" {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// Ext is the file extension for the image's format.
+func (i *Image) Ext() string {
+	if i.Format == "png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// Encode writes img in the format of i.
+func (i *Image) Encode(w io.Writer, img image.Image) error {
+	if i.Format == "png" {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+}
+
+// exifOrientation returns the EXIF orientation tag (1-8) of a JPEG, or 1 when
+// there is none.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Start of scan: metadata segments come before it.
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		e := ifd + 2 + n*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[e:]) == 0x0112 {
+			o := int(order.Uint16(tiff[e+8:]))
+			if o < 1 || o > 8 {
+				return 1
+			}
+			return o
+		}
+	}
+	return 1
+}
+
+// orient applies an EXIF orientation so the result displays upright.
+func orient(img image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// Fit scales img down to fit within maxW x maxH, keeping its aspect ratio.
+// Smaller images are returned unchanged.
+func Fit(img image.Image, maxW, maxH int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxW && h <= maxH {
+		return img
+	}
+	if w*maxH > h*maxW {
+		h = max(1, h*maxW/w)
+		w = maxW
+	} else {
+		w = max(1, w*maxH/h)
+		h = maxH
+	}
+	return resize(img, w, h)
+}
+
+// Fill scales and center-crops img to exactly size x size, for thumbnails.
+func Fill(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	square := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	if side <= size {
+		return square
+	}
+	return resize(square, size, size)
+}
+
+// resize downsamples by averaging the source pixels under each destination
+// pixel (a box filter), which avoids the aliasing of nearest-neighbour
+// sampling when shrinking photos.
+func resize(img image.Image, w, h int) image.Image {
+	src := image.NewNRGBA(img.Bounds())
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := y * sh / h
+		y1 := max(y0+1, (y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := x * sw / w
+			x1 := max(x0+1, (x+1)*sw/w)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0], d[1], d[2], d[3] = uint8(r/n), uint8(g/n), uint8(bl/n), uint8(a/n)
+		}
+	}
+	return dst
+}
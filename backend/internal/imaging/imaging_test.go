@@ -0,0 +1,98 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// jpegWithOrientation encodes a w x h JPEG whose top-left pixel is red and
+// inserts an EXIF APP1 segment carrying the given orientation.
+func jpegWithOrientation(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	data := jpegWithOrientation(t, 32, 16, 6)
+	if o := exifOrientation(data); o != 6 {
+		t.Fatalf("expected orientation 6, got %d", o)
+	}
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 32 {
+		t.Fatalf("expected 16x32 after rotating, got %dx%d", b.Dx(), b.Dy())
+	}
+	// Rotating clockwise moves the top-left corner to the top-right.
+	if !isRed(img.At(14, 1)) || isRed(img.At(1, 1)) {
+		t.Fatal("expected the red corner at the top right")
+	}
+
+	var out bytes.Buffer
+	if err := img.Encode(&out, img.Image); err != nil {
+		t.Fatal(err)
+	}
+	if exifOrientation(out.Bytes()) != 1 || bytes.Contains(out.Bytes(), []byte("Exif")) {
+		t.Fatal("expected re-encoded image without EXIF")
+	}
+}
+
+func TestFitAndFill(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	if b := Fit(src, 100, 100).Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Fatalf("expected 100x50, got %v", b)
+	}
+	if b := Fit(src, 1000, 1000).Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Fatalf("expected small images unchanged, got %v", b)
+	}
+	if b := Fill(src, 64).Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Fatalf("expected 64x64 thumbnail, got %v", b)
+	}
+}
+
+func TestDecodeRejectsOtherFormats(t *testing.T) {
+	if _, err := Decode([]byte("%PDF-1.4 not an image")); err != ErrUnsupported {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
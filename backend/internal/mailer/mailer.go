@@ -0,0 +1,86 @@
+// Package mailer sends transactional email. Without SMTP settings messages are
+// only logged, which keeps development and tests free of a mail server.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Log writes messages to the logger instead of delivering them.
+type Log struct {
+	logger zerolog.Logger
+}
+
+func NewLog(logger zerolog.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	l.logger.Info().Str("to", msg.To).Str("subject", msg.Subject).Msg("email not sent (no SMTP configured)")
+	return nil
+}
+
+// SMTP delivers plain-text messages through a relay, using STARTTLS when the
+// server offers it.
+type SMTP struct {
+	addr     string
+	from     string
+	envelope string
+	auth     smtp.Auth
+}
+
+// NewSMTP accepts from either as a bare address or as "Name <address>".
+func NewSMTP(host, port, username, password, from string) *SMTP {
+	m := &SMTP{addr: net.JoinHostPort(host, port), from: from, envelope: from}
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		m.envelope = parsed.Address
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("mailer: header values must not contain newlines")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	// net/smtp has no context support; run it aside and give up on cancel.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.envelope, []string{msg.To}, []byte(b.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/mailer"
+)
+
+// digestInterval is how often users in digest mode get their summary email.
+const digestInterval = 24 * time.Hour
+
+// Each send gets a deadline, and one round of delivery sends at most
+// emailBatchSize messages; the rest wait for the next round.
+const (
+	emailSendTimeout = 15 * time.Second
+	emailBatchSize   = 50
+)
+
+// Notifier is implemented by the WebSocket hub so alerts reach users who are
+// connected to their personal channel.
+type Notifier interface {
+	NotifyUser(userID int64, n sqlc.Notification)
+}
+
+var listingStatusLabels = map[string]string{
+	"active":   "Disponible",
+	"sold":     "Vendido",
+	"inactive": "Retirado",
+	"auction":  "En subasta",
+}
+
+func statusLabel(status string) string {
+	if label, ok := listingStatusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+func formatPrice(amount int64) string {
+	digits := fmt.Sprint(amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return "$" + b.String() + " MXN"
+}
+
+// detectLikeChanges compares every liked listing with the price and status
+// last alerted for that like, records a notification for each price drop or
+// status change the user wants to hear about, and moves the snapshot forward
+// so each change is reported once. Price increases only move the snapshot.
+func (s *Scheduler) detectLikeChanges(ctx context.Context) {
+	var created []sqlc.Notification
+	push := map[int64]bool{}
+	err := s.queries.ExecTx(ctx, func(q *sqlc.Queries) error {
+		changes, err := q.ListLikeChanges(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if c.UserClosedAt == "" {
+				for _, params := range likeNotifications(c) {
+					n, err := q.CreateNotification(ctx, params)
+					if err != nil {
+						return err
+					}
+					created = append(created, n)
+					push[n.ID] = c.Preferences.WebSocket == 1
+				}
+			}
+			if err := q.UpdateLikeAlerted(ctx, c.LikeID, c.Price, c.Status); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("scheduler: failed to detect like changes")
+		return
+	}
+	for _, n := range created {
+		if s.notifier != nil && push[n.ID] {
+			s.notifier.NotifyUser(n.UserID, n)
+		}
+	}
+	if len(created) > 0 {
+		s.logger.Info().Int("count", len(created)).Msg("scheduler: like alerts created")
+	}
+}
+
+func likeNotifications(c sqlc.LikeChange) []sqlc.CreateNotificationParams {
+	emailState := "pending"
+	if c.Preferences.EmailMode == "off" {
+		emailState = "none"
+	}
+	var out []sqlc.CreateNotificationParams
+	if c.Preferences.PriceDrops == 1 && c.Price < c.AlertedPrice {
+		out = append(out, sqlc.CreateNotificationParams{
+			UserID:     c.UserID,
+			Kind:       "price_drop",
+			ItemType:   "listing",
+			ItemID:     c.ListingID,
+			Title:      "Bajó de precio: " + c.Title,
+			Body:       fmt.Sprintf("El precio de \"%s\" bajó de %s a %s.", c.Title, formatPrice(c.AlertedPrice), formatPrice(c.Price)),
+			OldPrice:   c.AlertedPrice,
+			NewPrice:   c.Price,
+			EmailState: emailState,
+		})
+	}
+	if c.Preferences.StatusChanges == 1 && c.Status != c.AlertedStatus {
+		out = append(out, sqlc.CreateNotificationParams{
+			UserID:     c.UserID,
+			Kind:       "status_change",
+			ItemType:   "listing",
+			ItemID:     c.ListingID,
+			Title:      fmt.Sprintf("%s: %s", c.Title, statusLabel(c.Status)),
+			Body:       fmt.Sprintf("\"%s\" cambió de %s a %s.", c.Title, statusLabel(c.AlertedStatus), statusLabel(c.Status)),
+			OldStatus:  c.AlertedStatus,
+			NewStatus:  c.Status,
+			EmailState: emailState,
+		})
+	}
+	return out
+}
+
+// sendPendingEmails emails queued notifications: one message per
+// notification in instant mode, and one summary a day in digest mode.
+// Notifications are marked sent only after the mailer accepts them, so a
+// failed send is retried on the next tick.
+func (s *Scheduler) sendPendingEmails(ctx context.Context) {
+	users, err := s.queries.ListPendingEmails(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("scheduler: failed to list pending emails")
+		return
+	}
+	sent := 0
+	for _, u := range users {
+		if sent >= emailBatchSize {
+			return
+		}
+		if u.EmailMode == "digest" && !digestDue(u.LastDigestAt) {
+			continue
+		}
+		pending, err := s.queries.ListPendingEmailNotifications(ctx, u.UserID)
+		if err != nil || len(pending) == 0 {
+			continue
+		}
+		if u.EmailMode == "digest" {
+			s.sendDigest(ctx, u, pending)
+			sent++
+			continue
+		}
+		for _, n := range pending {
+			if sent >= emailBatchSize {
+				return
+			}
+			sent++
+			body := fmt.Sprintf("Hola,\n\n%s\n\nPuedes ver tus notificaciones y cambiar tus preferencias en MAQZONE.\n", n.Body)
+			if err := s.sendEmail(ctx, mailer.Message{To: u.Email, Subject: "MAQZONE: " + n.Title, Body: body}); err != nil {
+				s.logger.Error().Err(err).Int64("user_id", u.UserID).Msg("scheduler: failed to send alert email")
+				break
+			}
+			if err := s.queries.MarkNotificationsEmailed(ctx, u.UserID, n.ID); err != nil {
+				s.logger.Error().Err(err).Int64("user_id", u.UserID).Msg("scheduler: failed to mark alert emailed")
+				break
+			}
+		}
+	}
+}
+
+func (s *Scheduler) sendDigest(ctx context.Context, u sqlc.PendingEmail, pending []sqlc.Notification) {
+	var b strings.Builder
+	b.WriteString("Hola,\n\nEstos son tus avisos de favoritos, subastas que sigues y búsquedas guardadas:\n\n")
+	for _, n := range pending {
+		fmt.Fprintf(&b, "- %s\n", n.Body)
+	}
+	b.WriteString("\nPuedes ver tus notificaciones y cambiar tus preferencias en MAQZONE.\n")
+	msg := mailer.Message{To: u.Email, Subject: "MAQZONE: resumen de tus avisos", Body: b.String()}
+	if err := s.sendEmail(ctx, msg); err != nil {
+		s.logger.Error().Err(err).Int64("user_id", u.UserID).Msg("scheduler: failed to send alert digest")
+		return
+	}
+	err := s.queries.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.MarkNotificationsEmailed(ctx, u.UserID, pending[len(pending)-1].ID); err != nil {
+			return err
+		}
+		return q.SetLastDigestAt(ctx, u.UserID)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Int64("user_id", u.UserID).Msg("scheduler: failed to mark digest emailed")
+	}
+}
+
+// sendEmail sends one message, giving up after emailSendTimeout so a slow
+// mail server cannot hold up the tick.
+func (s *Scheduler) sendEmail(ctx context.Context, msg mailer.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	defer cancel()
+	return s.mailer.Send(ctx, msg)
+}
+
+func digestDue(lastDigestAt string) bool {
+	if lastDigestAt == "" {
+		return true
+	}
+	last, err := time.Parse(time.DateTime, lastDigestAt)
+	if err != nil {
+		return true
+	}
+	return time.Since(last) >= digestInterval
+}
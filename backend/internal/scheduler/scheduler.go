@@ -2,11 +2,13 @@ package scheduler
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
 
 	sqlc "maqzone/backend/internal/db/sqlc"
+	"maqzone/backend/internal/mailer"
 )
 
 // Broadcaster is implemented by the WebSocket hub so the scheduler can
@@ -20,6 +22,11 @@ type Scheduler struct {
 	logger      zerolog.Logger
 	stop        chan struct{}
 	broadcaster Broadcaster
+	notifier    Notifier
+	mailer      mailer.Mailer
+	// mailDue wakes the email sender after a tick; it holds at most one
+	// wake-up, so ticks never wait on delivery.
+	mailDue chan struct{}
 }
 
 func New(queries *sqlc.Queries, logger zerolog.Logger) *Scheduler {
@@ -27,6 +34,8 @@ func New(queries *sqlc.Queries, logger zerolog.Logger) *Scheduler {
 		queries: queries,
 		logger:  logger,
 		stop:    make(chan struct{}),
+		mailer:  mailer.NewLog(logger),
+		mailDue: make(chan struct{}, 1),
 	}
 }
 
@@ -34,7 +43,28 @@ func (s *Scheduler) SetBroadcaster(b Broadcaster) {
 	s.broadcaster = b
 }
 
+func (s *Scheduler) SetNotifier(n Notifier) {
+	s.notifier = n
+}
+
+func (s *Scheduler) SetMailer(m mailer.Mailer) {
+	s.mailer = m
+}
+
+// Start runs the scheduler until Stop is called or ctx ends. Emails go out
+// from their own goroutine so a slow mail server cannot hold up opening and
+// closing auctions; Start returns once both have finished.
 func (s *Scheduler) Start(ctx context.Context) {
+	ticking := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.deliverEmails(ctx, ticking)
+	}()
+	defer wg.Wait()
+	defer close(ticking)
+
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -64,4 +94,104 @@ func (s *Scheduler) tick(ctx context.Context) {
 	if err := s.queries.CloseExpiredAuctions(ctx); err != nil {
 		s.logger.Error().Err(err).Msg("scheduler: failed to close auctions")
 	}
+	s.resolveConvertedAuctions(ctx)
+	s.reinstateSuspensions(ctx)
+	s.detectLikeChanges(ctx)
+	s.detectWatchChanges(ctx)
+	s.detectSearchMatches(ctx)
+	select {
+	case s.mailDue <- struct{}{}:
+	default:
+	}
+}
+
+// deliverEmails sends pending alert emails each time a tick asks for it,
+// until ticking is closed; a wake-up still queued then is served first, so
+// the alerts of the last tick go out.
+func (s *Scheduler) deliverEmails(ctx context.Context, ticking <-chan struct{}) {
+	for {
+		select {
+		case <-s.mailDue:
+			s.sendPendingEmails(ctx)
+		case <-ticking:
+			select {
+			case <-s.mailDue:
+				s.sendPendingEmails(ctx)
+			default:
+			}
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// resolveConvertedAuctions settles auctions created from a listing once they
+// close: the listing is marked sold, returned to the catalog when the admin
+// asked for it, or withdrawn.
+func (s *Scheduler) resolveConvertedAuctions(ctx context.Context) {
+	var links []sqlc.EndedLink
+	outcomes := map[int64]string{}
+	err := s.queries.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		links, err = q.ListEndedListingAuctionLinks(ctx)
+		if err != nil {
+			return err
+		}
+		for _, l := range links {
+			outcome, status := "unsold", "inactive"
+			switch {
+			case l.Sold:
+				outcome, status = "sold", "sold"
+			case l.ReturnIfUnsold == 1:
+				outcome, status = "returned", "active"
+			}
+			if err := q.SetListingStatus(ctx, l.ListingID, status); err != nil {
+				return err
+			}
+			if err := q.ResolveListingAuctionLink(ctx, l.AuctionID, outcome); err != nil {
+				return err
+			}
+			outcomes[l.AuctionID] = outcome
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("scheduler: failed to resolve converted auctions")
+		return
+	}
+	for _, l := range links {
+		s.logger.Info().Int64("auction_id", l.AuctionID).Int64("listing_id", l.ListingID).Str("outcome", outcomes[l.AuctionID]).Msg("scheduler: converted auction ended")
+	}
+}
+
+// reinstateSuspensions lifts suspensions whose end date has passed and records
+// the change in each user's status history, with no actor.
+func (s *Scheduler) reinstateSuspensions(ctx context.Context) {
+	var reinstated []sqlc.User
+	err := s.queries.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		reinstated, err = q.ReinstateExpiredSuspensions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range reinstated {
+			if err := q.CreateStatusChange(ctx, sqlc.CreateStatusChangeParams{
+				UserID:     u.ID,
+				FromStatus: "suspended",
+				ToStatus:   u.Status,
+				Reason:     "suspension ended",
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("scheduler: failed to reinstate suspended users")
+		return
+	}
+	for _, u := range reinstated {
+		s.logger.Info().Int64("user_id", u.ID).Msg("scheduler: suspension ended, user reinstated")
+	}
 }
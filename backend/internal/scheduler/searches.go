@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// catalogChangeBatch caps how many changed items one tick checks against the
+// saved searches; the rest wait for the next tick.
+const catalogChangeBatch = 1000
+
+// detectSearchMatches checks the listings and auctions created or changed
+// since the last tick against every saved search. Items a search has not
+// matched before are recorded, and owners who asked for it get one
+// notification per search.
+func (s *Scheduler) detectSearchMatches(ctx context.Context) {
+	var created []sqlc.Notification
+	push := map[int64]bool{}
+	var matched int
+	err := s.queries.ExecTx(ctx, func(q *sqlc.Queries) error {
+		changes, err := q.ListCatalogChanges(ctx, catalogChangeBatch)
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		changed := map[string][]int64{}
+		for _, c := range changes {
+			changed[c.ItemType] = append(changed[c.ItemType], c.ItemID)
+		}
+		searches, err := q.ListSavedSearchesForMatching(ctx)
+		if err != nil {
+			return err
+		}
+		for _, search := range searches {
+			var found []sqlc.CatalogMatch
+			var foundType string
+			for _, itemType := range search.Types() {
+				ids, ok := changed[itemType]
+				if !ok {
+					continue
+				}
+				f := search.Filter
+				f.ViewerID = search.UserID
+				items, err := q.MatchCatalogItems(ctx, itemType, f, search.MatchQuery, ids)
+				if err != nil {
+					return err
+				}
+				for _, item := range items {
+					added, err := q.AddSavedSearchMatch(ctx, search.ID, itemType, item.ID, 0)
+					if err != nil {
+						return err
+					}
+					if added {
+						found = append(found, item)
+						foundType = itemType
+					}
+				}
+			}
+			matched += len(found)
+			if len(found) == 0 || search.Notify == 0 || search.UserClosedAt != "" {
+				continue
+			}
+			n, err := q.CreateNotification(ctx, searchNotification(search, found, foundType))
+			if err != nil {
+				return err
+			}
+			created = append(created, n)
+			push[n.ID] = search.Preferences.WebSocket == 1
+		}
+		return q.DeleteCatalogChanges(ctx, changes[len(changes)-1].ID)
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("scheduler: failed to match saved searches")
+		return
+	}
+	for _, n := range created {
+		if s.notifier != nil && push[n.ID] {
+			s.notifier.NotifyUser(n.UserID, n)
+		}
+	}
+	if matched > 0 {
+		s.logger.Info().Int("matches", matched).Int("notifications", len(created)).Msg("scheduler: saved search matches found")
+	}
+}
+
+// searchNotification summarizes a tick's new matches for one search. It
+// points at the last item found, which is of itemType.
+func searchNotification(search sqlc.SavedSearchOwner, found []sqlc.CatalogMatch, itemType string) sqlc.CreateNotificationParams {
+	emailState := "pending"
+	if search.Preferences.EmailMode == "off" {
+		emailState = "none"
+	}
+	last := found[len(found)-1]
+	body := fmt.Sprintf("\"%s\" coincide con tu búsqueda guardada \"%s\".", last.Title, search.Name)
+	if len(found) > 1 {
+		body = fmt.Sprintf("\"%s\" y %d más coinciden con tu búsqueda guardada \"%s\".", last.Title, len(found)-1, search.Name)
+	}
+	return sqlc.CreateNotificationParams{
+		UserID:     search.UserID,
+		Kind:       "search_match",
+		ItemType:   itemType,
+		ItemID:     last.ID,
+		Title:      "Nuevos resultados: " + search.Name,
+		Body:       body,
+		EmailState: emailState,
+	}
+}
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	sqlc "maqzone/backend/internal/db/sqlc"
+)
+
+// detectWatchChanges alerts watchers when an auction is about to start or end,
+// gets new bids, or ends. Starting, ending and result alerts go out once per
+// watch; an alert that is no longer timely (the auction started or ended
+// before it was due) is skipped rather than sent late. Bids placed by the
+// watcher do not alert them.
+func (s *Scheduler) detectWatchChanges(ctx context.Context) {
+	var created []sqlc.Notification
+	push := map[int64]bool{}
+	err := s.queries.ExecTx(ctx, func(q *sqlc.Queries) error {
+		changes, err := q.ListWatchChanges(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			params, update := watchNotifications(c)
+			if c.UserClosedAt == "" {
+				for _, p := range params {
+					n, err := q.CreateNotification(ctx, p)
+					if err != nil {
+						return err
+					}
+					created = append(created, n)
+					push[n.ID] = c.Preferences.WebSocket == 1
+				}
+			}
+			if err := q.UpdateWatchAlerted(ctx, update); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("scheduler: failed to detect watched auction changes")
+		return
+	}
+	for _, n := range created {
+		if s.notifier != nil && push[n.ID] {
+			s.notifier.NotifyUser(n.UserID, n)
+		}
+	}
+	if len(created) > 0 {
+		s.logger.Info().Int("count", len(created)).Msg("scheduler: watch alerts created")
+	}
+}
+
+func watchNotifications(c sqlc.WatchChange) ([]sqlc.CreateNotificationParams, sqlc.UpdateWatchAlertedParams) {
+	prefs := c.Preferences
+	emailState := "pending"
+	if prefs.EmailMode == "off" {
+		emailState = "none"
+	}
+	ended := c.Status == "closed" || c.Status == "sold"
+	update := sqlc.UpdateWatchAlertedParams{
+		ID:           c.ID,
+		AlertedBid:   c.CurrentBid,
+		StartingSent: c.StartingSent,
+		EndingSent:   c.EndingSent,
+		ResultSent:   c.ResultSent,
+	}
+	var out []sqlc.CreateNotificationParams
+	add := func(kind, title, body string) {
+		out = append(out, sqlc.CreateNotificationParams{
+			UserID:     c.UserID,
+			Kind:       kind,
+			ItemType:   "auction",
+			ItemID:     c.AuctionID,
+			Title:      title,
+			Body:       body,
+			OldPrice:   c.AlertedBid,
+			NewPrice:   c.CurrentBid,
+			NewStatus:  c.Status,
+			EmailState: emailState,
+		})
+	}
+
+	if c.StartingSent == 0 && (c.StartingDue || c.Status != "scheduled") {
+		if c.StartingDue && prefs.AuctionStarting == 1 {
+			add("auction_starting", "Comienza pronto: "+c.Title,
+				fmt.Sprintf("La subasta \"%s\" comienza en %s.", c.Title, minutesLabel(c.MinutesLeft)))
+		}
+		update.StartingSent = 1
+	}
+	if c.EndingSent == 0 && (c.EndingDue || ended) {
+		if c.EndingDue && prefs.AuctionEnding == 1 {
+			add("auction_ending", "Termina pronto: "+c.Title,
+				fmt.Sprintf("La subasta \"%s\" termina en %s. Puja actual: %s.", c.Title, minutesLabel(c.MinutesLeft), formatPrice(c.CurrentBid)))
+		}
+		update.EndingSent = 1
+	}
+	// A result alert reports the final bid, so new bids are folded into it.
+	if c.CurrentBid != c.AlertedBid && !ended && c.HighestBidderID != c.UserID && prefs.AuctionBids == 1 {
+		add("auction_bid", "Nueva puja: "+c.Title,
+			fmt.Sprintf("La puja actual de \"%s\" subió de %s a %s.", c.Title, formatPrice(c.AlertedBid), formatPrice(c.CurrentBid)))
+	}
+	if c.ResultSent == 0 && ended {
+		if prefs.AuctionResults == 1 {
+			var body string
+			switch {
+			case c.Sold && c.HighestBidderID == c.UserID:
+				body = fmt.Sprintf("Ganaste la subasta \"%s\" con %s.", c.Title, formatPrice(c.CurrentBid))
+			case c.Sold:
+				body = fmt.Sprintf("La subasta \"%s\" terminó con una venta por %s.", c.Title, formatPrice(c.CurrentBid))
+			default:
+				body = fmt.Sprintf("La subasta \"%s\" terminó sin venta.", c.Title)
+			}
+			add("auction_result", "Subasta terminada: "+c.Title, body)
+		}
+		update.ResultSent = 1
+	}
+	return out, update
+}
+
+func minutesLabel(n int64) string {
+	if n == 1 {
+		return "1 minuto"
+	}
+	return fmt.Sprintf("%d minutos", n)
+}
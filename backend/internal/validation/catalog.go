@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrState      = errors.New("state is not a Mexican federal entity")
+	ErrPostalCode = errors.New("postal code must be five digits")
+	ErrPhone      = errors.New("phone must have 10 digits")
+)
+
+// States is the official INEGI catalogue of the 32 federal entities, keyed by
+// their ISO 3166-2:MX code.
+var States = map[string]string{
+	"AGU": "Aguascalientes",
+	"BCN": "Baja California",
+	"BCS": "Baja California Sur",
+	"CAM": "Campeche",
+	"COA": "Coahuila de Zaragoza",
+	"COL": "Colima",
+	"CHP": "Chiapas",
+	"CHH": "Chihuahua",
+	"CMX": "Ciudad de México",
+	"DUR": "Durango",
+	"GUA": "Guanajuato",
+	"GRO": "Guerrero",
+	"HID": "Hidalgo",
+	"JAL": "Jalisco",
+	"MEX": "México",
+	"MIC": "Michoacán de Ocampo",
+	"MOR": "Morelos",
+	"NAY": "Nayarit",
+	"NLE": "Nuevo León",
+	"OAX": "Oaxaca",
+	"PUE": "Puebla",
+	"QUE": "Querétaro",
+	"ROO": "Quintana Roo",
+	"SLP": "San Luis Potosí",
+	"SIN": "Sinaloa",
+	"SON": "Sonora",
+	"TAB": "Tabasco",
+	"TAM": "Tamaulipas",
+	"TLA": "Tlaxcala",
+	"VER": "Veracruz de Ignacio de la Llave",
+	"YUC": "Yucatán",
+	"ZAC": "Zacatecas",
+}
+
+// stateAliases are common spellings that do not match a catalogue name.
+var stateAliases = map[string]string{
+	"cdmx":                  "CMX",
+	"df":                    "CMX",
+	"distrito federal":      "CMX",
+	"mexico df":             "CMX",
+	"coahuila":              "COA",
+	"michoacan":             "MIC",
+	"veracruz":              "VER",
+	"estado de mexico":      "MEX",
+	"edomex":                "MEX",
+	"edo mex":               "MEX",
+	"edo de mexico":         "MEX",
+	"queretaro de arteaga":  "QUE",
+	"nuevo leon":            "NLE",
+	"baja california norte": "BCN",
+}
+
+var stateLookup = func() map[string]string {
+	lookup := map[string]string{}
+	for code, name := range States {
+		lookup[foldState(code)] = name
+		lookup[foldState(name)] = name
+	}
+	for alias, code := range stateAliases {
+		lookup[alias] = States[code]
+	}
+	return lookup
+}()
+
+var accentFolder = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+// foldState lowercases, strips accents and punctuation, and collapses spaces
+// so "Nuevo León", "NUEVO LEON" and "nuevo-leon" compare equal.
+func foldState(s string) string {
+	var b strings.Builder
+	for _, r := range accentFolder.Replace(strings.ToLower(s)) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '_':
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// State returns the catalogue name for a state given by name, common alias
+// or ISO code.
+func State(raw string) (string, error) {
+	if name, ok := stateLookup[foldState(raw)]; ok {
+		return name, nil
+	}
+	return "", ErrState
+}
+
+var postalCodePattern = regexp.MustCompile(`^[0-9]{5}$`)
+
+// PostalCode validates a five-digit código postal. Codes start at 01000.
+func PostalCode(raw string) (string, error) {
+	code := strings.TrimSpace(raw)
+	if !postalCodePattern.MatchString(code) || strings.HasPrefix(code, "00") {
+		return "", ErrPostalCode
+	}
+	return code, nil
+}
+
+// Phone normalizes a Mexican phone number to its 10 national digits,
+// accepting separators and an optional +52 country code.
+func Phone(raw string) (string, error) {
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune(" -().+", r):
+		default:
+			return "", ErrPhone
+		}
+	}
+	d := digits.String()
+	if len(d) == 12 && strings.HasPrefix(d, "52") {
+		d = d[2:]
+	}
+	if len(d) != 10 {
+		return "", ErrPhone
+	}
+	return d, nil
+}
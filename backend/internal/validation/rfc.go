@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRFCLength     = errors.New("rfc must have 12 characters (persona moral) or 13 (persona física)")
+	ErrRFCFormat     = errors.New("rfc has an invalid format")
+	ErrRFCDate       = errors.New("rfc contains an invalid date")
+	ErrRFCCheckDigit = errors.New("rfc check digit does not match")
+)
+
+// Generic RFCs issued by SAT for the general public and foreign residents.
+// They do not follow the check digit rule.
+var genericRFCs = map[string]bool{
+	"XAXX010101000": true,
+	"XEXX010101000": true,
+}
+
+// rfcValues gives each character its value in the SAT check digit algorithm:
+// its position in "0-9 A-N & O-Z space Ñ".
+var rfcValues = func() map[rune]int {
+	values := map[rune]int{}
+	for i, c := range []rune("0123456789ABCDEFGHIJKLMN&OPQRSTUVWXYZ Ñ") {
+		values[c] = i
+	}
+	return values
+}()
+
+// RFC validates a Registro Federal de Contribuyentes and returns it in
+// upper case. A persona moral RFC is three letters, a YYMMDD date and a
+// three-character homoclave; a persona física RFC has four letters. The last
+// homoclave character is a mod-11 check digit.
+func RFC(raw string) (string, error) {
+	rfc := strings.ToUpper(strings.Join(strings.Fields(raw), ""))
+	rfc = strings.ReplaceAll(rfc, "-", "")
+	chars := []rune(rfc)
+
+	var letters int
+	switch len(chars) {
+	case 12:
+		letters = 3
+	case 13:
+		letters = 4
+	default:
+		return "", ErrRFCLength
+	}
+	if genericRFCs[rfc] {
+		return rfc, nil
+	}
+	for i, c := range chars {
+		switch {
+		case i < letters:
+			if !(c >= 'A' && c <= 'Z') && c != 'Ñ' && c != '&' {
+				return "", ErrRFCFormat
+			}
+		case i < letters+6:
+			if c < '0' || c > '9' {
+				return "", ErrRFCFormat
+			}
+		default:
+			if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+				return "", ErrRFCFormat
+			}
+		}
+	}
+	if _, err := time.Parse("060102", string(chars[letters:letters+6])); err != nil {
+		return "", ErrRFCDate
+	}
+	if rfcCheckDigit(chars[:len(chars)-1]) != chars[len(chars)-1] {
+		return "", ErrRFCCheckDigit
+	}
+	return rfc, nil
+}
+
+func rfcCheckDigit(base []rune) rune {
+	// Persona moral RFCs are padded with a leading space to 12 characters.
+	if len(base) == 11 {
+		base = append([]rune{' '}, base...)
+	}
+	sum := 0
+	for i, c := range base {
+		sum += rfcValues[c] * (13 - i)
+	}
+	switch rem := sum % 11; rem {
+	case 0:
+		return '0'
+	case 1:
+		return 'A'
+	default:
+		return rune('0' + 11 - rem)
+	}
+}
@@ -0,0 +1,78 @@
+// Package validation checks and normalizes the fiscal and contact data that
+// Mexican businesses provide at registration.
+package validation
+
+import (
+	"sort"
+	"strings"
+)
+
+// Errors maps a JSON field name to a human-readable problem with its value.
+type Errors map[string]string
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		parts = append(parts, f+": "+e[f])
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Err returns e as an error, or nil when no field failed.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Profile holds the user-editable registration fields, using the same JSON
+// names as the API.
+type Profile struct {
+	BusinessName        string
+	LegalRepresentative string
+	RFC                 string
+	StreetAddress       string
+	Colony              string
+	Municipality        string
+	PostalCode          string
+	City                string
+	State               string
+	Phone               string
+	Mobile              string
+}
+
+// Normalize trims every field, canonicalizes RFC, postal code, state and
+// phone numbers in place, and reports every invalid field at once. Empty
+// values are left alone; callers decide which fields are required.
+func (p *Profile) Normalize() Errors {
+	errs := Errors{}
+	for _, f := range []*string{
+		&p.BusinessName, &p.LegalRepresentative, &p.RFC, &p.StreetAddress, &p.Colony,
+		&p.Municipality, &p.PostalCode, &p.City, &p.State, &p.Phone, &p.Mobile,
+	} {
+		*f = strings.Join(strings.Fields(*f), " ")
+	}
+	check := func(field string, value *string, fn func(string) (string, error)) {
+		if *value == "" {
+			return
+		}
+		normalized, err := fn(*value)
+		if err != nil {
+			errs[field] = err.Error()
+			return
+		}
+		*value = normalized
+	}
+	check("rfc", &p.RFC, RFC)
+	check("postal_code", &p.PostalCode, PostalCode)
+	check("state", &p.State, State)
+	check("phone", &p.Phone, Phone)
+	check("mobile", &p.Mobile, Phone)
+	return errs
+}
@@ -0,0 +1,67 @@
+package validation_test
+
+import (
+	"errors"
+	"testing"
+
+	"maqzone/backend/internal/validation"
+)
+
+func TestRFC(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"SAT970701NN3", "SAT970701NN3", nil},   // persona moral
+		{"gode561231gr8", "GODE561231GR8", nil}, // persona física, lower case
+		{"GODE-561231-GR8", "GODE561231GR8", nil},
+		{"XAXX010101000", "XAXX010101000", nil}, // generic public RFC
+		{"SAT970701NN4", "", validation.ErrRFCCheckDigit},
+		{"GODE561331GR8", "", validation.ErrRFCDate},
+		{"G0DE561231GR8", "", validation.ErrRFCFormat},
+		{"SAT9707", "", validation.ErrRFCLength},
+	}
+	for _, c := range cases {
+		got, err := validation.RFC(c.in)
+		if !errors.Is(err, c.err) || got != c.want {
+			t.Errorf("RFC(%q) = %q, %v; want %q, %v", c.in, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	cases := map[string]string{
+		"Nuevo León":       "Nuevo León",
+		"NUEVO LEON":       "Nuevo León",
+		"cdmx":             "Ciudad de México",
+		"Estado de México": "México",
+		"JAL":              "Jalisco",
+		"veracruz":         "Veracruz de Ignacio de la Llave",
+	}
+	for in, want := range cases {
+		if got, err := validation.State(in); err != nil || got != want {
+			t.Errorf("State(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := validation.State("Texas"); !errors.Is(err, validation.ErrState) {
+		t.Errorf("State(Texas) should be rejected, got %v", err)
+	}
+}
+
+func TestProfileNormalize(t *testing.T) {
+	p := validation.Profile{
+		RFC:        " sat970701nn3 ",
+		PostalCode: "6470",
+		State:      "nuevo leon",
+		Phone:      "+52 (81) 1234-5678",
+		Mobile:     "123",
+	}
+	errs := p.Normalize()
+	if len(errs) != 2 || errs["postal_code"] == "" || errs["mobile"] == "" {
+		t.Fatalf("expected postal_code and mobile errors, got %v", errs)
+	}
+	if p.RFC != "SAT970701NN3" || p.State != "Nuevo León" || p.Phone != "8112345678" {
+		t.Fatalf("fields were not normalized: %+v", p)
+	}
+}
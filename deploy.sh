@@ -73,12 +73,10 @@ if [ ! -f "${DEPLOY_DIR}/.env" ]; then
     read -p "  Dominio: " DOMAIN_INPUT
   done
 
-  ADMIN_TOKEN_GEN=$(openssl rand -hex 32)
   JWT_SECRET_GEN=$(openssl rand -hex 64)
 
   cat > "${DEPLOY_DIR}/.env" <<EOF
 DOMAIN=${DOMAIN_INPUT}
-ADMIN_TOKEN=${ADMIN_TOKEN_GEN}
 JWT_SECRET=${JWT_SECRET_GEN}
 EOF
 
@@ -87,7 +85,6 @@ EOF
   echo -e "${GREEN}  │  GUARDA ESTO EN UN LUGAR SEGURO — NO LO COMPARTAS   │${NC}"
   echo -e "${GREEN}  ├──────────────────────────────────────────────────────┤${NC}"
   echo -e "${GREEN}  │  Dominio:     ${DOMAIN_INPUT}                        ${NC}"
-  echo -e "${GREEN}  │  ADMIN_TOKEN: ${ADMIN_TOKEN_GEN:0:24}...             ${NC}"
   echo -e "${GREEN}  │  Admin login: admin@maqzone.mx / Admin123!           │${NC}"
   echo -e "${GREEN}  │  (Cambia la contraseña al primer acceso)             │${NC}"
   echo -e "${GREEN}  └──────────────────────────────────────────────────────┘${NC}"
@@ -99,7 +96,7 @@ else
 fi
 
 # ── 5. Validar .env ───────────────────────────────────────────
-for VAR in DOMAIN ADMIN_TOKEN JWT_SECRET; do
+for VAR in DOMAIN JWT_SECRET; do
   VAL=$(grep "^${VAR}=" "${DEPLOY_DIR}/.env" 2>/dev/null | cut -d'=' -f2)
   if [[ -z "$VAL" || "$VAL" == "cambia_esto"* ]]; then
     log_err "Variable ${VAR} no configurada en .env"
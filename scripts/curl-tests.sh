@@ -39,18 +39,19 @@ if [ "${CHECK_API:-1}" = "1" ]; then
   STATUS=$(curl -sS -o /dev/null -w "%{http_code}" -X POST "${API_URL}/api/admin/auctions" -H "Content-Type: application/json" -d '{"title":"test"}')
   [ "$STATUS" = "401" ] || fail "admin should return 401 without token, got ${STATUS}"
 
-  # Test admin CRUD with token
-  TOKEN="${ADMIN_TOKEN:-change_me_please}"
+  # Test admin CRUD with a scoped API key (needs auctions:write).
+  # Create one from the admin panel or POST /api/admin/api-keys.
+  API_KEY="${API_KEY:?set API_KEY to a key with the auctions:write scope}"
   CREATED=$(curl -sS -X POST "${API_URL}/api/admin/auctions" \
     -H "Content-Type: application/json" \
-    -H "X-Admin-Token: ${TOKEN}" \
+    -H "X-API-Key: ${API_KEY}" \
     -d '{"title":"Smoke Test","description":"QA test","location":"SLP","end_time":"2026-12-31T23:59:59Z"}')
   echo "$CREATED" | grep -q "Smoke Test" || fail "admin create auction failed"
   AUCTION_ID=$(echo "$CREATED" | grep -o '"id":[0-9]*' | grep -o '[0-9]*')
 
   # Delete the test auction
   DEL_STATUS=$(curl -sS -o /dev/null -w "%{http_code}" -X DELETE "${API_URL}/api/admin/auctions/${AUCTION_ID}" \
-    -H "X-Admin-Token: ${TOKEN}")
+    -H "X-API-Key: ${API_KEY}")
   [ "$DEL_STATUS" = "200" ] || fail "admin delete auction failed, got ${DEL_STATUS}"
 fi
 